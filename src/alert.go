@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Timeout of the request posting price drop alerts to the webhook
+const alertWebhookTimeout = 10 * time.Second
+
+// Items whose price dropped by at least the threshold percent since the compared run, posted to -alert-webhook
+type priceDropAlert struct {
+	Threshold float64       `json:"threshold_percent"`
+	Previous  string        `json:"previous"`
+	Drops     []priceChange `json:"drops"`
+}
+
+// Function picks price changes of the comparison which are drops of at least threshold percent
+func newPriceDropAlert(diff priceDiff, threshold float64) priceDropAlert {
+	alert := priceDropAlert{Threshold: threshold, Previous: diff.Previous, Drops: []priceChange{}}
+	for _, change := range diff.Changed {
+		if change.DeltaPercent < 0 && -change.DeltaPercent >= threshold {
+			alert.Drops = append(alert.Drops, change)
+		}
+	}
+
+	return alert
+}
+
+// Function logs a warning for every price drop
+func (a priceDropAlert) Log() {
+	for _, drop := range a.Drops {
+		slog.Warn("Price drop alert", "item_id", drop.ItemID, "title", drop.Title, "old_price", drop.OldPrice, "new_price", drop.NewPrice, "drop_percent", -drop.DeltaPercent, "url", drop.ProductURL)
+	}
+}
+
+// Function posts the drops as JSON to the webhook. Errors are returned so they can be reported, but must not fail the run
+func (a priceDropAlert) Send(webhookURL string) error {
+	alertJSON, _ := json.Marshal(a)
+
+	client := &http.Client{Timeout: alertWebhookTimeout}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(alertJSON))
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't send price drop alert to %s: %s", webhookURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("ERROR::Price drop alert webhook %s answered with status %d", webhookURL, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Function returns results page of classic cards of the item IDs with their prices
+func pricedItemsPage(prices map[string]string, itemIDs ...string) string {
+	page := `<html><body><ul>`
+	for _, itemID := range itemIDs {
+		page += fmt.Sprintf(`<li class="s-item" id="item%[1]s"><a class="s-item__link" href="https://www.ebay.com/itm/%[1]s">`+
+			`<div class="s-item__title"><span role="heading">Dell Laptop %[1]s</span></div></a><span class="s-item__price">$%[2]s</span></li>`, itemID, prices[itemID])
+	}
+
+	return page + `</ul></body></html>`
+}
+
+func TestPriceDropAlert(t *testing.T) {
+	previousDir := t.TempDir()
+	first := newPageServer(t, pricedItemsPage(map[string]string{"111": "100.00", "222": "100.00", "333": "100.00"}, "111", "222", "333"))
+	err := run([]string{"-url", first.URL + "/sch/i.html", "-output", "json", "-output-dir", previousDir, "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//111 drops 25%, 222 10% and 333 gets more expensive
+	second := newPageServer(t, pricedItemsPage(map[string]string{"111": "75.00", "222": "90.00", "333": "120.00"}, "111", "222", "333"))
+
+	var mu sync.Mutex
+	alerts := []priceDropAlert{}
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alert := priceDropAlert{}
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		alerts = append(alerts, alert)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	tests := []struct {
+		threshold string
+		want      string
+	}{
+		{"20", "111"},
+		{"25", "111"},
+		{"5", "111,222"},
+		{"30", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.threshold, func(t *testing.T) {
+			mu.Lock()
+			alerts = alerts[:0]
+			mu.Unlock()
+
+			err := run([]string{"-url", second.URL + "/sch/i.html", "-output", "json", "-output-dir", t.TempDir(), "-delay", "0",
+				"-compare-prices", filepath.Join(previousDir, "items.json"), "-alert-drop", test.threshold, "-alert-webhook", webhook.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			//Nothing is posted without drops
+			if test.want == "" {
+				if len(alerts) != 0 {
+					t.Errorf("got alerts %+v, want none", alerts)
+				}
+				return
+			}
+			if len(alerts) != 1 {
+				t.Fatalf("webhook got %d alerts, want 1", len(alerts))
+			}
+
+			ids := []string{}
+			for _, drop := range alerts[0].Drops {
+				ids = append(ids, drop.ItemID)
+			}
+			if got := strings.Join(ids, ","); got != test.want {
+				t.Errorf("alert of items %s, want %s", got, test.want)
+			}
+			if drop := alerts[0].Drops[0]; drop.OldPrice != "100.00" || drop.NewPrice != "75.00" || drop.DeltaPercent != -25 || drop.ProductURL != "https://www.ebay.com/itm/111" {
+				t.Errorf("got drop %+v, want 111 from 100.00 to 75.00 by -25%%", drop)
+			}
+		})
+	}
+}
+
+func TestPriceDropAlertFlags(t *testing.T) {
+	tests := [][]string{
+		{"-alert-drop", "20"},
+		{"-alert-drop", "120", "-compare-prices", "items.json"},
+		{"-alert-drop", "-5", "-compare-prices", "items.json"},
+		{"-alert-webhook", "http://127.0.0.1:1/alerts", "-compare-prices", "items.json"},
+	}
+
+	for _, args := range tests {
+		err := run(append([]string{"-query", "laptop", "-output", "json"}, args...))
+		if exitCode(err) != exitBadFlags {
+			t.Errorf("%v: got %v, want bad flags error", args, err)
+		}
+	}
+}
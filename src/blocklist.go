@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Function reads seller names of -blocklist-file, one per line, skipping empty lines and # comments
+func readBlocklistFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't read blocklist file %s: %s", path, err)
+	}
+	defer file.Close()
+
+	names := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ERROR::Can't read blocklist file %s: %s", path, err)
+	}
+
+	return names, nil
+}
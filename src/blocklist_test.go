@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadBlocklistFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	err := os.WriteFile(path, []byte("# refurbishers\ncheap_refurbs\n\n  *_outlet  \n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := readBlocklistFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names, ","); got != "cheap_refurbs,*_outlet" {
+		t.Errorf("got names %s, want cheap_refurbs,*_outlet", got)
+	}
+
+	if _, err := readBlocklistFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("got no error for missing file")
+	}
+}
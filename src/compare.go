@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ebay-crawler/crawler"
+)
+
+// Name of the price comparison report written to the output directory
+const priceDiffFileName string = "price_diff.json"
+
+// Price movement between a previous run and this one, keyed by item ID
+type priceDiff struct {
+	Previous        string        `json:"previous"`
+	New             []diffItem    `json:"new"`
+	Removed         []diffItem    `json:"removed"`
+	Changed         []priceChange `json:"changed"`
+	CurrencyChanged []priceChange `json:"currency_changed"` // prices in different currencies can't be compared
+	Unparseable     []priceChange `json:"unparseable"`      // old or new price is not a number, e.g. "See price"
+	Unchanged       int           `json:"unchanged"`
+}
+
+// Item only found by one of the compared runs
+type diffItem struct {
+	ItemID     string `json:"item_id"`
+	Title      string `json:"title"`
+	Price      string `json:"price"`
+	Currency   string `json:"currency,omitempty"`
+	ProductURL string `json:"product_url"`
+}
+
+// Price of an item found by both runs
+type priceChange struct {
+	ItemID       string  `json:"item_id"`
+	Title        string  `json:"title"`
+	ProductURL   string  `json:"product_url,omitempty"`
+	OldPrice     string  `json:"old_price"`
+	NewPrice     string  `json:"new_price"`
+	OldCurrency  string  `json:"old_currency,omitempty"`
+	NewCurrency  string  `json:"new_currency,omitempty"`
+	DeltaPercent float64 `json:"delta_percent,omitempty"`
+}
+
+// Function loads items of a previous run from items.json or a directory of <itemID>.json files (searched recursively,
+// so per source subdirectories are included). Records without item ID, like summary.json, are skipped
+func loadPreviousItems(path string) (map[string]crawler.ItemInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't read previous run %s: %s", path, err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		err = filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			//Temporary files of atomic writes start with a dot
+			if !entry.IsDir() && filepath.Ext(filePath) == ".json" && !strings.HasPrefix(entry.Name(), ".") {
+				files = append(files, filePath)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ERROR::Can't read previous run %s: %s", path, err)
+		}
+	}
+
+	items := map[string]crawler.ItemInfo{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("ERROR::Can't read previous run file %s: %s", file, err)
+		}
+
+		records := []crawler.ItemInfo{}
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+			err = json.Unmarshal(data, &records)
+		} else {
+			record := crawler.ItemInfo{}
+			err = json.Unmarshal(data, &record)
+			records = append(records, record)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ERROR::Can't parse previous run file %s: %s", file, err)
+		}
+
+		for _, record := range records {
+			if record.ItemID != "" {
+				items[record.ItemID] = record
+			}
+		}
+	}
+
+	return items, nil
+}
+
+// Function compares prices of crawled items with the ones of the previous run
+func newPriceDiff(previousPath string, previous map[string]crawler.ItemInfo, items []crawler.ItemInfo) priceDiff {
+	diff := priceDiff{Previous: previousPath, New: []diffItem{}, Removed: []diffItem{}, Changed: []priceChange{}, CurrencyChanged: []priceChange{}, Unparseable: []priceChange{}}
+
+	current := map[string]bool{}
+	for _, item := range items {
+		if current[item.ItemID] {
+			continue
+		}
+		current[item.ItemID] = true
+
+		old, found := previous[item.ItemID]
+		if !found {
+			diff.New = append(diff.New, newDiffItem(item))
+			continue
+		}
+
+		change := priceChange{
+			ItemID:      item.ItemID,
+			Title:       item.Title,
+			ProductURL:  item.ProductURL,
+			OldPrice:    old.Price,
+			NewPrice:    item.Price,
+			OldCurrency: old.Currency,
+			NewCurrency: item.Currency,
+		}
+
+		oldPrice, oldErr := strconv.ParseFloat(old.Price, 64)
+		newPrice, newErr := strconv.ParseFloat(item.Price, 64)
+
+		switch {
+		case oldErr != nil || newErr != nil:
+			if old.Price != item.Price {
+				diff.Unparseable = append(diff.Unparseable, change)
+			} else {
+				diff.Unchanged++
+			}
+		case old.Currency != item.Currency:
+			diff.CurrencyChanged = append(diff.CurrencyChanged, change)
+		case oldPrice == newPrice:
+			diff.Unchanged++
+		default:
+			if oldPrice != 0 {
+				change.DeltaPercent = math.Round((newPrice-oldPrice)/oldPrice*10000) / 100
+			}
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+
+	for id, old := range previous {
+		if !current[id] {
+			diff.Removed = append(diff.Removed, newDiffItem(old))
+		}
+	}
+	sort.Slice(diff.Removed, func(i, j int) bool {
+		return diff.Removed[i].ItemID < diff.Removed[j].ItemID
+	})
+
+	return diff
+}
+
+func newDiffItem(item crawler.ItemInfo) diffItem {
+	return diffItem{ItemID: item.ItemID, Title: item.Title, Price: item.Price, Currency: item.Currency, ProductURL: item.ProductURL}
+}
+
+// Function logs price changes and counts of the comparison
+func (d priceDiff) Log() {
+	for _, change := range d.Changed {
+		slog.Info("Price changed", "item_id", change.ItemID, "old_price", change.OldPrice, "new_price", change.NewPrice, "delta_percent", math.Round(change.DeltaPercent*100)/100)
+	}
+	for _, change := range d.CurrencyChanged {
+		slog.Info("Currency changed", "item_id", change.ItemID, "old_price", change.OldPrice, "old_currency", change.OldCurrency, "new_price", change.NewPrice, "new_currency", change.NewCurrency)
+	}
+	for _, change := range d.Unparseable {
+		slog.Info("Price not comparable", "item_id", change.ItemID, "old_price", change.OldPrice, "new_price", change.NewPrice)
+	}
+
+	slog.Info("Price comparison",
+		"previous", d.Previous,
+		"new", len(d.New),
+		"removed", len(d.Removed),
+		"changed", len(d.Changed),
+		"currency_changed", len(d.CurrencyChanged),
+		"unparseable", len(d.Unparseable),
+		"unchanged", d.Unchanged,
+	)
+}
+
+// Function writes the comparison to price_diff.json in the output directory
+func (d priceDiff) Write() error {
+	diffJSON, _ := marshalOutputJSON(d)
+
+	return writeOutputFile(filepath.Join(outputDir, priceDiffFileName), diffJSON)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Settings loaded from -config file. JSON names match command-line flags, nil field means the flag default is kept.
+// Precedence: command-line flags override config file values, which override flag defaults
+type Config struct {
+	Seller             *string  `json:"seller"`
+	LimitPerSeller     *int     `json:"limit-per-seller"`
+	URL                *string  `json:"url"`
+	Query              *string  `json:"query"`
+	ItemID             *string  `json:"item-id"`
+	WatchIDsFile       *string  `json:"watch-ids-file"`
+	Condition          *string  `json:"condition"`
+	NoClobber          *bool    `json:"no-clobber"`
+	NoOverwrite        *bool    `json:"no-overwrite"`
+	BaseURL            *string  `json:"base-url"`
+	Domain             *string  `json:"domain"`
+	IncludeBanners     *bool    `json:"include-banners"`
+	IncludeRelated     *bool    `json:"include-related"`
+	MinPrice           *float64 `json:"min-price"`
+	MaxPrice           *float64 `json:"max-price"`
+	Include            *string  `json:"include"`
+	Exclude            *string  `json:"exclude"`
+	BlockSeller        *string  `json:"block-seller"`
+	BlocklistFile      *string  `json:"blocklist-file"`
+	Regex              *bool    `json:"regex"`
+	Incremental        *bool    `json:"incremental"`
+	OutputDir          *string  `json:"output-dir"`
+	Stdout             *bool    `json:"stdout"`
+	Output             *string  `json:"output"`
+	BufferSize         *int     `json:"buffer-size"`
+	Rotate             *string  `json:"rotate"`
+	SplitSize          *int     `json:"split-size"`
+	Template           *string  `json:"template"`
+	DB                 *string  `json:"db"`
+	Vacuum             *bool    `json:"vacuum"`
+	VacuumEvery        *int     `json:"vacuum-every"`
+	SQLiteJournalMode  *string  `json:"sqlite-journal-mode"`
+	SQLiteSynchronous  *string  `json:"sqlite-synchronous"`
+	URLsOnly           *bool    `json:"urls-only"`
+	MaxPages           *int     `json:"max-pages"`
+	MaxItems           *int     `json:"max-items"`
+	Sample             *int     `json:"sample"`
+	ItemsPerPage       *int     `json:"items-per-page"`
+	Sort               *string  `json:"sort"`
+	MinItemsPerPage    *int     `json:"min-items-per-page"`
+	MinSuccessRate     *float64 `json:"min-success-rate"`
+	Workers            *int     `json:"workers"`
+	ParallelParse      *int     `json:"parallel-parse"`
+	ConcurrentPages    *bool    `json:"concurrent-pages"`
+	MaxBodySize        *int64   `json:"max-body-size"`
+	Delay              *string  `json:"delay"`
+	RPM                *int     `json:"rpm"`
+	RPS                *float64 `json:"rps"`
+	FailuresFile       *string  `json:"failures-file"`
+	SeenDB             *string  `json:"seen-db"`
+	DedupKey           *string  `json:"dedup-key"`
+	StatsDAddr         *string  `json:"statsd-addr"`
+	MetricsAddr        *string  `json:"metrics-addr"`
+	Resume             *bool    `json:"resume"`
+	ComparePrices      *string  `json:"compare-prices"`
+	AlertDrop          *float64 `json:"alert-drop"`
+	AlertWebhook       *string  `json:"alert-webhook"`
+	Summary            *bool    `json:"summary"`
+	Layout             *string  `json:"layout"`
+	ItemClass          *string  `json:"item-class"`
+	LinkClass          *string  `json:"link-class"`
+	TitleClass         *string  `json:"title-class"`
+	SubtitleClass      *string  `json:"subtitle-class"`
+	ConditionClass     *string  `json:"condition-class"`
+	PriceClasses       *string  `json:"price-classes"`
+	ItemIDPattern      *string  `json:"item-id-pattern"`
+	OutputEncoding     *string  `json:"output-encoding"`
+	EncodingErrors     *string  `json:"encoding-errors"`
+	StripEmoji         *bool    `json:"strip-emoji"`
+	JSONPath           *string  `json:"jsonpath"`
+	AffiliateCampID    *string  `json:"affiliate-campid"`
+	AffiliateMkcID     *string  `json:"affiliate-mkcid"`
+	AffiliateMkrID     *string  `json:"affiliate-mkrid"`
+	AffiliateCustomID  *string  `json:"affiliate-customid"`
+	NormalizeCondition *bool    `json:"normalize-condition"`
+	PageProcessTimeout *string  `json:"page-process-timeout"`
+	UserAgent          *string  `json:"user-agent"`
+	Retries            *int     `json:"retries"`
+	Timeout            *string  `json:"timeout"`
+	PageTimeout        *string  `json:"page-timeout"`
+	RunTimeout         *string  `json:"run-timeout"`
+	PinCert            *string  `json:"pin-cert"`
+	Proxy              *string  `json:"proxy"`
+	AllowHost          *string  `json:"allow-host"`
+	Cookie             *string  `json:"cookie"`
+	CookieFile         *string  `json:"cookie-file"`
+	CacheDir           *string  `json:"cache-dir"`
+	Refresh            *bool    `json:"refresh"`
+	MinPhotos          *int     `json:"min-photos"`
+	StrictPhotos       *bool    `json:"strict-photos"`
+	FastShippingOnly   *bool    `json:"fast-shipping-only"`
+	SkipSponsored      *bool    `json:"skip-sponsored"`
+	Enrich             *bool    `json:"enrich"`
+	FollowVariations   *bool    `json:"follow-variations"`
+	RetryFailedItems   *bool    `json:"retry-failed-items"`
+	MaxEnrichFailures  *int     `json:"max-enrich-failures"`
+	LogLevel           *string  `json:"log-level"`
+	Verbose            *bool    `json:"verbose"`
+	LogJSON            *bool    `json:"log-json"`
+	PrettyProgress     *bool    `json:"pretty-progress"`
+	JSONIndent         *string  `json:"json-indent"`
+	Compact            *bool    `json:"compact"`
+}
+
+// Function to read config file, fields not known to Config are reported as error
+func loadConfig(path string) (Config, error) {
+	config := Config{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("ERROR::Can't read config file %s: %s", path, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(&config)
+	if err != nil {
+		return config, fmt.Errorf("ERROR::Can't parse config file %s: %s. Its fields must match command-line flag names (command-line flags override config file values, which override defaults)", path, err)
+	}
+
+	return config, nil
+}
+
+// Function sets flags from config values, skipping flags given on the command line so they take precedence
+func (config Config) apply(fs *flag.FlagSet, path string) error {
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	value := reflect.ValueOf(config)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if field.IsNil() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		if explicit[name] {
+			continue
+		}
+
+		err := fs.Set(name, fmt.Sprint(field.Elem().Interface()))
+		if err != nil {
+			return fmt.Errorf("ERROR::Invalid value of %s in config file %s: %s (command-line flags override config file values, which override defaults)", name, path, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// eBay Partner Network campaign parameters appended to product URLs
+type AffiliateParams struct {
+	CampID   string
+	MkcID    string
+	MkrID    string
+	CustomID string
+}
+
+// Function validates affiliate parameters. Returns nil when no campaign is configured
+func NewAffiliateParams(params AffiliateParams) (*AffiliateParams, error) {
+	if params.CampID == "" {
+		if params.MkrID != "" || params.CustomID != "" {
+			return nil, fmt.Errorf("ERROR::Affiliate parameters require -affiliate-campid")
+		}
+		return nil, nil
+	}
+
+	if params.MkcID == "" || params.MkrID == "" {
+		return nil, fmt.Errorf("ERROR::-affiliate-campid requires both -affiliate-mkcid and -affiliate-mkrid")
+	}
+
+	return &params, nil
+}
+
+// Function appends affiliate campaign parameters to product URL
+func (a *AffiliateParams) Apply(productURL string) (string, error) {
+	parsedURL, err := url.Parse(productURL)
+	if err != nil {
+		return "", fmt.Errorf("ERROR::Can't parse product URL %s: %s", productURL, err)
+	}
+
+	query := parsedURL.Query()
+	query.Set("mkevt", "1")
+	query.Set("mkcid", a.MkcID)
+	query.Set("mkrid", a.MkrID)
+	query.Set("campid", a.CampID)
+	if a.CustomID != "" {
+		query.Set("customid", a.CustomID)
+	}
+	parsedURL.RawQuery = query.Encode()
+
+	return parsedURL.String(), nil
+}
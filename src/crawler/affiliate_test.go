@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestNewAffiliateParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  AffiliateParams
+		wantNil bool
+		wantErr bool
+	}{
+		{"not configured", AffiliateParams{MkcID: "1"}, true, false},
+		{"complete", AffiliateParams{CampID: "5338000000", MkcID: "1", MkrID: "711-53200-19255-0"}, false, false},
+		{"missing rotation ID", AffiliateParams{CampID: "5338000000", MkcID: "1"}, false, true},
+		{"missing channel ID", AffiliateParams{CampID: "5338000000", MkrID: "711-53200-19255-0"}, false, true},
+		{"rotation ID without campaign", AffiliateParams{MkcID: "1", MkrID: "711-53200-19255-0"}, false, true},
+		{"custom ID without campaign", AffiliateParams{MkcID: "1", CustomID: "laptops"}, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params, err := NewAffiliateParams(test.params)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %t", err, test.wantErr)
+			}
+			if !test.wantErr && (params == nil) != test.wantNil {
+				t.Errorf("got params %v, want nil %t", params, test.wantNil)
+			}
+		})
+	}
+}
+
+// Function returns results page of a single item linked with the href
+func affiliateFixturePage(href string) string {
+	return `<html><body><ul><li class="s-item" id="item1"><a class="s-item__link" href="` + href + `">` +
+		`<div class="s-item__title"><span role="heading">Dell Laptop</span></div></a><span class="s-item__price">$10.00</span></li></ul></body></html>`
+}
+
+func TestAffiliateProductURL(t *testing.T) {
+	search := newFixtureSearch(t, 0, func(w http.ResponseWriter, page int) bool {
+		fmt.Fprint(w, affiliateFixturePage("https://www.ebay.com/itm/555?_trksid=p2047675&amp;var=7"))
+		return true
+	})
+
+	tests := []struct {
+		name     string
+		customID string
+		want     url.Values
+	}{
+		{"campaign", "", url.Values{"var": {"7"}, "mkevt": {"1"}, "mkcid": {"1"}, "mkrid": {"711-53200-19255-0"}, "campid": {"5338000000"}}},
+		{"custom ID", "laptops", url.Values{"var": {"7"}, "mkevt": {"1"}, "mkcid": {"1"}, "mkrid": {"711-53200-19255-0"}, "campid": {"5338000000"}, "customid": {"laptops"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			affiliate, err := NewAffiliateParams(AffiliateParams{CampID: "5338000000", MkcID: "1", MkrID: "711-53200-19255-0", CustomID: test.customID})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := &Crawler{Logger: discardLogger, Affiliate: affiliate}
+			items, err := c.Crawl(context.Background(), search.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != 1 {
+				t.Fatalf("got %d items, want 1", len(items))
+			}
+
+			//Tracking parameters of the link are dropped before the campaign is appended
+			productURL, err := url.Parse(items[0].ProductURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if productURL.Host != "www.ebay.com" || productURL.Path != "/itm/555" || productURL.Query().Encode() != test.want.Encode() {
+				t.Errorf("product URL = %s, want /itm/555 with %s", items[0].ProductURL, test.want.Encode())
+			}
+			if items[0].RawURL != "https://www.ebay.com/itm/555?_trksid=p2047675&var=7" {
+				t.Errorf("raw URL = %s, want the link as found on the page", items[0].RawURL)
+			}
+		})
+	}
+}
+
+func TestAffiliateRawURL(t *testing.T) {
+	search := newFixtureSearch(t, 0, func(w http.ResponseWriter, page int) bool {
+		fmt.Fprint(w, affiliateFixturePage("https://www.ebay.com/itm/555"))
+		return true
+	})
+
+	affiliate := &AffiliateParams{CampID: "5338000000", MkcID: "1", MkrID: "711-53200-19255-0"}
+	tests := []struct {
+		name      string
+		affiliate *AffiliateParams
+		product   string
+		raw       string
+	}{
+		//The link is already canonical, the raw URL keeps it when the campaign changes the product URL
+		{"campaign", affiliate, "https://www.ebay.com/itm/555?campid=5338000000&mkcid=1&mkevt=1&mkrid=711-53200-19255-0", "https://www.ebay.com/itm/555"},
+		{"no campaign", nil, "https://www.ebay.com/itm/555", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Crawler{Logger: discardLogger, Affiliate: test.affiliate}
+			items, err := c.Crawl(context.Background(), search.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != 1 || items[0].ProductURL != test.product || items[0].RawURL != test.raw {
+				t.Errorf("got items %+v, want product URL %s and raw URL %q", items, test.product, test.raw)
+			}
+		})
+	}
+}
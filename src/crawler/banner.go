@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const brandBannerClass string = "s-brand-banner"
+
+// Function to find all elements, within an HTML NODE, by Attribute
+func findAllElementsByAttr(node *html.Node, elementType string, attrName string, attrValue string, nodeList []*html.Node) []*html.Node {
+	if node.Type == html.ElementNode && node.Data == elementType {
+		for _, a := range node.Attr {
+			if a.Key == attrName && strings.Contains(a.Val, attrValue) {
+				nodeList = append(nodeList, node)
+				break
+			}
+		}
+	}
+
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		nodeList = findAllElementsByAttr(c, elementType, attrName, attrValue, nodeList)
+	}
+
+	return nodeList
+}
+
+// Function to process product links of sponsored brand banners through the filters of page items, returns number
+// of written and failed items
+func (c *Crawler) processBannerNodes(pageNode *html.Node, pageIndex int, pageURL string, storeName string) (int, int) {
+	written := 0
+	failed := 0
+
+	for _, bannerNode := range findAllElementsByAttr(pageNode, "div", "class", brandBannerClass, []*html.Node{}) {
+		for _, linkNode := range findAllElementsByAttr(bannerNode, "a", "href", "/itm/", []*html.Node{}) {
+			href, _ := getElementAttrByName(linkNode, "href")
+
+			itemID := c.extractItemID(href)
+
+			item := new(ItemInfo)
+			item.ItemID = itemID
+			item.Title = getNodeText(linkNode)
+			item.ProductURL = href
+			item.IsBanner = true
+			item.PriceCents = -1
+			//Banners are above the page items
+			item.page = pageIndex
+			item.position = -1
+
+			priceNode := findFirstElementByAnyClass(bannerNode, "span", c.priceClasses())
+			if priceNode != nil {
+				price, err := getElementNodeVal(priceNode)
+				if err == nil {
+					item.PriceMin, item.PriceMax, item.Currency, _ = parsePriceRange(price, c.DecimalSeparator)
+					item.Price = item.PriceMin
+					if item.PriceMin != "" {
+						item.PriceCents = priceCents(item.PriceMin)
+					}
+				}
+			}
+
+			if item.Title == "" {
+				c.logger().Debug("Banner item has no title", "item_id", itemID)
+			}
+
+			item, err := c.finishItem(item, storeName, pageURL)
+			if err == nil && item == nil {
+				c.mu.Lock()
+				c.stats.Filtered++
+				c.mu.Unlock()
+				continue
+			}
+			if err == nil {
+				err = c.emit(item)
+			}
+			if errors.Is(err, ErrSkipItem) {
+				continue
+			}
+			if err != nil {
+				failed++
+				continue
+			}
+
+			written++
+		}
+	}
+
+	return written, failed
+}
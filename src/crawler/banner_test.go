@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Results page with a sponsored brand banner of two products above a classic item card
+const bannerResultsPage = `<html><body>
+<div class="s-brand-banner s-brand-banner--wide"><span class="s-item__price">$899.00</span>
+<a href="https://www.ebay.com/itm/901?_trksid=p1">Dell XPS 13 Laptop</a><a href="https://www.ebay.com/itm/902?_trksid=p1">Dell Monitor</a></div>
+<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/111">
+<div class="s-item__title"><span role="heading">Lenovo Laptop</span></div></a><span class="s-item__price">$120.00</span></li></ul>
+</body></html>`
+
+func TestIncludeBanners(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, bannerResultsPage)
+	}))
+	defer server.Close()
+
+	laptops, err := NewTitleFilter([]string{"laptop"}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		crawler *Crawler
+		want    string
+	}{
+		{"banners off by default", &Crawler{Logger: discardLogger}, "111"},
+		{"banners included", &Crawler{Logger: discardLogger, IncludeBanners: true}, "901,902,111"},
+		{"banners filtered like page items", &Crawler{Logger: discardLogger, IncludeBanners: true, TitleFilter: laptops}, "901,111"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, err := test.crawler.Crawl(context.Background(), server.URL+"/sch/i.html?_nkw=dell")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(itemIDs(items), ","); got != test.want {
+				t.Fatalf("got items %s, want %s", got, test.want)
+			}
+			for _, item := range items {
+				if item.IsBanner != strings.HasPrefix(item.ItemID, "9") {
+					t.Errorf("item %s has IsBanner %t", item.ItemID, item.IsBanner)
+				}
+			}
+		})
+	}
+
+	//Banner items are normalized like page items
+	items, err := (&Crawler{Logger: discardLogger, IncludeBanners: true}).Crawl(context.Background(), server.URL+"/sch/i.html?_nkw=dell")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if banner := items[0]; banner.ProductURL != "https://www.ebay.com/itm/901" || banner.PriceCents != 89900 || banner.Title != "Dell XPS 13 Laptop" {
+		t.Errorf("got banner item %+v, want canonical URL, price and title", banner)
+	}
+}
@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Function returns path of the cached page HTML, keyed by SHA-256 of the URL
+func cachePath(cacheDir string, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(cacheDir, hex.EncodeToString(sum[:])+".html")
+}
+
+// Function checks if the page will be read from the cache instead of fetched
+func (c *Crawler) isCached(url string) bool {
+	if c.CacheDir == "" || c.RefreshCache {
+		return false
+	}
+
+	_, err := os.Stat(cachePath(c.CacheDir, url))
+	return err == nil
+}
+
+// Function gets page HTML from the cache directory when set, fetching and caching it otherwise.
+// RefreshCache or refresh skip reading the cache, fetched page still overwrites it
+func (c *Crawler) fetchPage(ctx context.Context, url string, refresh bool) (string, error) {
+	if c.CacheDir == "" {
+		return c.fetchWithRetry(ctx, url, c.Retries)
+	}
+
+	path := cachePath(c.CacheDir, url)
+	if !c.RefreshCache && !refresh {
+		body, err := os.ReadFile(path)
+		if err == nil {
+			c.logger().Debug("Page read from cache", "url", url, "path", path)
+			return string(body), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("ERROR::Can't read cached page %s: %w", path, err)
+		}
+	}
+
+	body, err := c.fetchWithRetry(ctx, url, c.Retries)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(c.CacheDir, 0775)
+	if err == nil {
+		err = writeCacheFile(path, body)
+	}
+	if err != nil {
+		c.logger().Warn("Can't cache page", "url", url, "path", path, "err", err)
+	}
+
+	return body, nil
+}
+
+// Function writes cached page through a temporary file renamed into place, so a killed run leaves no truncated page
+// which later runs would read as a complete one
+func writeCacheFile(path string, body string) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := file.Name()
+	defer os.Remove(tempPath)
+
+	_, err = file.WriteString(body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempPath, 0644)
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tempPath, path)
+}
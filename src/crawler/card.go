@@ -0,0 +1,608 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Refurbishment grade shown by eBay Refurbished badges
+type RefurbGrade string
+
+const (
+	RefurbCertified RefurbGrade = "certified"
+	RefurbExcellent RefurbGrade = "excellent"
+	RefurbVeryGood  RefurbGrade = "very_good"
+	RefurbGood      RefurbGrade = "good"
+	RefurbSeller    RefurbGrade = "seller"
+)
+
+// Badge texts in matching order, longer texts go before the ones they contain
+var refurbBadges = []struct {
+	text  string
+	grade RefurbGrade
+}{
+	{"certified - refurbished", RefurbCertified},
+	{"certified refurbished", RefurbCertified},
+	{"excellent - refurbished", RefurbExcellent},
+	{"very good - refurbished", RefurbVeryGood},
+	{"good - refurbished", RefurbGood},
+	{"seller refurbished", RefurbSeller},
+}
+
+var quantityLeftRegEx = regexp.MustCompile(`(?i)only\s+(\d+)\s+left`)
+var quantityAvailableRegEx = regexp.MustCompile(`(?i)(\d+)\s+available`)
+
+// Quantity patterns with their literal word, checked before the pattern
+var quantityPatterns = []struct {
+	marker string
+	re     *regexp.Regexp
+}{
+	{"left", quantityLeftRegEx},
+	{"available", quantityAvailableRegEx},
+}
+
+var leadingNumberRegEx = regexp.MustCompile(`\d[\d,]*`)
+
+var itemsSoldRegEx = regexp.MustCompile(`(?i)(\d[\d,]*)\+?\s+sold\b`)
+var watchersRegEx = regexp.MustCompile(`(?i)(\d[\d,]*)\+?\s+watch(?:ing|ers?)\b`)
+
+var directFromRegEx = regexp.MustCompile(`(?i)^direct from\s+(.+)$`)
+
+var sellerInfoRegEx = regexp.MustCompile(`^(\S+)\s*(?:\([\d,.]+[kKmM]?\))?\s*(?:(\d+(?:\.\d+)?%))?`)
+
+var saleEndsRegEx = regexp.MustCompile(`(?i)sale ends\s*(?:in|on|:)?\s*([^|]+)`)
+var durationPartRegEx = regexp.MustCompile(`(?i)(\d+)\s*(d|h|m|s)\b`)
+
+// Absolute date formats used by eBay sale end indicators
+var saleEndsLayouts = []string{
+	"Jan 2, 2006 15:04",
+	"Jan 2, 2006",
+	"Mon, Jan 2 15:04",
+	"Jan 2 15:04",
+	"Jan 2",
+	"01/02/2006",
+	"2006-01-02",
+}
+
+// Function to get all text, within an HTML NODE, joined by single spaces. No space is put before punctuation
+func getNodeText(node *html.Node) string {
+	text := strings.Builder{}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			part := strings.TrimSpace(n.Data)
+			if part != "" {
+				//Punctuation following an element ("<span>Brand New</span>, Factory Sealed") isn't separated
+				if text.Len() > 0 && !strings.ContainsAny(part[:1], ",.;:!?)") {
+					text.WriteByte(' ')
+				}
+				text.WriteString(part)
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	return text.String()
+}
+
+// Function to parse sale end time from item card text. Returns zero time when absent or unparseable
+func parseSaleEndsAt(text string, now time.Time) time.Time {
+	if !hasCardMarker(text, "sale ends") {
+		return time.Time{}
+	}
+
+	matches := saleEndsRegEx.FindStringSubmatch(text)
+	if matches == nil {
+		return time.Time{}
+	}
+
+	value := strings.TrimSpace(matches[1])
+
+	//Relative format, e.g. "2d 5h 30m"
+	parts := durationPartRegEx.FindAllStringSubmatch(value, -1)
+	if len(parts) > 0 && strings.Index(value, parts[0][0]) == 0 {
+		duration := time.Duration(0)
+		for _, part := range parts {
+			amount, _ := strconv.Atoi(part[1])
+			switch strings.ToLower(part[2]) {
+			case "d":
+				duration += time.Duration(amount) * 24 * time.Hour
+			case "h":
+				duration += time.Duration(amount) * time.Hour
+			case "m":
+				duration += time.Duration(amount) * time.Minute
+			case "s":
+				duration += time.Duration(amount) * time.Second
+			}
+		}
+
+		return now.Add(duration)
+	}
+
+	//Absolute format, e.g. "Oct 20, 2026", possibly followed by other card text
+	words := strings.Fields(value)
+	for n := len(words); n > 0; n-- {
+		candidate := strings.Join(words[:n], " ")
+
+		for _, layout := range saleEndsLayouts {
+			endsAt, err := time.ParseInLocation(layout, candidate, now.Location())
+			if err != nil {
+				continue
+			}
+
+			if endsAt.Year() == 0 {
+				endsAt = endsAt.AddDate(now.Year(), 0, 0)
+				if endsAt.Before(now) {
+					endsAt = endsAt.AddDate(1, 0, 0)
+				}
+			}
+
+			return endsAt
+		}
+	}
+
+	return time.Time{}
+}
+
+// Function to get refurbishment grade from condition and badge text of item card. Returns empty grade for non-refurbished items
+func parseRefurbGrade(text string) RefurbGrade {
+	text = strings.ToLower(text)
+	for _, badge := range refurbBadges {
+		if strings.Contains(text, badge.text) {
+			return badge.grade
+		}
+	}
+
+	return ""
+}
+
+// Emoji modifiers and joiners which are not in the unicode symbol table
+var emojiExtras = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x200d, Hi: 0x200d, Stride: 1}, // zero width joiner
+		{Lo: 0x20e3, Hi: 0x20e3, Stride: 1}, // combining enclosing keycap
+		{Lo: 0xfe0e, Hi: 0xfe0f, Stride: 1}, // variation selectors
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f3fb, Hi: 0x1f3ff, Stride: 1}, // skin tone modifiers
+		{Lo: 0xe0020, Hi: 0xe007f, Stride: 1}, // tag characters
+	},
+}
+
+// Symbols below arrows block (©, ®, ™, currency-like signs) are kept as they are part of regular titles
+const firstPictographicRune rune = 0x2190
+
+// Function to remove emoji and pictographic symbols from text, collapsing left over whitespace
+func stripEmoji(text string) string {
+	stripped := strings.Map(func(r rune) rune {
+		if (r >= firstPictographicRune && unicode.Is(unicode.So, r)) || unicode.Is(emojiExtras, r) {
+			return -1
+		}
+		return r
+	}, text)
+
+	return strings.Join(strings.Fields(stripped), " ")
+}
+
+// Function checks if item card text contains the marker, ignoring case. Text is compared in place, so long card texts
+// checked for several markers aren't lowercased each time
+func hasCardMarker(text string, marker string) bool {
+	if marker == "" {
+		return true
+	}
+
+	first := lowerASCII(marker[0])
+	for i := 0; i+len(marker) <= len(text); i++ {
+		//Cheap first byte check before the full comparison
+		if first < utf8.RuneSelf && text[i] < utf8.RuneSelf && lowerASCII(text[i]) != first {
+			continue
+		}
+
+		if strings.EqualFold(text[i:i+len(marker)], marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Function returns lowercase of ASCII letter, other bytes unchanged
+func lowerASCII(b byte) byte {
+	if 'A' <= b && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+
+	return b
+}
+
+// Function to get remaining quantity from availability text of item card ("Only 2 left!", "5 available"). Returns 0 when absent
+func parseQuantityAvailable(text string) int {
+	for _, pattern := range quantityPatterns {
+		//Most cards have no quantity, skip the regular expression when its literal part is missing
+		if !hasCardMarker(text, pattern.marker) {
+			continue
+		}
+
+		matches := pattern.re.FindStringSubmatch(text)
+		if matches != nil {
+			quantity, err := strconv.Atoi(matches[1])
+			if err == nil {
+				return quantity
+			}
+		}
+	}
+
+	if hasCardMarker(text, "Last one") {
+		return 1
+	}
+
+	return 0
+}
+
+// Canonical spelling of condition texts by their lowercase form
+var conditionNames = map[string]string{
+	"new":                      "New",
+	"brand new":                "Brand New",
+	"new (other)":              "New (Other)",
+	"new other":                "New (Other)",
+	"open box":                 "Open Box",
+	"pre-owned":                "Pre-Owned",
+	"preowned":                 "Pre-Owned",
+	"pre owned":                "Pre-Owned",
+	"used":                     "Used",
+	"refurbished":              "Refurbished",
+	"certified - refurbished":  "Certified - Refurbished",
+	"seller refurbished":       "Seller Refurbished",
+	"for parts or not working": "For Parts or Not Working",
+	"for parts/not working":    "For Parts or Not Working",
+}
+
+// Function to canonicalize condition text case and spelling. Unknown conditions get each word capitalized
+func normalizeCondition(condition string) string {
+	condition = strings.Join(strings.Fields(condition), " ")
+	if condition == "" {
+		return ""
+	}
+
+	if name, ok := conditionNames[strings.ToLower(condition)]; ok {
+		return name
+	}
+
+	words := strings.Fields(strings.ToLower(condition))
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Function to get brand outlet indicator from item node. Returns brand name when the label names it
+func parseBrandOutlet(node *html.Node) (bool, string) {
+	brandOutlet := false
+	brandName := ""
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if brandName != "" {
+			return
+		}
+
+		if n.Type == html.TextNode {
+			text := strings.TrimSpace(n.Data)
+			if matches := directFromRegEx.FindStringSubmatch(text); matches != nil {
+				brandOutlet = true
+				brandName = strings.TrimSpace(matches[1])
+				return
+			}
+
+			if hasCardMarker(text, "Brand Outlet") {
+				brandOutlet = true
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	return brandOutlet, brandName
+}
+
+// Shipping texts of Top-rated fast shipping perk
+var fastShippingMarkers = []string{"Fast 'N Free", "Fast ’N Free", "Fast and Free", "Fast & Free"}
+
+// Function detects fast shipping perk in the item card shipping area, falling back to bid and purchase option rows.
+// The title is never matched, it may contain the same words
+func parseFastShipping(node *html.Node) bool {
+	text := ""
+	shippingNode := findFirstElementByAnyAttr(node, "span", "class", []string{"s-item__shipping", "s-item__logisticsCost"})
+	if shippingNode != nil {
+		text = getNodeText(shippingNode)
+	}
+
+	if text == "" {
+		text = getAttributeRowsText(node)
+	}
+
+	return hasFastShippingMarker(text)
+}
+
+// Function checks if shipping text has a fast shipping perk marker
+func hasFastShippingMarker(text string) bool {
+	for _, marker := range fastShippingMarkers {
+		if hasCardMarker(text, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Function to get shipping cost from item node: normalized amount ("0" for free shipping) and raw text. Empty when absent
+func parseShipping(node *html.Node, decimalSeparator string) (string, string) {
+	shippingNode := findFirstElementByAnyAttr(node, "span", "class", []string{"s-item__shipping", "s-item__logisticsCost"})
+	if shippingNode == nil {
+		return "", ""
+	}
+
+	return parseShippingText(getNodeText(shippingNode), decimalSeparator)
+}
+
+// Function to get normalized shipping amount ("0" for free shipping) from shipping text, returned with the raw text
+func parseShippingText(raw string, decimalSeparator string) (string, string) {
+	if hasCardMarker(raw, "free") {
+		return "0", raw
+	}
+
+	amount, _, err := parsePrice(raw, decimalSeparator)
+	if err != nil {
+		return "", raw
+	}
+
+	return amount, raw
+}
+
+// Prefixes of item location texts, e.g. "from China" or "Located in: United States"
+var locationPrefixRegEx = regexp.MustCompile(`(?i)^(?:located\s+in:?|from)\s*`)
+
+// Function to get item location from location span of item node, without its prefix. Empty when absent
+func parseLocation(node *html.Node) string {
+	locationNode := findFirstElementByAnyAttr(node, "span", "class", []string{"s-item__location", "s-item__itemLocation"})
+	if locationNode == nil {
+		return ""
+	}
+
+	return locationFromText(getNodeText(locationNode))
+}
+
+// Function strips "from"/"Located in:" prefix from location text
+func locationFromText(text string) string {
+	return strings.TrimSpace(locationPrefixRegEx.ReplaceAllString(strings.TrimSpace(text), ""))
+}
+
+// Classes of card rows with bid details and purchase options ("Reserve not met", "or Best Offer"), of both layouts
+var attributeRowClasses = []string{"s-item__details", "s-item__bids", "s-item__bidCount", "s-item__purchase-options", "s-item__purchaseOptionsWithIcon", "s-item__dynamic", "s-item__reserve", "s-item__quantity", "s-item__hotness", "s-card__attribute-row"}
+
+// Function to get text of bid and purchase option rows of item node, without the title. Rows are separated by newlines
+func getAttributeRowsText(node *html.Node) string {
+	parts := []string{}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			for _, a := range n.Attr {
+				if a.Key == "class" && slices.ContainsFunc(attributeRowClasses, func(class string) bool { return hasClassToken(a.Val, class) }) {
+					parts = append(parts, getNodeText(n))
+					return
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	return strings.Join(parts, "\n")
+}
+
+// Classes of per item category labels, e.g. "in Cell Phones & Smartphones"
+var categoryClasses = []string{"s-item__category", "s-card__category", "s-item__categoryName"}
+
+// Prefixes of category label texts
+var categoryPrefixRegEx = regexp.MustCompile(`(?i)^(?:in|category:?)\s+`)
+
+// Function to get item category from category span or anchor of item node, without its prefix. Empty when absent
+func parseCategory(node *html.Node) string {
+	categoryNode := findFirstElementByAnyAttr(node, "span", "class", categoryClasses)
+	if categoryNode == nil {
+		categoryNode = findFirstElementByAnyAttr(node, "a", "class", categoryClasses)
+	}
+	if categoryNode == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(categoryPrefixRegEx.ReplaceAllString(getNodeText(categoryNode), ""))
+}
+
+// Function checks if image source is a lazy-loading placeholder instead of the real image
+func isPlaceholderImage(src string) bool {
+	return src == "" || strings.HasPrefix(src, "data:") || strings.Contains(src, "1x1") || strings.HasSuffix(strings.ToLower(src), ".gif")
+}
+
+// Function to get image URL of img node, falling back to data-src when src is a placeholder
+func getImageSrc(imgNode *html.Node) string {
+	src, _ := getElementAttrByName(imgNode, "src")
+	if !isPlaceholderImage(src) {
+		return src
+	}
+
+	dataSrc, err := getElementAttrByName(imgNode, "data-src")
+	if err == nil && dataSrc != "" {
+		return dataSrc
+	}
+
+	return src
+}
+
+// Function to get thumbnail URL of the first image within item node. Empty when no image is found
+func parseImageURL(node *html.Node) string {
+	imgNode := findFirstElementByAttr(node, "img", "src", "")
+	if imgNode == nil {
+		imgNode = findFirstElementByAttr(node, "img", "data-src", "")
+	}
+
+	if imgNode == nil {
+		return ""
+	}
+
+	src := getImageSrc(imgNode)
+	if strings.HasPrefix(src, "data:") {
+		return ""
+	}
+
+	return src
+}
+
+// Function detects auction listing by its bid count span. Returns listing type (auction or fixed) and number of bids
+func parseListingType(node *html.Node) (string, int) {
+	bidsNode := findFirstElementByAnyAttr(node, "span", "class", []string{"s-item__bids", "s-item__bidCount"})
+	if bidsNode == nil {
+		return "fixed", 0
+	}
+
+	bids := 0
+	number := leadingNumberRegEx.FindString(getNodeText(bidsNode))
+	if number != "" {
+		bids, _ = strconv.Atoi(strings.ReplaceAll(number, ",", ""))
+	}
+
+	return "auction", bids
+}
+
+// Function to get seller name and positive feedback percentage, e.g. "garlandcomputer (12,345) 99.1%". Empty when absent
+func parseSellerInfo(node *html.Node) (string, string) {
+	sellerNode := findFirstElementByAttr(node, "span", "class", "s-item__seller-info-text")
+	if sellerNode == nil {
+		return "", ""
+	}
+
+	text, err := getElementNodeVal(sellerNode)
+	if err != nil {
+		return "", ""
+	}
+
+	matches := sellerInfoRegEx.FindStringSubmatch(text)
+	if matches == nil {
+		return "", ""
+	}
+
+	return matches[1], matches[2]
+}
+
+// Function to get popularity signals ("12 sold", "5 watching") from hotness spans of item node. Zero when absent
+func parsePopularity(node *html.Node) (int, int) {
+	itemsSold := 0
+	watchers := 0
+
+	spans := findAllElementsByAttr(node, "span", "class", "s-item__hotness", []*html.Node{})
+	spans = findAllElementsByAttr(node, "span", "class", "s-item__dynamic", spans)
+
+	for _, span := range spans {
+		text := getNodeText(span)
+
+		if matches := itemsSoldRegEx.FindStringSubmatch(text); matches != nil && itemsSold == 0 {
+			itemsSold, _ = strconv.Atoi(strings.ReplaceAll(matches[1], ",", ""))
+		}
+
+		if matches := watchersRegEx.FindStringSubmatch(text); matches != nil && watchers == 0 {
+			watchers, _ = strconv.Atoi(strings.ReplaceAll(matches[1], ",", ""))
+		}
+	}
+
+	return itemsSold, watchers
+}
+
+// Labels eBay injects into the title span
+const newListingPrefix string = "New Listing"
+const sponsoredLabel string = "SPONSORED"
+
+// Function to remove "New Listing" prefix from item title
+func cleanTitle(title string) string {
+	title = strings.TrimSpace(title)
+	if len(title) >= len(newListingPrefix) && strings.EqualFold(title[:len(newListingPrefix)], newListingPrefix) {
+		title = title[len(newListingPrefix):]
+	}
+
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// Function returns text of the title node without the "SPONSORED" label element eBay injects into it. The word in
+// the title text itself, like "SPONSORED Edition Print", is kept
+func getTitleText(titleNode *html.Node) (string, error) {
+	var value strings.Builder
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				value.WriteString(c.Data)
+				continue
+			}
+			if c.Type == html.ElementNode && strings.EqualFold(getNodeText(c), sponsoredLabel) {
+				continue
+			}
+
+			walk(c)
+		}
+	}
+	walk(titleNode)
+
+	title := strings.TrimSpace(value.String())
+	if title == "" {
+		return "", fmt.Errorf("ERROR::No text node found")
+	}
+
+	return title, nil
+}
+
+// Function detects sponsored listing by a separate "Sponsored" element of the card, the label of the title included
+func isSponsored(node *html.Node) bool {
+	sponsored := false
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if sponsored {
+			return
+		}
+
+		if n.Type == html.TextNode && strings.EqualFold(strings.TrimSpace(n.Data), "Sponsored") {
+			sponsored = true
+			return
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	return sponsored
+}
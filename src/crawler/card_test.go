@@ -0,0 +1,587 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSaleEndsAt(t *testing.T) {
+	now := time.Date(2026, time.October, 14, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		text string
+		want time.Time
+	}{
+		{"$99.00 Was: $120.00 Sale ends in 2d 5h 30m", now.Add(53*time.Hour + 30*time.Minute)},
+		{"Sale ends in 45m | Free shipping", now.Add(45 * time.Minute)},
+		{"SALE ENDS: 3h", now.Add(3 * time.Hour)},
+		{"Sale ends on Oct 20, 2026 18:30", time.Date(2026, time.October, 20, 18, 30, 0, 0, time.UTC)},
+		{"Sale ends Oct 20, 2026 Free returns", time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC)},
+		{"Sale ends Oct 20", time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC)},
+		//Date without year already past this year is next year
+		{"Sale ends Jan 5", time.Date(2027, time.January, 5, 0, 0, 0, 0, time.UTC)},
+		{"Sale ends soon", time.Time{}},
+		{"$99.00 Was: $120.00", time.Time{}},
+	}
+
+	for _, test := range tests {
+		if got := parseSaleEndsAt(test.text, now); !got.Equal(test.want) {
+			t.Errorf("parseSaleEndsAt(%q) = %s, want %s", test.text, got, test.want)
+		}
+	}
+}
+
+func TestSaleEndsAtFixture(t *testing.T) {
+	node := parseFixtureItem(t, `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">`+
+		`<div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a><span class="s-item__price">$99.00</span>`+
+		`<span class="s-item__discount">Was: $120.00</span><span class="s-item__sale-ends">Sale ends in 1d 2h</span></li></ul>`, "s-item")
+
+	before := time.Now()
+	item, err := (&EbayClassicParser{}).ParseItem(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := before.Add(26 * time.Hour)
+	if item.SaleEndsAt == nil {
+		t.Fatal("sale end time wasn't parsed")
+	}
+	if item.SaleEndsAt.Before(want) || item.SaleEndsAt.After(time.Now().Add(26*time.Hour)) {
+		t.Errorf("sale ends at %s, want about %s", item.SaleEndsAt, want)
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil || !strings.Contains(string(data), `"sale_ends_at":"`) {
+		t.Errorf("JSON item %s (%v) lacks sale_ends_at", data, err)
+	}
+
+	//Cards without the indicator leave the time unset and JSON without the key
+	item, err = (&EbayClassicParser{}).ParseItem(parseFixtureItem(t, auctionCard("ThinkPad X220", "3 bids"), "s-item"))
+	if err != nil || item.SaleEndsAt != nil {
+		t.Fatalf("got sale end %v (%v), want none", item.SaleEndsAt, err)
+	}
+	data, err = json.Marshal(item)
+	if err != nil || strings.Contains(string(data), "sale_ends_at") {
+		t.Errorf("JSON item %s (%v) has sale_ends_at key", data, err)
+	}
+}
+
+// Function returns classic card with the condition
+func conditionCard(condition string) string {
+	return `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">` +
+		`<div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a>` +
+		`<div class="s-item__subtitle"><span class="SECONDARY_INFO">` + condition + `</span></div><span class="s-item__price">$120.00</span></li></ul>`
+}
+
+func TestRefurbGrade(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser ItemParser
+		card   string
+		class  string
+		want   RefurbGrade
+	}{
+		{"excellent", &EbayClassicParser{}, conditionCard("Excellent - Refurbished"), "s-item", RefurbExcellent},
+		{"very good", &EbayClassicParser{}, conditionCard("Very Good - Refurbished"), "s-item", RefurbVeryGood},
+		{"good", &EbayClassicParser{}, conditionCard("Good - Refurbished"), "s-item", RefurbGood},
+		{"seller", &EbayClassicParser{}, conditionCard("Seller refurbished"), "s-item", RefurbSeller},
+		{"not refurbished", &EbayClassicParser{}, conditionCard("Pre-Owned"), "s-item", ""},
+		{"grade in title only", &EbayClassicParser{}, strings.Replace(conditionCard("Pre-Owned"), "ThinkPad X220", "iPhone 12 Excellent - Refurbished look", 1), "s-item", ""},
+		{"certified badge of the cards layout", &EbayCardParser{}, `<ul><li class="s-card" id="item1"><a class="su-link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-card__title"><span class="su-styled-text">ThinkPad X220</span></div></a><span class="s-card__price">$120.00</span>` +
+			`<div class="s-card__attribute-row"><span class="s-card__badge">Certified - Refurbished</span></div></li></ul>`, "s-card", RefurbCertified},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, err := test.parser.ParseItem(parseFixtureItem(t, test.card, test.class))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.RefurbGrade != test.want {
+				t.Errorf("refurb grade = %q, want %q", item.RefurbGrade, test.want)
+			}
+		})
+	}
+}
+
+func TestQuantityAvailable(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"only left", "Only 2 left!", 2},
+		{"only left lowercase", "only 1 left", 1},
+		{"available", "5 available", 5},
+		{"last one", "Last one", 1},
+		{"absent", "Free shipping", 0},
+		{"both phrasings", "Only 3 left - 3 available", 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			card := `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">` +
+				`<div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a><span class="s-item__price">$120.00</span>` +
+				`<span class="s-item__quantity">` + test.text + `</span></li></ul>`
+
+			item, err := (&EbayClassicParser{}).ParseItem(parseFixtureItem(t, card, "s-item"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.QuantityAvailable != test.want {
+				t.Errorf("%q quantity = %d, want %d", test.text, item.QuantityAvailable, test.want)
+			}
+		})
+	}
+
+	//Titles may contain the same words, only the availability text counts
+	for _, title := range []string{"Last one of the batch ThinkPad X220", "ThinkPad X220 3 available colors"} {
+		card := `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-item__title"><span role="heading">` + title + `</span></div></a><span class="s-item__price">$120.00</span></li></ul>`
+
+		item, err := (&EbayClassicParser{}).ParseItem(parseFixtureItem(t, card, "s-item"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.QuantityAvailable != 0 {
+			t.Errorf("title %q gave quantity %d, want 0", title, item.QuantityAvailable)
+		}
+	}
+}
+
+func TestNormalizeCondition(t *testing.T) {
+	tests := []struct {
+		condition, want string
+	}{
+		{"Pre-Owned", "Pre-Owned"},
+		{"Pre-owned", "Pre-Owned"},
+		{"PRE-OWNED", "Pre-Owned"},
+		{"pre owned", "Pre-Owned"},
+		{"  Brand  new ", "Brand New"},
+		{"New other", "New (Other)"},
+		{"for parts/not working", "For Parts or Not Working"},
+		{"excellent - refurbished", "Excellent - Refurbished"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := normalizeCondition(test.condition); got != test.want {
+			t.Errorf("normalizeCondition(%q) = %q, want %q", test.condition, got, test.want)
+		}
+	}
+}
+
+func TestNormalizeConditionCrawl(t *testing.T) {
+	conditions := []string{"Pre-Owned", "Pre-owned", "pre-owned", "BRAND NEW"}
+	search := newFixtureSearch(t, 0, func(w http.ResponseWriter, page int) bool {
+		fmt.Fprint(w, `<html><body><ul>`)
+		for i, condition := range conditions {
+			fmt.Fprintf(w, `<li class="s-item" id="item%[1]d"><a class="s-item__link" href="https://www.ebay.com/itm/%[1]d">`+
+				`<div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a>`+
+				`<div class="s-item__subtitle"><span class="SECONDARY_INFO">%[2]s</span></div><span class="s-item__price">$120.00</span></li>`, 500+i, condition)
+		}
+		fmt.Fprint(w, `</ul></body></html>`)
+		return true
+	})
+
+	tests := []struct {
+		name      string
+		normalize bool
+		want      []string
+	}{
+		{"normalized", true, []string{"Pre-Owned", "Pre-Owned", "Pre-Owned", "Brand New"}},
+		{"as listed", false, conditions},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Crawler{Logger: discardLogger, Workers: 1, NormalizeCondition: test.normalize}
+			items, err := c.Crawl(context.Background(), search.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != len(conditions) {
+				t.Fatalf("got %d items, want %d", len(items), len(conditions))
+			}
+
+			for i, item := range items {
+				raw := ""
+				if test.normalize {
+					raw = conditions[i]
+				}
+				if item.Condition != test.want[i] || item.RawCondition != raw {
+					t.Errorf("item %s condition = %q (raw %q), want %q (raw %q)", item.ItemID, item.Condition, item.RawCondition, test.want[i], raw)
+				}
+			}
+		})
+	}
+}
+
+func TestRichSubtitle(t *testing.T) {
+	tests := []struct {
+		name      string
+		parser    ItemParser
+		card      string
+		class     string
+		condition string
+		subtitle  string
+	}{
+		{"factory sealed", &EbayClassicParser{}, `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-item__title"><span role="heading">iPad Air</span></div></a>` +
+			`<div class="s-item__subtitle"><span class="SECONDARY_INFO">Brand New</span>, Factory Sealed</div><span class="s-item__price">$420.00</span></li></ul>`,
+			"s-item", "Brand New", "Brand New, Factory Sealed"},
+		{"qualifier spans", &EbayClassicParser{}, `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-item__title"><span role="heading">iPad Air</span></div></a>` +
+			`<div class="s-item__subtitle"><span class="SECONDARY_INFO">Open Box</span> · <span>Apple</span> · <span>64 GB</span></div><span class="s-item__price">$380.00</span></li></ul>`,
+			"s-item", "Open Box", "Open Box · Apple · 64 GB"},
+		{"condition only", &EbayClassicParser{}, conditionCard("Pre-Owned"), "s-item", "Pre-Owned", "Pre-Owned"},
+		{"cards layout", &EbayCardParser{}, `<ul><li class="s-card" id="item1"><a class="su-link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-card__title"><span class="su-styled-text">iPad Air</span></div></a>` +
+			`<div class="s-card__subtitle"><span class="su-styled-text">Brand New</span>, Factory Sealed</div><span class="s-card__price">$420.00</span></li></ul>`,
+			"s-card", "Brand New", "Brand New, Factory Sealed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, err := test.parser.ParseItem(parseFixtureItem(t, test.card, test.class))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.Condition != test.condition || item.Subtitle != test.subtitle {
+				t.Errorf("condition %q and subtitle %q, want %q and %q", item.Condition, item.Subtitle, test.condition, test.subtitle)
+			}
+		})
+	}
+}
+
+func TestBrandOutlet(t *testing.T) {
+	classicCard := func(label string) string {
+		return `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-item__title"><span role="heading">ThinkPad X1 Carbon</span></div></a><span class="s-item__price">$900.00</span>` + label + `</li></ul>`
+	}
+
+	tests := []struct {
+		name   string
+		parser ItemParser
+		card   string
+		class  string
+		outlet bool
+		brand  string
+	}{
+		{"direct from brand", &EbayClassicParser{}, classicCard(`<span class="s-item__etrs-text">Direct from Lenovo</span>`), "s-item", true, "Lenovo"},
+		{"brand outlet", &EbayClassicParser{}, classicCard(`<span class="s-item__etrs-text">Brand Outlet</span>`), "s-item", true, ""},
+		{"absent", &EbayClassicParser{}, classicCard(`<span class="s-item__shipping">Free delivery</span>`), "s-item", false, ""},
+		{"cards layout", &EbayCardParser{}, `<ul><li class="s-card" id="item1"><a class="su-link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-card__title"><span class="su-styled-text">XPS 13</span></div></a><span class="s-card__price">$900.00</span>` +
+			`<div class="s-card__attribute-row"><span class="su-styled-text">Direct from Dell</span></div></li></ul>`, "s-card", true, "Dell"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, err := test.parser.ParseItem(parseFixtureItem(t, test.card, test.class))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.BrandOutlet != test.outlet || item.BrandName != test.brand {
+				t.Errorf("brand outlet %t of brand %q, want %t of %q", item.BrandOutlet, item.BrandName, test.outlet, test.brand)
+			}
+		})
+	}
+}
+
+// Function returns classic card of the item ID with the shipping span text, no shipping span when empty
+func shippingCard(itemID string, shipping string) string {
+	card := `<li class="s-item" id="item` + itemID + `"><a class="s-item__link" href="https://www.ebay.com/itm/` + itemID + `">` +
+		`<div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a><span class="s-item__price">$120.00</span>`
+	if shipping != "" {
+		card += `<span class="s-item__shipping s-item__logisticsCost">` + shipping + `</span>`
+	}
+
+	return card + `</li>`
+}
+
+func TestFastShipping(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser ItemParser
+		card   string
+		class  string
+		want   bool
+	}{
+		{"fast n free", &EbayClassicParser{}, `<ul>` + shippingCard("555", "Fast 'N Free") + `</ul>`, "s-item", true},
+		{"typographic apostrophe", &EbayClassicParser{}, `<ul>` + shippingCard("555", "Free delivery · Fast ’N Free") + `</ul>`, "s-item", true},
+		{"free shipping only", &EbayClassicParser{}, `<ul>` + shippingCard("555", "Free delivery") + `</ul>`, "s-item", false},
+		{"paid shipping", &EbayClassicParser{}, `<ul>` + shippingCard("555", "+$12.50 delivery") + `</ul>`, "s-item", false},
+		{"card without shipping span", &EbayClassicParser{}, `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a><span class="s-item__price">$120.00</span>` +
+			`<div class="s-item__details">Fast and Free</div></li></ul>`, "s-item", true},
+		{"marker in title only", &EbayClassicParser{}, `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-item__title"><span role="heading">Fast 'N Free shipping case</span></div></a><span class="s-item__price">$12.00</span></li></ul>`, "s-item", false},
+		{"marker in title with other shipping", &EbayClassicParser{}, `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-item__title"><span role="heading">Fast 'N Free shipping case</span></div></a><span class="s-item__price">$12.00</span>` +
+			`<span class="s-item__shipping s-item__logisticsCost">+$4.00 delivery</span></li></ul>`, "s-item", false},
+		{"cards layout", &EbayCardParser{}, `<ul><li class="s-card" id="item1"><a class="su-link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-card__title"><span class="su-styled-text">ThinkPad X220</span></div></a><span class="s-card__price">$120.00</span>` +
+			`<div class="s-card__attribute-row"><span class="su-styled-text">Fast 'N Free</span></div></li></ul>`, "s-card", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, err := test.parser.ParseItem(parseFixtureItem(t, test.card, test.class))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.FastShipping != test.want {
+				t.Errorf("fast shipping = %t, want %t", item.FastShipping, test.want)
+			}
+		})
+	}
+}
+
+func TestFastShippingOnly(t *testing.T) {
+	search := newFixtureSearch(t, 0, func(w http.ResponseWriter, page int) bool {
+		//Item 558 has the marker in the title only
+		titleOnly := strings.Replace(shippingCard("558", ""), "ThinkPad X220", "Fast 'N Free shipping case", 1)
+		fmt.Fprint(w, `<html><body><ul>`+shippingCard("555", "Fast 'N Free")+shippingCard("556", "Free delivery")+shippingCard("557", "")+titleOnly+`</ul></body></html>`)
+		return true
+	})
+
+	tests := []struct {
+		name     string
+		only     bool
+		want     string
+		filtered int
+	}{
+		{"all items", false, "555,556,557,558", 0},
+		{"fast shipping only", true, "555", 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Crawler{Logger: discardLogger, Workers: 1, FastShippingOnly: test.only}
+			items, err := c.Crawl(context.Background(), search.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(itemIDs(items), ","); got != test.want || c.Stats().Filtered != test.filtered {
+				t.Errorf("got items %s with %d filtered, want %s with %d", got, c.Stats().Filtered, test.want, test.filtered)
+			}
+		})
+	}
+}
+
+func TestStripEmoji(t *testing.T) {
+	tests := []struct {
+		title, want string
+	}{
+		{"🔥🔥 Dell Latitude 7490 🔥 FAST SHIPPING 🚚💨", "Dell Latitude 7490 FAST SHIPPING"},
+		{"⭐️ Apple iPhone 12 ⭐️", "Apple iPhone 12"},
+		{"Gaming PC 👍🏽 RTX 3060", "Gaming PC RTX 3060"},
+		{"Keycap 1️⃣ set 👨‍💻", "Keycap 1 set"},
+		{"Nintendo® Switch™ © 2020 – 32GB – £199", "Nintendo® Switch™ © 2020 – 32GB – £199"},
+		{"Café Größe 15\"", "Café Größe 15\""},
+		{"Plain title", "Plain title"},
+	}
+
+	for _, test := range tests {
+		if got := stripEmoji(test.title); got != test.want {
+			t.Errorf("stripEmoji(%q) = %q, want %q", test.title, got, test.want)
+		}
+	}
+}
+
+func TestStripEmojiCrawl(t *testing.T) {
+	search := newFixtureSearch(t, 1, func(w http.ResponseWriter, page int) bool {
+		fmt.Fprint(w, strings.Replace(fixtureResultsPage(1, []string{"101"}, ""), "Item 101", "🔥 Dell Laptop 🔥", 1))
+		return true
+	})
+
+	for _, strip := range []bool{false, true} {
+		items, err := (&Crawler{Logger: discardLogger, StripEmoji: strip}).Crawl(context.Background(), search.URL())
+		if err != nil || len(items) != 1 {
+			t.Fatalf("got %d items (%v), want 1", len(items), err)
+		}
+
+		want, wantRaw := "🔥 Dell Laptop 🔥", ""
+		if strip {
+			want, wantRaw = "Dell Laptop", "🔥 Dell Laptop 🔥"
+		}
+		if items[0].Title != want || items[0].RawTitle != wantRaw {
+			t.Errorf("strip %t: title %q raw %q, want %q and %q", strip, items[0].Title, items[0].RawTitle, want, wantRaw)
+		}
+	}
+}
+
+func TestSellerInfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		sellerInfo string
+		wantName   string
+		wantRating string
+	}{
+		{"name, count and rating", `<div class="s-item__seller-info"><span class="s-item__seller-info-text">garlandcomputer (12,345) 99.1%</span></div>`, "garlandcomputer", "99.1%"},
+		{"name only", `<span class="s-item__seller-info-text">laptop_outlet</span>`, "laptop_outlet", ""},
+		{"without seller node", "", "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := parseFixtureItem(t, `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">`+
+				`<div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a><span class="s-item__price">$120.00</span>`+
+				test.sellerInfo+`</li></ul>`, "s-item")
+
+			item, err := (&EbayClassicParser{}).ParseItem(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.SellerName != test.wantName || item.SellerRating != test.wantRating {
+				t.Errorf("seller %q rating %q, want %q %q", item.SellerName, item.SellerRating, test.wantName, test.wantRating)
+			}
+		})
+	}
+}
+
+func TestParsePopularity(t *testing.T) {
+	tests := []struct {
+		name         string
+		spans        string
+		wantSold     int
+		wantWatchers int
+	}{
+		{"sold and watching", `<span class="s-item__hotness"><span class="BOLD">12 sold</span></span><span class="s-item__dynamic">5 watching</span>`, 12, 5},
+		{"grouping and plus", `<span class="s-item__hotness s-item__itemHotness">1,204+ sold</span><span class="s-item__dynamic s-item__watchCountTotal">37 watchers</span>`, 1204, 37},
+		{"price isn't sold count", `<span class="s-item__hotness">$12.00 · Almost gone</span>`, 0, 0},
+		{"other span", `<span class="s-item__price">12 sold</span>`, 0, 0},
+		{"absent", "", 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sold, watchers := parsePopularity(parseSnippet(t, "<div>"+test.spans+"</div>"))
+			if sold != test.wantSold || watchers != test.wantWatchers {
+				t.Errorf("got %d sold and %d watchers, want %d and %d", sold, watchers, test.wantSold, test.wantWatchers)
+			}
+		})
+	}
+}
+
+func TestCleanTitle(t *testing.T) {
+	tests := []struct {
+		title, want string
+	}{
+		{"New Listing Dell Latitude 5490", "Dell Latitude 5490"},
+		{"NEW LISTINGDell Latitude 5490", "Dell Latitude 5490"},
+		{"  New Listing   ThinkPad X220  ", "ThinkPad X220"},
+		{"ThinkPad X220 Brand New Listing", "ThinkPad X220 Brand New Listing"},
+		{"SPONSORED Edition Print", "SPONSORED Edition Print"},
+	}
+
+	for _, test := range tests {
+		if got := cleanTitle(test.title); got != test.want {
+			t.Errorf("cleanTitle(%q) = %q, want %q", test.title, got, test.want)
+		}
+	}
+}
+
+func TestGetTitleText(t *testing.T) {
+	tests := []struct {
+		name, title, want string
+	}{
+		{"label before title", `<span role="heading"><span class="s-item__sponsored">SPONSORED</span>ThinkPad X230</span>`, "ThinkPad X230"},
+		{"label after title", `<span role="heading">ThinkPad X230 <span><b>Sponsored</b></span></span>`, "ThinkPad X230"},
+		{"word of the title", `<span role="heading">SPONSORED Edition Print</span>`, "SPONSORED Edition Print"},
+		{"word in title element", `<span role="heading"><b>SPONSORED Edition</b> Print</span>`, "SPONSORED Edition Print"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			titleNode := findFirstElementByAttr(parseSnippet(t, test.title), "span", "role", "heading")
+			got, err := getTitleText(titleNode)
+			if err != nil || got != test.want {
+				t.Errorf("got %q, %v, want %q", got, err, test.want)
+			}
+		})
+	}
+
+	//Title of the label only has no text
+	titleNode := findFirstElementByAttr(parseSnippet(t, `<span role="heading"><span>SPONSORED</span></span>`), "span", "role", "heading")
+	if title, err := getTitleText(titleNode); err == nil {
+		t.Errorf("got title %q of the label only, want error", title)
+	}
+}
+
+func TestSkipSponsored(t *testing.T) {
+	search := newFixtureSearch(t, 0, func(w http.ResponseWriter, page int) bool {
+		fmt.Fprint(w, `<html><body><ul>`+
+			`<li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555"><div class="s-item__title"><span role="heading">New Listing ThinkPad X220</span></div></a><span class="s-item__price">$120.00</span></li>`+
+			`<li class="s-item" id="item2"><a class="s-item__link" href="https://www.ebay.com/itm/556"><div class="s-item__title"><span role="heading"><span class="s-item__sponsored">SPONSORED</span>ThinkPad X230</span></div></a><span class="s-item__price">$130.00</span></li>`+
+			`<li class="s-item" id="item4"><a class="s-item__link" href="https://www.ebay.com/itm/558"><div class="s-item__title"><span role="heading">SPONSORED Edition Print</span></div></a><span class="s-item__price">$15.00</span></li>`+
+			`<li class="s-item" id="item3"><a class="s-item__link" href="https://www.ebay.com/itm/557"><div class="s-item__title"><span role="heading">ThinkPad T480</span></div></a><span class="s-item__price">$140.00</span><span class="s-item__sep"><span>Sponsored</span></span></li>`+
+			`</ul></body></html>`)
+		return true
+	})
+
+	tests := []struct {
+		name string
+		skip bool
+		want string
+	}{
+		{"kept", false, "555,556,558,557"},
+		{"skipped", true, "555,558"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Crawler{Logger: discardLogger, Workers: 1, SkipSponsored: test.skip}
+			items, err := c.Crawl(context.Background(), search.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(itemIDs(items), ","); got != test.want {
+				t.Errorf("got items %s, want %s", got, test.want)
+			}
+			//The injected label is dropped from the title, the word of a genuine title is kept
+			wantTitles := map[string]string{"555": "ThinkPad X220", "556": "ThinkPad X230", "557": "ThinkPad T480", "558": "SPONSORED Edition Print"}
+			for _, item := range items {
+				if item.Title != wantTitles[item.ItemID] {
+					t.Errorf("item %s title = %q, want %q", item.ItemID, item.Title, wantTitles[item.ItemID])
+				}
+			}
+		})
+	}
+}
+
+func TestLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		want     string
+	}{
+		{"from", `<span class="s-item__location s-item__itemLocation">from China</span>`, "China"},
+		{"located in", `<span class="s-item__itemLocation">Located in: United States</span>`, "United States"},
+		{"without prefix", `<span class="s-item__location">Germany</span>`, "Germany"},
+		{"absent", "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := parseFixtureItem(t, `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">`+
+				`<div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a><span class="s-item__price">$120.00</span>`+
+				test.location+`</li></ul>`, "s-item")
+
+			item, err := (&EbayClassicParser{}).ParseItem(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.Location != test.want {
+				t.Errorf("location = %q, want %q", item.Location, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package crawler
+
+import (
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Price span classes of the card layout
+var cardPriceClasses = []string{"s-card__price"}
+
+var bidsRegEx = regexp.MustCompile(`(?i)(\d[\d,]*)\s+bids?\b`)
+
+// Seller row of the card layout: "techstore 99.5% positive (1.2K)"
+var cardSellerRegEx = regexp.MustCompile(`(?i)^(\S+)\s+(\d+(?:\.\d+)?%)\s+positive`)
+
+// Parser of the newer results layout eBay A/B tests: li.s-card cards within srp-river-results,
+// with s-card__* and su-* elements
+type EbayCardParser struct {
+	ItemIDRegEx *regexp.Regexp // pattern extracting item ID from product URL, DefaultItemIDPattern when nil
+	Logger      *slog.Logger   // logger for diagnostics, slog.Default() when nil
+	Verbose     bool           // log at debug level each item card lookup which found nothing
+	// Decimal separator of prices of the crawled site, DomainDecimalSeparator. Empty guesses it from each amount
+	DecimalSeparator string
+}
+
+func (p *EbayCardParser) FindItems(pageNode *html.Node) []*html.Node {
+	cards := findItemElementsByClass(pageNode, "li", "s-card", []*html.Node{})
+
+	itemElementList := make([]*html.Node, 0, len(cards))
+	for _, node := range cards {
+		if findFirstElementByAttr(node, "a", "href", "/itm/") == nil || strings.EqualFold(p.cardTitle(node), placeholderItemTitle) {
+			continue
+		}
+		itemElementList = append(itemElementList, node)
+	}
+
+	if len(cards) > len(itemElementList) {
+		loggerOrDefault(p.Logger).Debug("Skipped placeholder items", "items", len(cards)-len(itemElementList))
+	}
+
+	return itemElementList
+}
+
+func (p *EbayCardParser) ParseItem(node *html.Node) (ItemInfo, error) {
+	item := ItemInfo{}
+
+	itemLink := findFirstElementByAttr(node, "a", "href", "/itm/")
+	if itemLink == nil {
+		p.logSelectorMiss(&item, `a[href*="/itm/"]`, "")
+		return item, newParseError(ErrLinkNotFound, "link", "", "ERROR::Item link node not found")
+	}
+
+	href, err := getElementAttrByName(itemLink, "href")
+	if err != nil {
+		p.logSelectorMiss(&item, `a[href*="/itm/"][href]`, "")
+		return item, newParseError(ErrLinkNotFound, "link", "", "ERROR::%s", err)
+	}
+
+	itemID := extractItemID(patternOrDefault(p.ItemIDRegEx), href, p.Logger)
+
+	priceNode := findFirstElementByAnyClass(node, "span", cardPriceClasses)
+	if priceNode == nil {
+		p.logSelectorMiss(&item, "span.s-card__price", href)
+		return item, newParseError(ErrPriceNotFound, "price", itemID, "ERROR::Price node not found")
+	}
+
+	price, err := getElementNodeVal(priceNode)
+	if err != nil {
+		p.logSelectorMiss(&item, "price text", href)
+		return item, newParseError(ErrPriceNotFound, "price", itemID, "ERROR::Price value not found\n%s", err)
+	}
+
+	title := p.cardTitle(node)
+	if title == "" {
+		p.logSelectorMiss(&item, "div.s-card__title", href)
+		return item, newParseError(ErrTitleNotFound, "title", itemID, "ERROR::Title node not found")
+	}
+
+	item.ItemID = itemID
+	item.ProductURL = href
+	item.Title = title
+
+	subtitleNode := findFirstElementByAttr(node, "div", "class", "s-card__subtitle")
+	if subtitleNode == nil {
+		p.logSelectorMiss(&item, "div.s-card__subtitle", href)
+	} else {
+		item.Subtitle = getNodeText(subtitleNode)
+
+		conditionNode := findFirstElementByAttr(subtitleNode, "span", "class", "su-styled-text")
+		if conditionNode != nil {
+			item.Condition = getNodeText(conditionNode)
+		}
+	}
+
+	setItemPrice(&item, price, p.DecimalSeparator, p.Logger)
+	originalNode := findFirstElementByAttr(node, "span", "class", "strikethrough")
+	if originalNode != nil {
+		originalPrice, _, err := parsePrice(getNodeText(originalNode), p.DecimalSeparator)
+		if err == nil {
+			item.OriginalPrice, item.DiscountPercent = originalPrice, discountPercent(originalPrice, item.Price)
+		}
+	}
+
+	setCardDetails(&item, node)
+	item.BrandOutlet, item.BrandName = parseBrandOutlet(node)
+	item.FastShipping = parseFastShipping(node)
+	item.ListingType = "fixed"
+
+	//Shipping, bids and popularity are separate attribute rows told apart by their text
+	for _, rowNode := range findAllElementsByAttr(node, "div", "class", "s-card__attribute-row", []*html.Node{}) {
+		text := getNodeText(rowNode)
+
+		if item.ShippingRaw == "" && (hasCardMarker(text, "delivery") || hasCardMarker(text, "shipping")) {
+			item.Shipping, item.ShippingRaw = parseShippingText(text, p.DecimalSeparator)
+		}
+
+		if item.Location == "" && locationPrefixRegEx.MatchString(text) {
+			item.Location = locationFromText(text)
+		}
+
+		if matches := bidsRegEx.FindStringSubmatch(text); matches != nil {
+			item.ListingType = "auction"
+			item.Bids, _ = strconv.Atoi(strings.ReplaceAll(matches[1], ",", ""))
+		}
+
+		if matches := itemsSoldRegEx.FindStringSubmatch(text); matches != nil && item.ItemsSold == 0 {
+			item.ItemsSold, _ = strconv.Atoi(strings.ReplaceAll(matches[1], ",", ""))
+		}
+
+		if matches := watchersRegEx.FindStringSubmatch(text); matches != nil && item.Watchers == 0 {
+			item.Watchers, _ = strconv.Atoi(strings.ReplaceAll(matches[1], ",", ""))
+		}
+
+		if matches := cardSellerRegEx.FindStringSubmatch(text); matches != nil && item.SellerName == "" {
+			item.SellerName, item.SellerRating = matches[1], matches[2]
+		}
+	}
+
+	return item, nil
+}
+
+// Function to get card title as displayed, without the hidden "Opens in a new window or tab" note. Empty when absent
+func (p *EbayCardParser) cardTitle(node *html.Node) string {
+	titleDivNode := findFirstElementByClass(node, "div", "s-card__title")
+	if titleDivNode == nil {
+		return ""
+	}
+
+	titleNode := findFirstElementByAttr(titleDivNode, "span", "class", "primary")
+	if titleNode == nil {
+		return getNodeText(titleDivNode)
+	}
+
+	return getNodeText(titleNode)
+}
+
+func (p *EbayCardParser) logSelectorMiss(item *ItemInfo, selector string, href string) {
+	logSelectorMiss(p.Logger, p.Verbose, item, selector, href)
+}
@@ -0,0 +1,46 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Error returned for bot check pages and sign-in redirects served instead of the requested page
+type ChallengeError struct {
+	URL    string
+	Marker string // title marker or redirect which identified the challenge
+}
+
+func (e *ChallengeError) Error() string {
+	return fmt.Sprintf("ERROR::Blocked by eBay bot check at %s (%s)", e.URL, e.Marker)
+}
+
+// Page title parts of eBay and CDN bot check pages
+var challengeTitleMarkers = []string{"Pardon the interruption", "Checking your browser", "Security Measure", "Access Denied"}
+
+var titleRegEx = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// Size of page head searched for the title
+const titleSearchSize = 64 << 10
+
+// Function detects bot check page by its title, or a redirect to the sign-in page. Returns the marker found, empty for regular pages
+func detectChallenge(finalURL *url.URL, body string) string {
+	if finalURL != nil && strings.Contains(finalURL.Host+finalURL.Path, "signin") {
+		return "redirected to " + finalURL.Host + finalURL.Path
+	}
+
+	matches := titleRegEx.FindStringSubmatch(body[:min(len(body), titleSearchSize)])
+	if matches == nil {
+		return ""
+	}
+
+	for _, marker := range challengeTitleMarkers {
+		if hasCardMarker(matches[1], marker) {
+			return "page title " + strings.TrimSpace(matches[1])
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,224 @@
+package crawler
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultUserAgent string = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36"
+
+// Default limit of a decoded response body size
+const DefaultMaxBodySize int64 = 10 << 20
+
+var errBodyTooLarge = errors.New("response body too large")
+
+// Function creates HTTP client verifying that the server leaf certificate matches SHA-256 fingerprint
+func NewPinnedClient(fingerprint string) (*http.Client, error) {
+	pin, err := hex.DecodeString(strings.ReplaceAll(strings.ToLower(fingerprint), ":", ""))
+	if err != nil || len(pin) != sha256.Size {
+		return nil, fmt.Errorf("ERROR::Certificate pin %s must be a hex encoded SHA-256 fingerprint", fingerprint)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("ERROR::Server presented no certificate")
+			}
+
+			sum := sha256.Sum256(rawCerts[0])
+			if hex.EncodeToString(sum[:]) != hex.EncodeToString(pin) {
+				return fmt.Errorf("ERROR::Server certificate fingerprint %x does not match pinned %x", sum, pin)
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// Function routes client requests through the proxy URL. Empty proxy keeps HTTP_PROXY/HTTPS_PROXY environment settings
+func SetProxy(client *http.Client, proxy string) error {
+	proxyFunc := http.ProxyFromEnvironment
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return fmt.Errorf("ERROR::Can't parse proxy URL %s: %s", proxy, err)
+		}
+
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("ERROR::Proxy URL %s must have http, https or socks5 scheme", proxy)
+		}
+
+		if proxyURL.Host == "" {
+			return fmt.Errorf("ERROR::Proxy URL %s must contain host", proxy)
+		}
+
+		proxyFunc = http.ProxyURL(proxyURL)
+	}
+
+	if client.Transport == nil {
+		client.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("ERROR::Proxy can't be set on custom client transport")
+	}
+	transport.Proxy = proxyFunc
+
+	return nil
+}
+
+// Function sets browser-like headers on the request, so eBay serves the real results page
+func setRequestHeaders(req *http.Request, userAgent string) {
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	//Set explicitly, so the transport leaves decoding to decodeBody
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+}
+
+// Function wraps response body with decompressing reader according to Content-Encoding
+func decodeBody(res *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return res.Body, nil
+	case "gzip":
+		return gzip.NewReader(res.Body)
+	case "deflate":
+		return zlib.NewReader(res.Body)
+	default:
+		return nil, fmt.Errorf("ERROR::Unsupported Content-Encoding %s", res.Header.Get("Content-Encoding"))
+	}
+}
+
+// Reader counting bytes read through it
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// Size of page tail searched for the closing html tag
+const htmlTailSize = 4096
+
+// Function checks if page HTML lacks the closing html tag, a sign of a response cut short
+func isTruncatedHTML(body string) bool {
+	tail := body[max(0, len(body)-htmlTailSize):]
+	return !strings.Contains(strings.ToLower(tail), "</html>")
+}
+
+// Function counts bytes read from the network in live metrics and crawl counters
+func (c *Crawler) addBytesDownloaded(n int64) {
+	c.metrics().BytesDownloaded.Add(n)
+
+	c.mu.Lock()
+	c.stats.BytesDownloaded += n
+	c.mu.Unlock()
+}
+
+// Function returns configured response body size limit or the default one
+func (c *Crawler) maxBodySize() int64 {
+	if c.MaxBodySize <= 0 {
+		return DefaultMaxBodySize
+	}
+
+	return c.MaxBodySize
+}
+
+// Function makes GET request to provided URL and returns its response in string format
+func (c *Crawler) getPageHTML(ctx context.Context, url string) (string, error) {
+	if c.Limiter != nil {
+		err := c.Limiter.Wait(ctx)
+		if err != nil {
+			return "", fmt.Errorf("ERROR::Rate limiter wait failed for %s: %w", url, err)
+		}
+	}
+
+	//The limiter wait doesn't count against the request timeout
+	requestCtx := ctx
+	if c.PageTimeout > 0 {
+		var cancel context.CancelFunc
+		requestCtx, cancel = context.WithTimeout(ctx, c.PageTimeout)
+		defer cancel()
+	}
+
+	requestURL := url
+	req, err := http.NewRequestWithContext(requestCtx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("ERROR::Can't create request for %s: %w", url, err)
+	}
+	setRequestHeaders(req, c.userAgent())
+
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		c.metrics().HTTPErrors.Add(1)
+		if ctx.Err() == nil && errors.Is(requestCtx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("ERROR::Request to %s timed out after %s: %w", url, c.PageTimeout, err)
+		}
+		return "", fmt.Errorf("ERROR::Can't make http request to %s: %w", url, err)
+	}
+	defer res.Body.Close()
+
+	//Redirects are followed by the client, so any other status is an error
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		c.metrics().HTTPErrors.Add(1)
+		return "", &HTTPError{URL: url, StatusCode: res.StatusCode, RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now())}
+	}
+
+	maxBodySize := c.maxBodySize()
+	if res.ContentLength > maxBodySize {
+		return "", fmt.Errorf("ERROR::Response body of %s is %d bytes, more than the limit of %d: %w", url, res.ContentLength, maxBodySize, errBodyTooLarge)
+	}
+
+	//Count raw bytes, so a body cut short can be told from a complete one
+	counter := &countingReader{reader: res.Body}
+	res.Body = io.NopCloser(counter)
+
+	bodyReader, err := decodeBody(res)
+	if err != nil {
+		return "", fmt.Errorf("ERROR::Can't decode http response body of %s: %w", url, err)
+	}
+	defer bodyReader.Close()
+
+	//Read one byte over the limit, so a body cut by it can be told from one of exactly the limit size
+	body, err := io.ReadAll(io.LimitReader(bodyReader, maxBodySize+1))
+	c.addBytesDownloaded(counter.count)
+	if err != nil {
+		return "", fmt.Errorf("ERROR::Can't read http response body of %s: %w", url, err)
+	}
+	if int64(len(body)) > maxBodySize {
+		return "", fmt.Errorf("ERROR::Response body of %s is more than the limit of %d bytes: %w", url, maxBodySize, errBodyTooLarge)
+	}
+	if res.ContentLength > 0 && counter.count < res.ContentLength {
+		return "", fmt.Errorf("ERROR::Response body of %s is truncated: got %d of %d bytes: %w", url, counter.count, res.ContentLength, io.ErrUnexpectedEOF)
+	}
+
+	//Bot check pages come with 200 status, sign-in pages after a redirect
+	if marker := detectChallenge(res.Request.URL, string(body)); marker != "" {
+		return "", &ChallengeError{URL: url, Marker: marker}
+	}
+
+	return string(body), nil
+}
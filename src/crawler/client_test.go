@@ -0,0 +1,404 @@
+package crawler
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Results page with one classic item card, linking to the next page when next isn't empty
+func fixturePage(itemID string, next string) string {
+	page := `<html><body><ul><li class="s-item" id="item` + itemID + `"><a class="s-item__link" href="https://www.ebay.com/itm/` + itemID + `">` +
+		`<div class="s-item__title"><span role="heading">Item ` + itemID + `</span></div></a>` +
+		`<span class="s-item__price">$10.00</span></li></ul>`
+	if next != "" {
+		page += `<a class="pagination__next icon-link" href="` + next + `">next</a>`
+	}
+
+	return page + `</body></html>`
+}
+
+func TestCompressedResponse(t *testing.T) {
+	page := fixturePage("111", "")
+
+	tests := []struct {
+		encoding string
+		writer   func(w io.Writer) io.WriteCloser
+	}{
+		{"gzip", func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }},
+		{"deflate", func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.encoding, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.Contains(r.Header.Get("Accept-Encoding"), test.encoding) {
+					t.Errorf("Accept-Encoding = %q, want %s", r.Header.Get("Accept-Encoding"), test.encoding)
+				}
+				w.Header().Set("Content-Encoding", test.encoding)
+				writer := test.writer(w)
+				fmt.Fprint(writer, page)
+				writer.Close()
+			}))
+			defer server.Close()
+
+			c := &Crawler{Logger: discardLogger}
+			got, err := c.getPageHTML(context.Background(), server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != page {
+				t.Errorf("got page %q, want %q", got, page)
+			}
+		})
+	}
+}
+
+func TestPageTimeoutRetried(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//The first request hangs past the page timeout, the retry is answered at once
+		if requests.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		fmt.Fprint(w, fixturePage("111", ""))
+	}))
+	defer server.Close()
+
+	c := &Crawler{Logger: discardLogger, Retries: 1, PageTimeout: 100 * time.Millisecond}
+	items, err := c.Crawl(context.Background(), server.URL+"/sch/i.html")
+	if err != nil {
+		t.Fatalf("crawl failed: %s", err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("%d requests made, want the timed out one and its retry", requests.Load())
+	}
+	if len(items) != 1 || items[0].ItemID != "111" {
+		t.Errorf("got items %v, want item 111", items)
+	}
+}
+
+func TestPageTimeoutNotRetriedWithoutRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	c := &Crawler{Logger: discardLogger, PageTimeout: 50 * time.Millisecond}
+	_, err := c.getPageHTML(context.Background(), server.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want deadline exceeded", err)
+	}
+	if !isRetryableError(err) {
+		t.Errorf("page timeout error %v is not retryable", err)
+	}
+}
+
+func TestRunTimeoutReturnsPartialItems(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//The first page answers at once, the second one outlasts the run timeout
+		if r.URL.Query().Get("_pgn") == "" {
+			fmt.Fprint(w, fixturePage("111", server.URL+"/sch/i.html?_pgn=2"))
+			return
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	c := &Crawler{Logger: discardLogger, Retries: 3, PageTimeout: time.Minute}
+	start := time.Now()
+	items, err := c.Crawl(ctx, server.URL+"/sch/i.html")
+	if err == nil {
+		t.Fatal("crawl stopped by the run timeout returned no error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("crawl took %s, the run timeout wasn't honored", elapsed)
+	}
+	if len(items) != 1 || items[0].ItemID != "111" {
+		t.Errorf("got items %v, want item 111 of the first page", items)
+	}
+}
+
+func TestCrawlDeadlineStopsWorkers(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//Results pages answer at once, detail pages of the enriched items outlast the deadline
+		if strings.HasPrefix(r.URL.Path, "/itm/") {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		fmt.Fprint(w, strings.ReplaceAll(fixtureResultsPage(4, []string{"111", "112", "113", "114"}, ""), "https://www.ebay.com", server.URL))
+	}))
+	defer server.Close()
+
+	goroutines := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	c := &Crawler{Logger: discardLogger, Workers: 4, Enrich: true}
+	_, err := c.Crawl(ctx, server.URL+"/sch/i.html")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, ctx.Err())
+	}
+
+	//Idle keep-alive connections of the default client aren't goroutines of the crawl
+	http.DefaultClient.CloseIdleConnections()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > goroutines {
+		t.Errorf("%d goroutines running after the crawl returned, want at most %d", n, goroutines)
+	}
+}
+
+func TestPinnedClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, fixturePage("111", ""))
+	}))
+	defer server.Close()
+
+	sum := sha256.Sum256(server.Certificate().Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+	colonFingerprint := ""
+	for i, b := range sum {
+		if i > 0 {
+			colonFingerprint += ":"
+		}
+		colonFingerprint += fmt.Sprintf("%02X", b)
+	}
+
+	tests := []struct {
+		name    string
+		pin     string
+		wantErr bool
+	}{
+		{"match", fingerprint, false},
+		{"match with colons", colonFingerprint, false},
+		{"mismatch", strings.Repeat("ab", sha256.Size), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, err := NewPinnedClient(test.pin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			//The pin is checked on top of the usual verification, which must trust the test server
+			client.Transport.(*http.Transport).TLSClientConfig.RootCAs = server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+			c := &Crawler{Logger: discardLogger, HTTPClient: client}
+			items, err := c.Crawl(context.Background(), server.URL+"/sch/i.html")
+			if test.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "does not match pinned") {
+					t.Fatalf("got %v, want pin mismatch error", err)
+				}
+				if isRetryableError(err) {
+					t.Errorf("pin mismatch %v is retryable", err)
+				}
+				return
+			}
+			if err != nil || len(items) != 1 {
+				t.Fatalf("got %d items and %v, want the item of the pinned server", len(items), err)
+			}
+		})
+	}
+
+	for _, pin := range []string{"", "abc", "zz" + fingerprint[2:], fingerprint[2:]} {
+		if _, err := NewPinnedClient(pin); err == nil {
+			t.Errorf("got no error for pin %q", pin)
+		}
+	}
+}
+
+func TestTruncatedBodyRetried(t *testing.T) {
+	page := fixturePage("111", "")
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > 1 {
+			fmt.Fprint(w, page)
+			return
+		}
+
+		//The first response announces the whole page but the connection drops after half of it
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Length: %d\r\n\r\n%s", len(page), page[:len(page)/2])
+		buf.Flush()
+	}))
+	defer server.Close()
+
+	c := &Crawler{Logger: discardLogger}
+	_, err := c.getPageHTML(context.Background(), server.URL)
+	if err == nil || !isRetryableError(err) {
+		t.Fatalf("got %v, want retryable error for truncated body", err)
+	}
+
+	requests.Store(0)
+	c = &Crawler{Logger: discardLogger, Retries: 1}
+	items, err := c.Crawl(context.Background(), server.URL+"/sch/i.html")
+	if err != nil {
+		t.Fatalf("crawl failed: %s", err)
+	}
+	if requests.Load() != 2 || len(items) != 1 || items[0].ItemID != "111" {
+		t.Errorf("got items %v after %d requests, want item 111 of the retried page", items, requests.Load())
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	page := fixturePage("111", "")
+
+	tests := []struct {
+		name    string
+		chunked bool
+	}{
+		{"content length", false},
+		{"chunked", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.chunked {
+					w.(http.Flusher).Flush()
+				}
+				fmt.Fprint(w, page)
+			}))
+			defer server.Close()
+
+			c := &Crawler{Logger: discardLogger, MaxBodySize: int64(len(page) - 1)}
+			_, err := c.getPageHTML(context.Background(), server.URL)
+			if !errors.Is(err, errBodyTooLarge) {
+				t.Fatalf("got %v, want body too large error", err)
+			}
+			if isRetryableError(err) {
+				t.Errorf("body too large error %v is retryable", err)
+			}
+
+			//A body of exactly the limit is read
+			c = &Crawler{Logger: discardLogger, MaxBodySize: int64(len(page))}
+			body, err := c.getPageHTML(context.Background(), server.URL)
+			if err != nil || body != page {
+				t.Errorf("got %q, %v, want the page", body, err)
+			}
+			if c.Stats().BytesDownloaded != int64(len(page)) {
+				t.Errorf("%d bytes downloaded, want %d", c.Stats().BytesDownloaded, len(page))
+			}
+		})
+	}
+}
+
+func TestBotChallenge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/challenge":
+			fmt.Fprint(w, `<html><head><title>Pardon the interruption...</title></head><body>Please verify yourself</body></html>`)
+		case "/forbidden":
+			http.Error(w, "Access denied", http.StatusForbidden)
+		default:
+			fmt.Fprint(w, fixturePage("111", ""))
+		}
+	}))
+	defer server.Close()
+
+	c := &Crawler{Logger: discardLogger}
+
+	_, err := c.getPageHTML(context.Background(), server.URL+"/challenge")
+	var challengeErr *ChallengeError
+	if !errors.As(err, &challengeErr) || !strings.Contains(challengeErr.Marker, "Pardon the interruption") {
+		t.Errorf("got %v for challenge page, want ChallengeError", err)
+	}
+
+	_, err = c.getPageHTML(context.Background(), server.URL+"/forbidden")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusForbidden {
+		t.Errorf("got %v for forbidden page, want HTTPError with status 403", err)
+	}
+	if isRetryableError(err) {
+		t.Errorf("403 error %v is retryable", err)
+	}
+
+	if _, err = c.getPageHTML(context.Background(), server.URL+"/sch/i.html"); err != nil {
+		t.Errorf("got %v for regular page, want no error", err)
+	}
+}
+
+func TestLimiterSharedByAllRequests(t *testing.T) {
+	var requests atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if strings.HasPrefix(r.URL.Path, "/itm/") {
+			fmt.Fprint(w, specificsDetailPage)
+			return
+		}
+		fmt.Fprint(w, strings.ReplaceAll(fixtureResultsPage(3, []string{"111", "112", "113"}, ""), "https://www.ebay.com", server.URL))
+	}))
+	defer server.Close()
+
+	//The results page and three detail pages at 10 requests per second, the first one passes at once
+	c := &Crawler{Logger: discardLogger, Workers: 3, Enrich: true, Limiter: rate.NewLimiter(10, 1)}
+	start := time.Now()
+	items, err := c.Crawl(context.Background(), server.URL+"/sch/i.html")
+	if err != nil {
+		t.Fatalf("crawl failed: %s", err)
+	}
+	if len(items) != 3 || requests.Load() != 4 {
+		t.Fatalf("got %d items after %d requests, want 3 items after 4 requests", len(items), requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed < 290*time.Millisecond {
+		t.Errorf("4 requests took %s, want at least 300ms at 10 requests per second", elapsed)
+	}
+}
+
+func TestLimiterWaitCancelled(t *testing.T) {
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	limiter.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	c := &Crawler{Logger: discardLogger, Limiter: limiter}
+	start := time.Now()
+	_, err := c.getPageHTML(ctx, "http://127.0.0.1/unreachable")
+	if err == nil {
+		t.Fatal("request waiting on the limiter wasn't stopped")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled limiter wait took %s", elapsed)
+	}
+}
@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prefix Netscape cookie files put before the domain of HttpOnly cookies
+const httpOnlyPrefix string = "#HttpOnly_"
+
+// Function parses "name=value" cookie given on the command line
+func ParseCookie(value string) (*http.Cookie, error) {
+	name, cookieValue, ok := strings.Cut(value, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("ERROR::Cookie must have name=value form, got value without '=' or name")
+	}
+
+	return &http.Cookie{Name: name, Value: strings.TrimSpace(cookieValue)}, nil
+}
+
+// Function reads cookies from a Netscape format cookie file (as exported by browsers and curl).
+// Errors never contain cookie values
+func LoadCookieFile(path string) ([]*http.Cookie, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't open cookie file %s: %s", path, err)
+	}
+	defer file.Close()
+
+	cookies := []*http.Cookie{}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		//domain, include subdomains, path, secure, expiry, name, value
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("ERROR::Invalid cookie file %s line %d: expected 7 tab separated fields, got %d", path, lineNumber, len(fields))
+		}
+
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ERROR::Invalid cookie file %s line %d: expiry must be a Unix time", path, lineNumber)
+		}
+
+		cookie := &http.Cookie{
+			Domain:   strings.TrimPrefix(fields[0], "."),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		//Zero expiry marks a session cookie
+		if expiry > 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+		}
+
+		cookies = append(cookies, cookie)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't read cookie file %s: %s", path, err)
+	}
+
+	return cookies, nil
+}
+
+// Function attaches cookie jar to the client. Cookies with a domain (from cookie files) apply to it,
+// cookies without one are sent to the hosts of siteURLs
+func SetCookies(client *http.Client, cookies []*http.Cookie, siteURLs []string) error {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't create cookie jar: %s", err)
+	}
+
+	for _, cookie := range cookies {
+		if cookie.Domain != "" {
+			path := cookie.Path
+			if path == "" {
+				path = "/"
+			}
+
+			jar.SetCookies(&url.URL{Scheme: "https", Host: cookie.Domain, Path: path}, []*http.Cookie{cookie})
+			continue
+		}
+
+		for _, siteURL := range siteURLs {
+			parsedURL, err := url.Parse(siteURL)
+			if err != nil {
+				return fmt.Errorf("ERROR::Can't parse URL %s: %s", siteURL, err)
+			}
+
+			jar.SetCookies(&url.URL{Scheme: parsedURL.Scheme, Host: parsedURL.Host, Path: "/"}, []*http.Cookie{{Name: cookie.Name, Value: cookie.Value, Path: "/"}})
+		}
+	}
+
+	client.Jar = jar
+
+	return nil
+}
@@ -0,0 +1,1070 @@
+package crawler
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/time/rate"
+)
+
+// Default number of workers processing items of a page
+const DefaultWorkers = 8
+
+// Candidate price classes in order of preference (eBay A/B tests different names)
+var DefaultPriceClasses = []string{"s-item__price", "s-item__detail-price", "s-item__price-current", "s-card__price"}
+
+// Crawler of eBay listing pages. Zero value is usable, unset fields fall back to defaults
+type Crawler struct {
+	HTTPClient *http.Client  // client used for all requests, http.DefaultClient when nil
+	UserAgent  string        // User-Agent header, DefaultUserAgent when empty
+	Workers    int           // number of workers processing items of a page (and fetching pages with ConcurrentPages), DefaultWorkers when not positive
+	Retries    int           // number of retries of a failed request
+	Limiter    *rate.Limiter // limiter shared by all requests, nil means no limit
+	Logger     *slog.Logger  // logger for progress and diagnostics, slog.Default() when nil
+	Verbose    bool          // log at debug level each item card lookup which found nothing
+	Metrics    *Metrics      // live counters updated during the crawl, nil when not needed
+
+	CacheDir     string // directory fetched pages are cached in, empty disables the cache
+	RefreshCache bool   // fetch pages even when cached, overwriting the cache
+
+	PageTimeout        time.Duration // maximum time of a single request, derived from the crawl context. A request which times out is retried, 0 means no limit
+	ParallelParse      int           // number of page parsers working while next pages are fetched, 0 means one by one
+	PageProcessTimeout time.Duration // maximum time to process items of a single page, 0 means no limit
+	MinItemsPerPage    int           // warn when a non-final page has fewer items, 0 disables the check
+	MinSuccessRate     float64       // pages where a smaller share (0-1) of items parse are counted in Stats.LowSuccessPages, 0 disables the check
+	MaxPages           int           // maximum number of pages to crawl, 0 means no limit
+	Sample             int           // process only this many first item cards of the crawl and stop, 0 means all
+	MaxItems           int           // stop the crawl once this many items were emitted, later items are dropped. 0 means no limit
+	MaxBodySize        int64         // limit of a decoded response body size in bytes, DefaultMaxBodySize when not positive
+	PageDelay          time.Duration // pause between page requests, not applied before the first one
+	ConcurrentPages    bool          // fetch search result pages concurrently when their count and page parameter are known
+	AllowedHosts       []string      // hosts next page links may point to besides the start URL one, subdomains included
+
+	Parser    ItemParser       // parser of item cards, EbayClassicParser with Selectors, PriceClasses and ItemIDRegEx when nil. Parsers of this package without Logger use the crawler one
+	Selectors ClassicSelectors // class names of classic layout card elements, empty ones fall back to DefaultClassicSelectors
+
+	IncludeBanners     bool           // also parse product links of sponsored brand banners
+	IncludeRelated     bool           // also parse items listed after "Results matching fewer words", tagged with Related
+	URLsOnly           bool           // only collect product URLs without parsing items
+	PriceClasses       []string       // price span classes to try in order, DefaultPriceClasses when empty
+	ItemIDRegEx        *regexp.Regexp // pattern extracting item ID from product URL, DefaultItemIDPattern when nil
+	DecimalSeparator   string         // decimal separator of prices of the crawled site, DomainDecimalSeparator; empty guesses it
+	StripEmoji         bool           // remove emoji from item titles
+	NormalizeCondition bool           // canonicalize condition text
+	FastShippingOnly   bool           // keep only items with fast shipping perk
+	SkipSponsored      bool           // drop sponsored listings
+	Enrich             bool           // fetch detail page of every item for item specifics, quantity and description
+	FollowVariations   bool           // with Enrich, emit an item per variation of multi-variation listings instead of the listing
+	RetryFailedItems   bool           // with Enrich, fetch detail pages which failed with a transient error again after the crawl
+	MaxEnrichFailures  int            // with Enrich, stop enriching after more consecutive detail page failures, 0 means no limit
+
+	Source       string           // tag stored in source field of crawled items, e.g. seller name
+	PriceFilter  *PriceFilter     // price range filter, nil when not set
+	TitleFilter  *TitleFilter     // title keyword filter, nil when not set
+	SellerFilter *SellerFilter    // seller blocklist, nil when not set
+	PhotoFilter  *PhotoFilter     // minimal photo count filter, applied after enrichment, nil when not set
+	Affiliate    *AffiliateParams // affiliate parameters appended to product URLs, nil when not set
+
+	// Key of items which are duplicates within a crawl, e.g. their title, item ID when nil
+	DedupKey func(item *ItemInfo) string
+	// Called for each parsed item before OnItem, possibly from several goroutines. It may change the item, returning
+	// ErrSkipItem drops it as filtered out, any other error counts the item as failed. With URLsOnly it gets items
+	// holding only item ID and URLs
+	ItemHook func(item *ItemInfo) error
+	// Called for each parsed item, possibly from several goroutines. Returned error counts the item as failed
+	OnItem func(item *ItemInfo) error
+	// Called for each item card lookup which found nothing and each card which failed to parse, possibly from several goroutines
+	OnParseFailure func(failure ParseFailure)
+
+	mu      sync.Mutex
+	items   []ItemInfo
+	seenIDs map[string]bool
+	stats   Stats
+
+	//Number of items on the first page, used to estimate expected total when -max-pages stops the crawl
+	firstPageItems int
+
+	//Item cards left to process with Sample set
+	sampleLeft int
+	//Items emitted or being emitted, checked against MaxItems
+	emitted int
+	//Items whose detail page failed with a transient error, enriched again by the retry pass
+	failedItems []*ItemInfo
+	//Consecutive detail page failures, enrichment stops when they exceed MaxEnrichFailures
+	enrichFailures int
+	enrichStopped  bool
+
+	//Counters updated when Metrics is nil
+	unusedMetrics Metrics
+
+	//Crawled pages in order and whether all their items were processed, see ResumePoint
+	pageURLs  []string
+	pagesDone []bool
+}
+
+// Counters of the last crawl
+type Stats struct {
+	Pages      int
+	ItemsFound int
+	Failures   int
+	Duplicates int // items repeated by pagination, skipped
+	Filtered   int // items dropped by price, title, seller, fast shipping or sponsored filters
+	StoreName  string
+	// Pages where share of successfully parsed items was below MinSuccessRate
+	LowSuccessPages int
+	// Bytes of response bodies read from the network (compressed size when compressed)
+	BytesDownloaded int64
+	// Pages whose items were not all processed within PageProcessTimeout
+	AbandonedPages int
+	// Total number of results reported by the first page header, 0 when absent
+	ResultCount int
+	// The first page reported no results for the search, the crawl succeeded without items
+	NoResults bool
+}
+
+// Items of a fetched page waiting to be parsed
+type pageJob struct {
+	index           int
+	url             string
+	itemElementList []*html.Node
+	relatedFrom     int
+	storeName       string
+	category        string // category of the page, for items without their own
+}
+
+// Function returns counters of the last crawl
+func (c *Crawler) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+// Function crawls listing pages starting from startURL and following pagination.
+// Returns items collected so far together with the error which stopped the crawl, ctx.Err() when it was cancelled
+func (c *Crawler) Crawl(ctx context.Context, startURL string) ([]ItemInfo, error) {
+	_, err := c.crawl(ctx, startURL, false)
+
+	return c.sortedItems(), err
+}
+
+// Page of results crawled by CrawlPage
+type Page struct {
+	URL         string
+	Items       []ItemInfo
+	NextURL     string // URL of the next page, empty on the last page or when its link can't be followed
+	StoreName   string
+	ResultCount int  // total number of results reported by the page header, 0 when absent
+	NoResults   bool // the page reports no results for the search
+}
+
+// Function fetches and processes a single listing page without following pagination, as the first page of Crawl: the
+// page items pass the filters and OnItem, and Stats count the page. A page without items fails unless it reports no results
+func (c *Crawler) CrawlPage(ctx context.Context, pageURL string) (*Page, error) {
+	step, err := c.crawl(ctx, pageURL, true)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := c.Stats()
+	page := &Page{URL: pageURL, Items: c.sortedItems(), StoreName: stats.StoreName, ResultCount: stats.ResultCount, NoResults: stats.NoResults}
+	if step != nil {
+		page.NextURL = step.nextURL
+	}
+
+	return page, nil
+}
+
+// Function crawls listing pages starting from startURL for Crawl and CrawlPage, stopping after the first page when
+// singlePage is set. Returns the step of the last page fetched one by one, nil when the crawl stopped before any
+func (c *Crawler) crawl(ctx context.Context, startURL string, singlePage bool) (*pageStep, error) {
+	c.reset()
+
+	var failures atomic.Int64
+
+	//Start parsers pool, so fetching of the next page overlaps with parsing of the current one
+	var pageJobs chan pageJob
+	parsersWG := new(sync.WaitGroup)
+	if c.ParallelParse > 0 {
+		pageJobs = make(chan pageJob, c.ParallelParse)
+		parsersWG.Add(c.ParallelParse)
+
+		for i := 0; i < c.ParallelParse; i++ {
+			go func() {
+				defer parsersWG.Done()
+				for job := range pageJobs {
+					if c.processPageItems(ctx, job, &failures) {
+						c.pageDone(job.index)
+					}
+				}
+			}()
+		}
+	}
+
+	step, err := c.crawlPages(ctx, startURL, singlePage, pageJobs, &failures)
+
+	if pageJobs != nil {
+		close(pageJobs)
+		parsersWG.Wait()
+	}
+	c.retryFailedItems(ctx, &failures)
+
+	c.mu.Lock()
+	c.stats.Failures = int(failures.Load())
+	c.mu.Unlock()
+
+	//Cancelled while the last pages were parsed
+	if err == nil {
+		err = ctx.Err()
+	}
+	//A single page can't be compared with the result count of the whole search
+	if err == nil && c.Sample <= 0 && !singlePage {
+		c.checkResultCount()
+	}
+
+	return step, err
+}
+
+// Function clears results and counters of the previous crawl
+func (c *Crawler) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = nil
+	c.pageURLs = nil
+	c.pagesDone = nil
+	c.seenIDs = map[string]bool{}
+	c.stats = Stats{}
+	c.sampleLeft = c.Sample
+	c.emitted = 0
+	c.failedItems = nil
+	c.enrichFailures = 0
+	c.enrichStopped = false
+}
+
+// Function returns items of the crawl in the order they appear on the crawled pages
+func (c *Crawler) sortedItems() []ItemInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	//Items are processed concurrently, so order them as they appear on the crawled pages
+	slices.SortStableFunc(c.items, func(a, b ItemInfo) int {
+		return cmp.Or(cmp.Compare(a.page, b.page), cmp.Compare(a.position, b.position))
+	})
+
+	return c.items
+}
+
+// Function fetches pages one by one, processing their items or passing them to parsers, only the first page when
+// singlePage is set. Returns the step of the last page fetched one by one
+func (c *Crawler) crawlPages(ctx context.Context, pageURL string, singlePage bool, pageJobs chan pageJob, failures *atomic.Int64) (*pageStep, error) {
+	storeName := ""
+	pages := 0
+	startURL := pageURL
+	var last *pageStep
+
+	for {
+		if ctx.Err() != nil {
+			return last, ctx.Err()
+		}
+
+		step, err := c.crawlPage(ctx, pageURL, startURL, pages, storeName, pageJobs, failures)
+		if err != nil || step == nil {
+			return last, err
+		}
+		last = step
+		pages++
+		storeName = step.storeName
+
+		if singlePage {
+			return last, nil
+		}
+
+		//If there are more pages - iterate
+		if !step.hasMorePages {
+			return last, nil
+		}
+
+		if c.sampleDone() {
+			c.logger().Info("Collected sample, stopping crawl", "sample", c.Sample)
+			return last, nil
+		}
+
+		if c.itemLimitReached() {
+			c.logger().Info("Reached item limit, stopping crawl", "max_items", c.MaxItems)
+			return last, nil
+		}
+
+		if c.MaxPages > 0 && pages >= c.MaxPages {
+			c.logger().Info("Reached page limit, stopping crawl", "max_pages", c.MaxPages)
+			return last, nil
+		}
+
+		if step.nextURL == "" {
+			return last, nil
+		}
+		pageURL = step.nextURL
+
+		//With search results the remaining page URLs are known after the first page
+		if c.ConcurrentPages && pages == 1 {
+			pageURLs := c.predictPageURLs(pageURL)
+			if pageURLs != nil {
+				return last, c.crawlPagesConcurrently(ctx, pageURLs, storeName, failures)
+			}
+
+			c.logger().Info("Page count or page parameter unknown, fetching pages one by one")
+		}
+	}
+}
+
+// Outcome of a crawled page
+type pageStep struct {
+	storeName    string // store name of the crawl, read from the first page which has it
+	hasMorePages bool   // the page links to a next one
+	nextURL      string // URL of the next page, empty when the link can't be followed
+}
+
+// Function fetches a page of the crawl after the page delay and processes its items, pages counting pages crawled
+// before it. Returns nil step when the crawl stops at this page without an error: the search has no results or a
+// later page has no items
+func (c *Crawler) crawlPage(ctx context.Context, pageURL string, startURL string, pages int, storeName string, pageJobs chan pageJob, failures *atomic.Int64) (*pageStep, error) {
+	pageIndex := c.trackPage(pageURL)
+
+	//Pause between pages, so the crawl doesn't hammer the site
+	if pages > 0 && c.PageDelay > 0 && !c.isCached(pageURL) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.PageDelay):
+		}
+	}
+
+	c.metrics().CurrentPage.Store(int64(pages + 1))
+
+	//Get HTML from the provided URL
+	pageHTML, itemElementList, err := c.loadPage(ctx, pageURL)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, err
+	}
+	if len(itemElementList) == 0 {
+		//Nothing matches the search, which is not a failure
+		if pages == 0 && isNoResultsPage(pageHTML) {
+			c.logger().Info("Search returned no results", "url", pageURL)
+			c.mu.Lock()
+			c.stats.NoResults = true
+			c.mu.Unlock()
+			return nil, nil
+		}
+
+		return nil, c.stopOnBadPage(pages, fmt.Errorf("ERROR::Failed to get items from %s", pageURL))
+	}
+
+	//Check if there are more then one page of results
+	nextButtonNode := findFirstElementByAttr(pageHTML, "a", "class", "pagination__next icon-link")
+	step := &pageStep{storeName: storeName, hasMorePages: nextButtonNode != nil}
+
+	//Too few items on a non-final page usually means partial loading or blocking
+	if step.hasMorePages && len(itemElementList) < c.MinItemsPerPage {
+		c.logger().Warn("Page has too few items, results may be incomplete", "url", pageURL, "items", len(itemElementList), "expected", c.MinItemsPerPage)
+	}
+
+	//Get store name from the store header (missing in search results)
+	if step.storeName == "" {
+		step.storeName = getStoreName(pageHTML)
+	}
+
+	c.mu.Lock()
+	if pages == 0 {
+		c.stats.ResultCount = parseResultCount(pageHTML)
+		c.firstPageItems = len(itemElementList)
+	}
+	c.mu.Unlock()
+
+	err = c.handlePage(ctx, pageIndex, pageURL, pageHTML, itemElementList, step.storeName, pageJobs, failures)
+	if err != nil {
+		return nil, err
+	}
+
+	if step.hasMorePages {
+		step.nextURL = c.nextPageURL(nextButtonNode, pageURL, startURL)
+	}
+
+	return step, nil
+}
+
+// Function returns URL of the next page link, empty when it has no href or points off-site
+func (c *Crawler) nextPageURL(nextButtonNode *html.Node, pageURL string, startURL string) string {
+	nextHref, err := getElementAttrByName(nextButtonNode, "href")
+	if err != nil {
+		c.logger().Error("Failed to get next page", "err", err)
+		return ""
+	}
+
+	nextHref = strings.TrimSpace(nextHref)
+	if nextHref == "" {
+		c.logger().Debug("Next page link has empty href, no more pages")
+		return ""
+	}
+
+	nextURL, err := resolveURL(pageURL, nextHref)
+	if err != nil {
+		c.logger().Error("Failed to get next page", "err", err)
+		return ""
+	}
+
+	//Changed markup or an injected link must not send requests to arbitrary hosts
+	if !isAllowedHost(nextURL, startURL, c.AllowedHosts) {
+		c.logger().Warn("Next page link points off-site, stopping crawl", "url", nextURL, "start_url", startURL)
+		return ""
+	}
+
+	return nextURL
+}
+
+// Function counts page items and processes them, or passes them to parsers while next page is fetched
+func (c *Crawler) handlePage(ctx context.Context, pageIndex int, pageURL string, pageHTML *html.Node, itemElementList []*html.Node, storeName string, pageJobs chan pageJob, failures *atomic.Int64) error {
+	relatedFrom := relatedItemsStart(pageHTML, itemElementList)
+	if !c.IncludeRelated && relatedFrom < len(itemElementList) {
+		c.logger().Info("Skipped items matching fewer words", "url", pageURL, "items", len(itemElementList)-relatedFrom)
+		itemElementList = itemElementList[:relatedFrom]
+	}
+
+	//Sample was completed by pages fetched concurrently
+	if c.sampleDone() {
+		return nil
+	}
+	itemElementList = c.takeSample(itemElementList)
+
+	c.mu.Lock()
+	c.stats.Pages++
+	c.stats.ItemsFound += len(itemElementList)
+	c.stats.StoreName = storeName
+	c.mu.Unlock()
+
+	c.logger().Info("Found items", "url", pageURL, "items", len(itemElementList))
+
+	if c.IncludeBanners && !c.URLsOnly {
+		bannerItems, bannerFailures := c.processBannerNodes(pageHTML, pageIndex, pageURL, storeName)
+		if bannerItems > 0 {
+			c.logger().Info("Found banner items", "url", pageURL, "items", bannerItems)
+		}
+		failures.Add(int64(bannerFailures))
+	}
+
+	job := pageJob{index: pageIndex, url: pageURL, itemElementList: itemElementList, relatedFrom: relatedFrom, storeName: storeName, category: getPageCategory(pageHTML)}
+
+	if c.URLsOnly {
+		c.collectItemURLs(pageIndex, pageURL, itemElementList, failures)
+		c.pageDone(pageIndex)
+	} else if pageJobs != nil {
+		select {
+		case pageJobs <- job:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	} else {
+		if c.processPageItems(ctx, job, failures) {
+			c.pageDone(pageIndex)
+		}
+	}
+
+	return nil
+}
+
+// Function collects product URLs of the item nodes as items with item ID and URLs only. They pass ItemHook like parsed
+// items, so filters of the caller apply to them too
+func (c *Crawler) collectItemURLs(pageIndex int, pageURL string, itemElementList []*html.Node, failures *atomic.Int64) {
+	urlItems := []ItemInfo{}
+	filtered := 0
+	for i, itemURL := range getItemURLs(itemElementList, c.Selectors.withDefaults().Link) {
+		item := ItemInfo{ItemID: c.extractItemID(itemURL), ProductURL: canonicalProductURL(pageURL, itemURL), SourceURL: pageURL, page: pageIndex, position: i}
+
+		if c.ItemHook != nil {
+			err := c.ItemHook(&item)
+			if errors.Is(err, ErrSkipItem) {
+				filtered++
+				continue
+			}
+			if err != nil {
+				c.logger().Debug("Item failed", "url", item.ProductURL, "err", err)
+				failures.Add(1)
+				continue
+			}
+		}
+
+		urlItems = append(urlItems, item)
+	}
+
+	c.mu.Lock()
+	c.items = append(c.items, urlItems...)
+	c.stats.Filtered += filtered
+	c.mu.Unlock()
+}
+
+// Function truncates item nodes of a page to the part of Sample not taken by previous pages, all nodes without Sample
+func (c *Crawler) takeSample(itemElementList []*html.Node) []*html.Node {
+	if c.Sample <= 0 {
+		return itemElementList
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	taken := min(len(itemElementList), c.sampleLeft)
+	c.sampleLeft -= taken
+
+	return itemElementList[:taken]
+}
+
+// Function checks if all items of Sample were taken
+func (c *Crawler) sampleDone() bool {
+	if c.Sample <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.sampleLeft == 0
+}
+
+// Function checks if MaxItems items were emitted
+func (c *Crawler) itemLimitReached() bool {
+	if c.MaxItems <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.emitted >= c.MaxItems
+}
+
+// Search results page parameter
+const pageParam string = "_pgn"
+
+// Function builds URLs of the pages following the first one from URL of the second page, incrementing its page
+// parameter up to the page count estimated from result count. Returns nil when the page parameter or the count is unknown
+func (c *Crawler) predictPageURLs(secondPageURL string) []string {
+	u, err := url.Parse(secondPageURL)
+	if err != nil || u.Query().Get(pageParam) != "2" {
+		return nil
+	}
+
+	c.mu.Lock()
+	resultCount := c.stats.ResultCount
+	firstPageItems := c.firstPageItems
+	c.mu.Unlock()
+
+	if resultCount == 0 || firstPageItems == 0 {
+		return nil
+	}
+
+	totalPages := (resultCount + firstPageItems - 1) / firstPageItems
+	if c.MaxPages > 0 {
+		totalPages = min(totalPages, c.MaxPages)
+	}
+	if totalPages < 2 {
+		return nil
+	}
+
+	pageURLs := []string{}
+	for page := 2; page <= totalPages; page++ {
+		query := u.Query()
+		query.Set(pageParam, strconv.Itoa(page))
+		u.RawQuery = query.Encode()
+		pageURLs = append(pageURLs, u.String())
+	}
+
+	return pageURLs
+}
+
+// Function fetches pages by a bounded pool of workers, processing items of each page as it arrives.
+// Like with pages fetched one by one, a page without items stops the crawl keeping the pages before it, and the first
+// failed page cancels the others and its error is returned
+func (c *Crawler) crawlPagesConcurrently(ctx context.Context, pageURLs []string, storeName string, failures *atomic.Int64) error {
+	c.logger().Info("Fetching pages concurrently", "pages", len(pageURLs)+1, "workers", c.workers())
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type pageTask struct {
+		index  int
+		number int
+		url    string
+	}
+
+	//Register all pages up front, so item order and resume point follow page order
+	tasks := make(chan pageTask, len(pageURLs))
+	for i, pageURL := range pageURLs {
+		tasks <- pageTask{index: c.trackPage(pageURL), number: i + 2, url: pageURL}
+	}
+	close(tasks)
+
+	var errOnce sync.Once
+	var firstErr error
+	failPages := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	//Number of the first page without items, pages after it are not processed
+	var stopAt atomic.Int64
+	stopAt.Store(math.MaxInt64)
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < min(c.workers(), len(pageURLs)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				if fetchCtx.Err() != nil || c.sampleDone() || c.itemLimitReached() || int64(task.number) > stopAt.Load() {
+					return
+				}
+
+				//Each worker pauses between its requests
+				if c.PageDelay > 0 && !c.isCached(task.url) {
+					select {
+					case <-fetchCtx.Done():
+						return
+					case <-time.After(c.PageDelay):
+					}
+				}
+
+				c.metrics().CurrentPage.Store(int64(task.number))
+
+				pageHTML, itemElementList, err := c.loadPage(fetchCtx, task.url)
+				if err != nil {
+					if fetchCtx.Err() == nil {
+						failPages(err)
+					}
+					return
+				}
+				if len(itemElementList) == 0 {
+					c.stopOnBadPage(task.number-1, fmt.Errorf("ERROR::Failed to get items from %s", task.url))
+					for {
+						number := stopAt.Load()
+						if int64(task.number) >= number || stopAt.CompareAndSwap(number, int64(task.number)) {
+							break
+						}
+					}
+					return
+				}
+
+				//Fetched while an earlier page turned out to have no items
+				if int64(task.number) > stopAt.Load() {
+					return
+				}
+
+				err = c.handlePage(fetchCtx, task.index, task.url, pageHTML, itemElementList, storeName, nil, failures)
+				if err != nil {
+					if fetchCtx.Err() == nil {
+						failPages(err)
+					}
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return firstErr
+}
+
+// Function fetches page and returns its HTML node with item nodes found by the parser.
+// A page cut short before any item is fetched again, bypassing the cache
+func (c *Crawler) loadPage(ctx context.Context, pageURL string) (*html.Node, []*html.Node, error) {
+	var pageHTML *html.Node
+	var itemElementList []*html.Node
+
+	for attempt := 0; ; attempt++ {
+		bodyHTML, err := c.fetchPage(ctx, pageURL, attempt > 0)
+		var challengeErr *ChallengeError
+		if errors.As(err, &challengeErr) {
+			c.logger().Error("Blocked by eBay bot check, try again later, with a lower -rpm or with session -cookie", "url", pageURL, "marker", challengeErr.Marker)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		c.metrics().PagesFetched.Add(1)
+
+		//Build HTML node from HTML string
+		pageHTML, err = html.Parse(strings.NewReader(bodyHTML))
+		if err != nil {
+			return nil, nil, fmt.Errorf("ERROR::Can't parse HTML of %s: %w", pageURL, err)
+		}
+
+		//Get list of HTML elements with item data
+		itemElementList = c.itemParser().FindItems(pageHTML)
+		if len(itemElementList) > 0 || !isTruncatedHTML(bodyHTML) || attempt >= c.Retries {
+			break
+		}
+
+		c.logger().Warn("Page without items looks truncated, fetching it again", "url", pageURL, "attempt", attempt+1, "attempts", c.Retries+1)
+	}
+
+	return pageHTML, itemElementList, nil
+}
+
+// Share of the advertised result count below which collected items are reported as incomplete
+const minResultCountShare = 0.8
+
+// Function warns when far fewer items were found than the result header advertised, a sign of broken selectors or blocked pages
+func (c *Crawler) checkResultCount() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expected := c.stats.ResultCount
+	if expected == 0 {
+		return
+	}
+
+	//Stopped by the page limit, so only the crawled pages can be expected
+	if c.MaxPages > 0 && c.stats.Pages >= c.MaxPages && c.stats.Pages*c.firstPageItems < expected {
+		expected = c.stats.Pages * c.firstPageItems
+	}
+
+	if float64(c.stats.ItemsFound) < float64(expected)*minResultCountShare {
+		c.logger().Warn("Found far fewer items than eBay reports, selectors may be broken or pages blocked", "found", c.stats.ItemsFound, "reported", c.stats.ResultCount, "expected", expected)
+	}
+}
+
+// Function counts and reports the page when the share of its successfully parsed items is below MinSuccessRate,
+// usually a sign that eBay changed its markup
+func (c *Crawler) checkSuccessRate(pageIndex int, processed int, failed int) {
+	if c.MinSuccessRate <= 0 || processed == 0 {
+		return
+	}
+
+	rate := float64(processed-failed) / float64(processed)
+	if rate >= c.MinSuccessRate {
+		return
+	}
+
+	c.mu.Lock()
+	c.stats.LowSuccessPages++
+	pageURL := c.pageURLs[pageIndex]
+	c.mu.Unlock()
+
+	c.logger().Error("Share of parsed items is below the minimal success rate, page markup may have changed", "url", pageURL, "success_rate", fmt.Sprintf("%.2f", rate), "min_success_rate", c.MinSuccessRate, "processed", processed, "failed", failed)
+}
+
+// Function decides how to stop on a page without items: the first page fails the crawl,
+// later ones (e.g. a transient challenge page) stop it gracefully keeping items gathered so far
+func (c *Crawler) stopOnBadPage(pages int, err error) error {
+	if pages == 0 {
+		return err
+	}
+
+	c.logger().Warn("Stopping crawl on a page without items, keeping results of previous pages", "pages", pages, "err", err)
+
+	return nil
+}
+
+// Function to process item nodes of a page concurrently, counting failed items. Items from relatedFrom on are tagged
+// as related, items without category get the page one. Workers stop once ctx is done or PageProcessTimeout passes,
+// nothing is emitted after that. Returns whether all items of the page were processed
+func (c *Crawler) processPageItems(ctx context.Context, page pageJob, failures *atomic.Int64) bool {
+	pageCtx := ctx
+	if c.PageProcessTimeout > 0 {
+		var cancel context.CancelFunc
+		pageCtx, cancel = context.WithTimeout(ctx, c.PageProcessTimeout)
+		defer cancel()
+	}
+
+	//Set by workers which stopped before the page was processed
+	var abandoned atomic.Bool
+
+	pageErrors := new(itemErrors)
+	defer func() {
+		pageErrors.LogSummary(c.logger())
+
+		processed, failed := pageErrors.Counts()
+		failures.Add(int64(failed))
+		c.checkSuccessRate(page.index, processed, failed)
+	}()
+
+	//Feed item nodes to a bounded pool of workers, with their position on the page
+	type itemNode struct {
+		node     *html.Node
+		position int
+	}
+	itemNodes := make(chan itemNode, len(page.itemElementList))
+	for i, node := range page.itemElementList {
+		itemNodes <- itemNode{node: node, position: i}
+	}
+	close(itemNodes)
+
+	workers := c.workers()
+
+	wg := new(sync.WaitGroup)
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for next := range itemNodes {
+				//Stop picking up items once the crawl is cancelled or the page timed out
+				if pageCtx.Err() != nil {
+					abandoned.Store(true)
+					return
+				}
+				//Items over the limit would be dropped, don't fetch their detail pages
+				if c.itemLimitReached() {
+					continue
+				}
+
+				item, err := c.processItemNode(next.node, page.storeName, page.url)
+				if err == nil && item == nil {
+					c.mu.Lock()
+					c.stats.Filtered++
+					c.mu.Unlock()
+				}
+				if err == nil && item != nil {
+					item.page = page.index
+					item.position = next.position
+					item.Related = next.position >= page.relatedFrom
+					if item.Category == "" {
+						item.Category = page.category
+					}
+					var variations []ItemInfo
+					var enrichErr error
+					if c.enrichEnabled() {
+						variations, enrichErr = c.enrichItem(pageCtx, item)
+						c.countEnrichResult(pageCtx, enrichErr)
+					}
+
+					//Items of an abandoned page are dropped, so nothing is written after the crawl returns
+					if pageCtx.Err() != nil {
+						abandoned.Store(true)
+						return
+					}
+					//Transient failures are retried once the pages are crawled, the item is emitted then
+					retryLater := enrichErr != nil && c.RetryFailedItems && isRetryableError(enrichErr)
+					if retryLater {
+						c.logger().Warn("Can't enrich item, retrying at the end of the crawl", "item_id", item.ItemID, "err", enrichErr)
+					} else if enrichErr != nil {
+						c.logger().Warn("Can't enrich item, keeping listing data", "item_id", item.ItemID, "err", enrichErr)
+					}
+
+					switch {
+					case retryLater:
+						c.mu.Lock()
+						c.failedItems = append(c.failedItems, item)
+						c.mu.Unlock()
+					case len(variations) > 0:
+						err = c.emitVariations(variations)
+					default:
+						err = c.emit(item)
+					}
+					if errors.Is(err, ErrSkipItem) {
+						item, err = nil, nil
+					}
+				}
+				if err != nil {
+					c.metrics().ItemsFailed.Add(1)
+				} else if item != nil {
+					c.metrics().ItemsParsed.Add(1)
+				}
+				pageErrors.Add(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if !abandoned.Load() {
+		return true
+	}
+
+	if ctx.Err() == nil {
+		c.logger().Warn("Page processing timed out, abandoning page", "url", page.url, "timeout", c.PageProcessTimeout, "items", len(page.itemElementList))
+		c.mu.Lock()
+		c.stats.AbandonedPages++
+		c.mu.Unlock()
+	}
+
+	return false
+}
+
+// Function emits variations of a listing in place of the listing, variations outside the price filter are filtered
+// out. Returns the first error of the variations which failed
+func (c *Crawler) emitVariations(variations []ItemInfo) error {
+	var firstErr error
+
+	for i := range variations {
+		if c.PriceFilter != nil && !c.PriceFilter.Keep(&variations[i]) {
+			c.mu.Lock()
+			c.stats.Filtered++
+			c.mu.Unlock()
+			continue
+		}
+
+		err := c.emit(&variations[i])
+		if err != nil && !errors.Is(err, ErrSkipItem) && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Error returned by ItemHook to drop the item
+var ErrSkipItem = errors.New("item skipped by hook")
+
+// Function passes item to ItemHook and OnItem callbacks and collects it for the crawl result, items with DedupKey
+// of an item already emitted in this crawl are skipped. Returns ErrSkipItem when the hook dropped the item or MaxItems
+// items were emitted
+func (c *Crawler) emit(item *ItemInfo) error {
+	//Photo count is known only once the detail page is parsed, so the filter runs here rather than with the card filters
+	if c.PhotoFilter != nil && !c.PhotoFilter.Keep(item) {
+		c.mu.Lock()
+		c.stats.Filtered++
+		c.mu.Unlock()
+		return ErrSkipItem
+	}
+
+	key := item.ItemID
+	if c.DedupKey != nil {
+		key = c.DedupKey(item)
+	}
+
+	c.mu.Lock()
+	if c.seenIDs[key] {
+		c.stats.Duplicates++
+		c.mu.Unlock()
+		return nil
+	}
+	//The place is taken before callbacks run, so concurrent workers can't emit more than MaxItems
+	if c.MaxItems > 0 && c.emitted >= c.MaxItems {
+		c.mu.Unlock()
+		return ErrSkipItem
+	}
+	c.seenIDs[key] = true
+	c.emitted++
+	c.mu.Unlock()
+
+	item.Source = c.Source
+	item.SchemaVersion = SchemaVersion
+	item.CrawledAt = time.Now().UTC().Format(time.RFC3339)
+
+	if c.ItemHook != nil {
+		err := c.ItemHook(item)
+		if err != nil {
+			c.mu.Lock()
+			delete(c.seenIDs, key)
+			c.emitted--
+			if errors.Is(err, ErrSkipItem) {
+				c.stats.Filtered++
+			}
+			c.mu.Unlock()
+			return err
+		}
+	}
+
+	if c.OnItem != nil {
+		err := c.OnItem(item)
+		if err != nil {
+			c.mu.Lock()
+			delete(c.seenIDs, key)
+			c.emitted--
+			c.mu.Unlock()
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	c.items = append(c.items, *item)
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Function returns configured price classes or the defaults
+func (c *Crawler) priceClasses() []string {
+	return priceClassesOrDefault(c.PriceClasses)
+}
+
+// Function returns configured item parser or the classic layout one. Parsers of this package without a logger
+// get a copy with the crawler logger, so their records carry the attributes of the caller
+func (c *Crawler) itemParser() ItemParser {
+	switch parser := c.Parser.(type) {
+	case nil:
+		return &EbayClassicParser{Selectors: c.Selectors, PriceClasses: c.PriceClasses, ItemIDRegEx: c.ItemIDRegEx, Logger: c.Logger, Verbose: c.Verbose, DecimalSeparator: c.DecimalSeparator}
+	case *EbayClassicParser:
+		if parser.Logger == nil {
+			withLogger := *parser
+			withLogger.Logger = c.Logger
+			return &withLogger
+		}
+	case *EbayCardParser:
+		if parser.Logger == nil {
+			withLogger := *parser
+			withLogger.Logger = c.Logger
+			return &withLogger
+		}
+	}
+
+	return c.Parser
+}
+
+// Function returns configured number of workers or the default one
+func (c *Crawler) workers() int {
+	if c.Workers <= 0 {
+		return DefaultWorkers
+	}
+
+	return c.Workers
+}
+
+// Function returns configured item ID pattern or the default one
+func (c *Crawler) itemIDRegEx() *regexp.Regexp {
+	return patternOrDefault(c.ItemIDRegEx)
+}
+
+// Function returns configured logger or the default one
+func (c *Crawler) logger() *slog.Logger {
+	return loggerOrDefault(c.Logger)
+}
+
+// Function returns configured User-Agent or the default one
+func (c *Crawler) userAgent() string {
+	if c.UserAgent == "" {
+		return DefaultUserAgent
+	}
+
+	return c.UserAgent
+}
+
+// Function returns configured HTTP client or the default one
+func (c *Crawler) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+
+	return c.HTTPClient
+}
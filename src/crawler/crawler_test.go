@@ -0,0 +1,756 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Results page with the result count header and a classic item card per item ID, linking to the next page when
+// next isn't empty
+func fixtureResultsPage(resultCount int, itemIDs []string, next string) string {
+	page := strings.Builder{}
+	fmt.Fprintf(&page, `<html><body><h1 class="srp-controls__count-heading">%d results</h1><ul>`, resultCount)
+	for _, itemID := range itemIDs {
+		fmt.Fprintf(&page, `<li class="s-item" id="item%[1]s"><a class="s-item__link" href="https://www.ebay.com/itm/%[1]s">`+
+			`<div class="s-item__title"><span role="heading">Item %[1]s</span></div></a><span class="s-item__price">$10.00</span></li>`, itemID)
+	}
+	page.WriteString(`</ul>`)
+	if next != "" {
+		fmt.Fprintf(&page, `<a class="pagination__next icon-link" href="%s">next</a>`, next)
+	}
+	page.WriteString(`</body></html>`)
+
+	return page.String()
+}
+
+// Test server of search results with two items per page, pages are answered by the handler of their number
+type fixtureSearch struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	requested []int
+}
+
+func newFixtureSearch(t *testing.T, resultCount int, handle func(w http.ResponseWriter, page int) bool) *fixtureSearch {
+	search := &fixtureSearch{}
+	search.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get(pageParam))
+		page = max(page, 1)
+
+		search.mu.Lock()
+		search.requested = append(search.requested, page)
+		search.mu.Unlock()
+
+		if handle != nil && handle(w, page) {
+			return
+		}
+
+		next := ""
+		if page*2 < resultCount {
+			next = fmt.Sprintf("%s/sch/i.html?_nkw=laptop&%s=%d", search.server.URL, pageParam, page+1)
+		}
+		fmt.Fprint(w, fixtureResultsPage(resultCount, []string{strconv.Itoa(page*100 + 1), strconv.Itoa(page*100 + 2)}, next))
+	}))
+	t.Cleanup(search.server.Close)
+
+	return search
+}
+
+func (s *fixtureSearch) URL() string {
+	return s.server.URL + "/sch/i.html?_nkw=laptop"
+}
+
+func (s *fixtureSearch) Requested() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]int{}, s.requested...)
+}
+
+// Function returns item IDs of the items
+func itemIDs(items []ItemInfo) []string {
+	ids := []string{}
+	for _, item := range items {
+		ids = append(ids, item.ItemID)
+	}
+
+	return ids
+}
+
+func TestConcurrentPagesStopOnPageWithoutItems(t *testing.T) {
+	search := newFixtureSearch(t, 12, func(w http.ResponseWriter, page int) bool {
+		if page == 3 {
+			fmt.Fprint(w, fixtureResultsPage(12, nil, ""))
+			return true
+		}
+		return false
+	})
+
+	c := &Crawler{Logger: discardLogger, ConcurrentPages: true, Workers: 1}
+	items, err := c.Crawl(context.Background(), search.URL())
+	if err != nil {
+		t.Fatalf("crawl failed: %s", err)
+	}
+
+	//Like one by one, the pages before the one without items are kept and the ones after it aren't fetched
+	if got := strings.Join(itemIDs(items), ","); got != "101,102,201,202" {
+		t.Errorf("got items %s, want items of pages 1 and 2", got)
+	}
+	if got := fmt.Sprint(search.Requested()); got != "[1 2 3]" {
+		t.Errorf("requested pages %s, want [1 2 3]", got)
+	}
+}
+
+func TestConcurrentPagesFirstError(t *testing.T) {
+	search := newFixtureSearch(t, 12, func(w http.ResponseWriter, page int) bool {
+		if page == 3 {
+			w.WriteHeader(http.StatusNotFound)
+			return true
+		}
+		return false
+	})
+
+	c := &Crawler{Logger: discardLogger, ConcurrentPages: true, Workers: 1}
+	items, err := c.Crawl(context.Background(), search.URL())
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("got error %v, want the 404 of page 3", err)
+	}
+
+	//The failed page cancels the pages after it
+	if got := fmt.Sprint(search.Requested()); got != "[1 2 3]" {
+		t.Errorf("requested pages %s, want [1 2 3]", got)
+	}
+	if len(items) != 4 {
+		t.Errorf("got items %v, want the items of pages 1 and 2", itemIDs(items))
+	}
+}
+
+func TestConcurrentPagesAllPages(t *testing.T) {
+	search := newFixtureSearch(t, 12, nil)
+
+	c := &Crawler{Logger: discardLogger, ConcurrentPages: true, Workers: 4}
+	items, err := c.Crawl(context.Background(), search.URL())
+	if err != nil {
+		t.Fatalf("crawl failed: %s", err)
+	}
+
+	//Items keep page order whatever order the pages arrive in
+	if got := strings.Join(itemIDs(items), ","); got != "101,102,201,202,301,302,401,402,501,502,601,602" {
+		t.Errorf("got items %s, want two items of each of 6 pages in page order", got)
+	}
+	if stats := c.Stats(); stats.Pages != 6 {
+		t.Errorf("crawled %d pages, want 6", stats.Pages)
+	}
+}
+
+func TestCrawlPage(t *testing.T) {
+	search := newFixtureSearch(t, 6, nil)
+
+	c := &Crawler{Logger: discardLogger}
+	page, err := c.CrawlPage(context.Background(), search.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Only the page itself is fetched, the next one is reported
+	if got := fmt.Sprint(search.Requested()); got != "[1]" {
+		t.Errorf("requested pages %s, want [1]", got)
+	}
+	if got := strings.Join(itemIDs(page.Items), ","); got != "101,102" {
+		t.Errorf("got items %s, want 101,102", got)
+	}
+	if want := search.server.URL + "/sch/i.html?_nkw=laptop&_pgn=2"; page.NextURL != want {
+		t.Errorf("next URL = %q, want %q", page.NextURL, want)
+	}
+	if page.ResultCount != 6 || c.Stats().Pages != 1 {
+		t.Errorf("result count %d, pages %d, want 6 results on 1 page", page.ResultCount, c.Stats().Pages)
+	}
+
+	//The last page has no next URL
+	page, err = c.CrawlPage(context.Background(), page.NextURL[:len(page.NextURL)-1]+"3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.NextURL != "" || len(page.Items) != 2 {
+		t.Errorf("last page has next URL %q and %d items, want none and 2", page.NextURL, len(page.Items))
+	}
+}
+
+func TestCrawlPageWithoutItems(t *testing.T) {
+	search := newFixtureSearch(t, 0, func(w http.ResponseWriter, page int) bool {
+		fmt.Fprint(w, fixtureResultsPage(0, nil, ""))
+		return true
+	})
+
+	page, err := (&Crawler{Logger: discardLogger}).CrawlPage(context.Background(), search.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !page.NoResults || len(page.Items) != 0 {
+		t.Errorf("got page %+v, want empty search result", page)
+	}
+}
+
+func TestCrawlPageMatchesCrawl(t *testing.T) {
+	search := newFixtureSearch(t, 6, nil)
+
+	tests := []struct {
+		name    string
+		crawler func() *Crawler
+	}{
+		{"sequential parsing", func() *Crawler { return &Crawler{Logger: discardLogger} }},
+		{"parallel parsing", func() *Crawler { return &Crawler{Logger: discardLogger, ParallelParse: 2} }},
+		{"sample", func() *Crawler { return &Crawler{Logger: discardLogger, Sample: 1} }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			//The page of CrawlPage and the single page of Crawl go through the same steps
+			pageCrawler := test.crawler()
+			page, err := pageCrawler.CrawlPage(context.Background(), search.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := test.crawler()
+			c.MaxPages = 1
+			items, err := c.Crawl(context.Background(), search.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := strings.Join(itemIDs(page.Items), ","), strings.Join(itemIDs(items), ","); got != want {
+				t.Errorf("page items %s, crawl items %s", got, want)
+			}
+			if pageCrawler.Stats() != c.Stats() {
+				t.Errorf("page stats %+v, crawl stats %+v", pageCrawler.Stats(), c.Stats())
+			}
+		})
+	}
+}
+
+func TestCrawlFollowsNextPage(t *testing.T) {
+	search := newFixtureSearch(t, 4, nil)
+
+	c := &Crawler{Logger: discardLogger, Workers: 1}
+	items, err := c.Crawl(context.Background(), search.URL())
+	if err != nil {
+		t.Fatalf("crawl failed: %s", err)
+	}
+
+	//Page 1 links to page 2, which has no next link, so the crawl stops there
+	if got := fmt.Sprint(search.Requested()); got != "[1 2]" {
+		t.Errorf("requested pages %s, want [1 2]", got)
+	}
+	if got := strings.Join(itemIDs(items), ","); got != "101,102,201,202" {
+		t.Errorf("got items %s, want 101,102,201,202", got)
+	}
+	for _, item := range items {
+		if item.Title != "Item "+item.ItemID || item.Price != "10.00" || item.ProductURL != "https://www.ebay.com/itm/"+item.ItemID {
+			t.Errorf("got item %+v, want title, price and URL of the card", item)
+		}
+	}
+	if stats := c.Stats(); stats.Pages != 2 || stats.ItemsFound != 4 {
+		t.Errorf("crawled %d pages and %d items, want 2 and 4", stats.Pages, stats.ItemsFound)
+	}
+}
+
+func TestItemSourceURLAndCrawledAt(t *testing.T) {
+	search := newFixtureSearch(t, 4, nil)
+
+	start := time.Now().UTC().Truncate(time.Second)
+	c := &Crawler{Logger: discardLogger, Workers: 1}
+	items, err := c.Crawl(context.Background(), search.URL())
+	if err != nil {
+		t.Fatalf("crawl failed: %s", err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("got %d items, want 4", len(items))
+	}
+
+	//Items carry the results page they were found on, not the start URL
+	pageURLs := map[string]string{"1": search.URL(), "2": search.server.URL + "/sch/i.html?_nkw=laptop&" + pageParam + "=2"}
+	for _, item := range items {
+		if want := pageURLs[item.ItemID[:1]]; item.SourceURL != want {
+			t.Errorf("item %s source URL = %q, want %q", item.ItemID, item.SourceURL, want)
+		}
+
+		crawledAt, err := time.Parse(time.RFC3339, item.CrawledAt)
+		if err != nil || crawledAt.Before(start) || crawledAt.After(time.Now()) {
+			t.Errorf("item %s crawled at %q (%v), want RFC 3339 time of the crawl", item.ItemID, item.CrawledAt, err)
+		}
+	}
+
+	data, err := json.Marshal(items[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"source_url":`)) || !bytes.Contains(data, []byte(`"crawled_at":`)) {
+		t.Errorf("JSON %s lacks source_url or crawled_at", data)
+	}
+}
+
+func TestCrawlFollowsRelativeNextPage(t *testing.T) {
+	search := newFixtureSearch(t, 4, func(w http.ResponseWriter, page int) bool {
+		if page != 1 {
+			return false
+		}
+		fmt.Fprint(w, fixtureResultsPage(4, []string{"101", "102"}, "/sch/i.html?_nkw=laptop&"+pageParam+"=2"))
+		return true
+	})
+
+	c := &Crawler{Logger: discardLogger, Workers: 1}
+	items, err := c.Crawl(context.Background(), search.URL())
+	if err != nil {
+		t.Fatalf("crawl failed: %s", err)
+	}
+
+	//Relative next link is resolved against the page URL instead of ending the crawl
+	if got := fmt.Sprint(search.Requested()); got != "[1 2]" {
+		t.Errorf("requested pages %s, want [1 2]", got)
+	}
+	if got := strings.Join(itemIDs(items), ","); got != "101,102,201,202" {
+		t.Errorf("got items %s, want 101,102,201,202", got)
+	}
+}
+
+func TestItemHook(t *testing.T) {
+	search := newFixtureSearch(t, 3, func(w http.ResponseWriter, page int) bool {
+		fmt.Fprint(w, fixtureResultsPage(3, []string{"101", "102", "103"}, ""))
+		return true
+	})
+
+	var written []string
+	c := &Crawler{
+		Logger:  discardLogger,
+		Workers: 1,
+		ItemHook: func(item *ItemInfo) error {
+			switch item.ItemID {
+			case "102":
+				return ErrSkipItem
+			case "103":
+				return errors.New("hook failed")
+			}
+			item.Title = strings.ToUpper(item.Title)
+			return nil
+		},
+		OnItem: func(item *ItemInfo) error {
+			written = append(written, item.ItemID+" "+item.Title)
+			return nil
+		},
+	}
+	items, err := c.Crawl(context.Background(), search.URL())
+	if err != nil {
+		t.Fatalf("crawl failed: %s", err)
+	}
+
+	//The changed item reaches OnItem and the result, the skipped one is filtered out and the failed one counted
+	if got := strings.Join(written, ","); got != "101 ITEM 101" {
+		t.Errorf("written items %q, want 101 ITEM 101", got)
+	}
+	if len(items) != 1 || items[0].Title != "ITEM 101" {
+		t.Errorf("got items %v, want item 101 with changed title", items)
+	}
+	if stats := c.Stats(); stats.Filtered != 1 || stats.Failures != 1 {
+		t.Errorf("got %d filtered and %d failed items, want 1 and 1", stats.Filtered, stats.Failures)
+	}
+}
+
+func TestItemHookURLsOnly(t *testing.T) {
+	search := newFixtureSearch(t, 3, func(w http.ResponseWriter, page int) bool {
+		fmt.Fprint(w, fixtureResultsPage(3, []string{"101", "102", "103"}, ""))
+		return true
+	})
+
+	c := &Crawler{
+		Logger:   discardLogger,
+		URLsOnly: true,
+		ItemHook: func(item *ItemInfo) error {
+			switch item.ItemID {
+			case "102":
+				return ErrSkipItem
+			case "103":
+				return errors.New("hook failed")
+			}
+			return nil
+		},
+	}
+	items, err := c.Crawl(context.Background(), search.URL())
+	if err != nil {
+		t.Fatalf("crawl failed: %s", err)
+	}
+
+	//URL items get their item ID, so the hook can filter them like parsed items
+	if len(items) != 1 || items[0].ProductURL != "https://www.ebay.com/itm/101" {
+		t.Errorf("got items %v, want URL of item 101", items)
+	}
+	if stats := c.Stats(); stats.Filtered != 1 || stats.Failures != 1 {
+		t.Errorf("got %d filtered and %d failed items, want 1 and 1", stats.Filtered, stats.Failures)
+	}
+}
+
+func TestCrawlEmptyNextHref(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		//Debug note of the empty href, a missing attribute is logged as a failure instead
+		emptyNote bool
+	}{
+		{"empty href", `<a class="pagination__next icon-link" href="">next</a>`, true},
+		{"blank href", `<a class="pagination__next icon-link" href="  ">next</a>`, true},
+		{"missing href", `<a class="pagination__next icon-link">next</a>`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			search := newFixtureSearch(t, 0, func(w http.ResponseWriter, page int) bool {
+				fmt.Fprint(w, strings.Replace(fixtureResultsPage(4, []string{"101", "102"}, ""), "</body>", test.link+"</body>", 1))
+				return true
+			})
+
+			logs := bytes.Buffer{}
+			c := &Crawler{Logger: slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})), Workers: 1}
+			items, err := c.Crawl(context.Background(), search.URL())
+			if err != nil {
+				t.Fatalf("got %s, want crawl to end after the first page", err)
+			}
+
+			if got := fmt.Sprint(search.Requested()); got != "[1]" {
+				t.Errorf("requested pages %s, want [1]", got)
+			}
+			if got := strings.Join(itemIDs(items), ","); got != "101,102" {
+				t.Errorf("got items %s, want 101,102", got)
+			}
+			if got := strings.Contains(logs.String(), "Next page link has empty href"); got != test.emptyNote {
+				t.Errorf("logged empty href note %t, want %t:\n%s", got, test.emptyNote, logs.String())
+			}
+		})
+	}
+}
+
+// Parser taking delay to parse each card of the first page
+type slowParser struct {
+	EbayClassicParser
+	delay time.Duration
+}
+
+func (p *slowParser) ParseItem(node *html.Node) (ItemInfo, error) {
+	item, err := p.EbayClassicParser.ParseItem(node)
+	if strings.HasPrefix(item.ItemID, "1") {
+		time.Sleep(p.delay)
+	}
+
+	return item, err
+}
+
+func TestPageProcessTimeout(t *testing.T) {
+	search := newFixtureSearch(t, 6, nil)
+
+	logs := bytes.Buffer{}
+	c := &Crawler{
+		Logger:             slog.New(slog.NewTextHandler(&logs, nil)),
+		Parser:             &slowParser{delay: 200 * time.Millisecond},
+		Workers:            1,
+		PageProcessTimeout: 50 * time.Millisecond,
+	}
+	items, err := c.Crawl(context.Background(), search.URL())
+	if err != nil {
+		t.Fatalf("got %s, want the crawl to go on after the slow page", err)
+	}
+
+	//Parsing of the first page runs over the limit, its items are dropped and the next pages are crawled
+	if got := fmt.Sprint(search.Requested()); got != "[1 2 3]" {
+		t.Errorf("requested pages %s, want [1 2 3]", got)
+	}
+	if got := strings.Join(itemIDs(items), ","); got != "201,202,301,302" {
+		t.Errorf("got items %s, want 201,202,301,302", got)
+	}
+	if abandoned := c.Stats().AbandonedPages; abandoned != 1 {
+		t.Errorf("got %d abandoned pages, want 1", abandoned)
+	}
+	if !strings.Contains(logs.String(), "level=WARN msg=\"Page processing timed out, abandoning page\"") {
+		t.Errorf("abandoned page wasn't logged:\n%s", logs.String())
+	}
+}
+
+func TestMinItemsPerPage(t *testing.T) {
+	//The middle page is short and the last one too, only the middle one is suspicious
+	search := newFixtureSearch(t, 0, func(w http.ResponseWriter, page int) bool {
+		switch page {
+		case 1:
+			fmt.Fprint(w, fixtureResultsPage(0, []string{"101", "102"}, fmt.Sprintf("/sch/i.html?_nkw=laptop&%s=2", pageParam)))
+		case 2:
+			fmt.Fprint(w, fixtureResultsPage(0, []string{"201"}, fmt.Sprintf("/sch/i.html?_nkw=laptop&%s=3", pageParam)))
+		default:
+			fmt.Fprint(w, fixtureResultsPage(0, []string{"301"}, ""))
+		}
+		return true
+	})
+
+	tests := []struct {
+		name     string
+		minItems int
+		warnings int
+	}{
+		{"short middle page", 2, 1},
+		{"disabled", 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logs := bytes.Buffer{}
+			c := &Crawler{Logger: slog.New(slog.NewJSONHandler(&logs, nil)), Workers: 1, MinItemsPerPage: test.minItems}
+			items, err := c.Crawl(context.Background(), search.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			//The warning doesn't stop the crawl
+			if got := strings.Join(itemIDs(items), ","); got != "101,102,201,301" {
+				t.Errorf("got items %s, want 101,102,201,301", got)
+			}
+
+			warnings := []map[string]any{}
+			for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+				record := map[string]any{}
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatal(err)
+				}
+				if record["msg"] == "Page has too few items, results may be incomplete" {
+					warnings = append(warnings, record)
+				}
+			}
+			if len(warnings) != test.warnings {
+				t.Fatalf("got warnings %v, want %d", warnings, test.warnings)
+			}
+			if test.warnings > 0 {
+				if url, _ := warnings[0]["url"].(string); !strings.HasSuffix(url, pageParam+"=2") || warnings[0]["items"] != 1.0 || warnings[0]["expected"] != 2.0 {
+					t.Errorf("got warning %v, want page 2 with 1 of 2 items", warnings[0])
+				}
+			}
+		})
+	}
+}
+
+func TestCrawlNoResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     string
+		wantErr  bool
+		noResult bool
+	}{
+		{"zero results header", fixtureResultsPage(0, nil, ""), false, true},
+		{"no exact matches", `<html><body><h3 class="srp-save-null-search__heading">No exact matches found</h3></body></html>`, false, true},
+		{"no matches message", `<html><body><p>No exact matches found. Try fewer words.</p></body></html>`, false, true},
+		{"blocked page", `<html><body><h1>Pardon Our Interruption...</h1></body></html>`, true, false},
+		{"changed markup", fixtureResultsPage(120, nil, ""), true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			search := newFixtureSearch(t, 0, func(w http.ResponseWriter, page int) bool {
+				fmt.Fprint(w, test.page)
+				return true
+			})
+
+			c := &Crawler{Logger: discardLogger}
+			items, err := c.Crawl(context.Background(), search.URL())
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %t", err, test.wantErr)
+			}
+			if len(items) != 0 || c.Stats().NoResults != test.noResult {
+				t.Errorf("got %d items and NoResults %t, want none and %t", len(items), c.Stats().NoResults, test.noResult)
+			}
+		})
+	}
+}
+
+func TestCrawlRefusesOffSiteNextLink(t *testing.T) {
+	//The other site is the same test server under another host name
+	offSite := newFixtureSearch(t, 0, func(w http.ResponseWriter, page int) bool {
+		fmt.Fprint(w, fixtureResultsPage(4, []string{"901", "902"}, ""))
+		return true
+	})
+	offSiteURL := strings.Replace(offSite.URL(), "127.0.0.1", "localhost", 1)
+
+	search := newFixtureSearch(t, 4, func(w http.ResponseWriter, page int) bool {
+		fmt.Fprint(w, fixtureResultsPage(4, []string{"101", "102"}, offSiteURL+"&_pgn=2"))
+		return true
+	})
+
+	tests := []struct {
+		name         string
+		allowedHosts []string
+		want         string
+		offSiteHits  int
+	}{
+		{"refused", nil, "101,102", 0},
+		{"allowed host", []string{"localhost"}, "101,102,901,902", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := len(offSite.Requested())
+
+			c := &Crawler{Logger: discardLogger, AllowedHosts: test.allowedHosts}
+			items, err := c.Crawl(context.Background(), search.URL())
+			if err != nil {
+				t.Fatalf("crawl failed: %s", err)
+			}
+
+			if got := strings.Join(itemIDs(items), ","); got != test.want {
+				t.Errorf("got items %s, want %s", got, test.want)
+			}
+			if hits := len(offSite.Requested()) - before; hits != test.offSiteHits {
+				t.Errorf("off-site server got %d requests, want %d", hits, test.offSiteHits)
+			}
+		})
+	}
+}
+
+func TestParallelParse(t *testing.T) {
+	//Page 3 repeats an item of page 2, as pagination shifting during a crawl does
+	search := newFixtureSearch(t, 12, func(w http.ResponseWriter, page int) bool {
+		if page == 3 {
+			fmt.Fprint(w, fixtureResultsPage(12, []string{"202", "301"}, "/sch/i.html?_nkw=laptop&_pgn=4"))
+			return true
+		}
+		return false
+	})
+
+	want := "101,102,201,202,301,401,402,501,502,601,602"
+	for _, parsers := range []int{0, 1, 4} {
+		c := &Crawler{Logger: discardLogger, ParallelParse: parsers, Workers: 2}
+		items, err := c.Crawl(context.Background(), search.URL())
+		if err != nil {
+			t.Fatalf("crawl with %d parsers failed: %s", parsers, err)
+		}
+
+		if got := strings.Join(itemIDs(items), ","); got != want {
+			t.Errorf("%d parsers got items %s, want %s", parsers, got, want)
+		}
+		if stats := c.Stats(); stats.Pages != 6 || stats.Duplicates != 1 {
+			t.Errorf("%d parsers crawled %d pages with %d duplicates, want 6 and 1", parsers, stats.Pages, stats.Duplicates)
+		}
+	}
+}
+
+// Test server of 8 pages of 240 cards, each answered after the latency of a real page fetch
+func newBenchmarkSearch(b *testing.B) *httptest.Server {
+	page := benchmarkResultsPage()
+	const next = "https://www.ebay.com/sch/i.html?_nkw=laptop&_pgn=2"
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+
+		pageNumber, _ := strconv.Atoi(r.URL.Query().Get(pageParam))
+		pageNumber = max(pageNumber, 1)
+		nextURL := ""
+		if pageNumber < 8 {
+			nextURL = fmt.Sprintf("%s/sch/i.html?_nkw=laptop&%s=%d", server.URL, pageParam, pageNumber+1)
+		}
+		//Item IDs of each page differ, so no page is dropped as duplicates
+		body := strings.ReplaceAll(page, "/itm/", fmt.Sprintf("/itm/%d0", pageNumber))
+		fmt.Fprint(w, strings.Replace(body, next, nextURL, 1))
+	}))
+	b.Cleanup(server.Close)
+
+	return server
+}
+
+func BenchmarkParallelParse(b *testing.B) {
+	server := newBenchmarkSearch(b)
+
+	for _, parsers := range []int{0, 2} {
+		b.Run(fmt.Sprintf("parsers=%d", parsers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				c := &Crawler{Logger: discardLogger, ParallelParse: parsers, Workers: 1}
+				items, err := c.Crawl(context.Background(), server.URL+"/sch/i.html?_nkw=laptop")
+				if err != nil || len(items) != 8*240 {
+					b.Fatalf("got %d items (%v), want %d", len(items), err, 8*240)
+				}
+			}
+		})
+	}
+}
+
+func TestCrawlerLoggerAttributes(t *testing.T) {
+	//Card layout page with cards lacking the subtitle, which the verbose card parser logs as selector misses
+	cardSearch := newFixtureSearch(t, 4, func(w http.ResponseWriter, page int) bool {
+		fmt.Fprint(w, `<html><body><ul>`)
+		for _, itemID := range []string{"301", "302"} {
+			fmt.Fprintf(w, `<li class="s-card" id="item%[1]s"><a class="su-link" href="https://www.ebay.com/itm/%[1]s?hash=item%[1]s">`+
+				`<div class="s-card__title"><span class="su-styled-text">Item %[1]s</span></div></a><span class="s-card__price">$10.00</span></li>`, itemID)
+		}
+		fmt.Fprint(w, `</ul></body></html>`)
+		return true
+	})
+
+	tests := []struct {
+		name   string
+		parser ItemParser
+		url    string
+	}{
+		{"default classic parser", nil, newFixtureSearch(t, 4, nil).URL()},
+		{"card parser without logger", &EbayCardParser{Verbose: true}, cardSearch.URL()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})).With("crawl_id", "c-42")
+
+			if _, err := (&Crawler{Logger: logger, Verbose: true, Parser: test.parser}).Crawl(context.Background(), test.url); err != nil {
+				t.Fatal(err)
+			}
+
+			//Records of the crawler and of its item parser all carry the attribute of the caller
+			messages := map[string]bool{}
+			for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+				record := map[string]any{}
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatal(err)
+				}
+				if record["crawl_id"] != "c-42" {
+					t.Errorf("record %s has no crawl_id", line)
+				}
+				messages[record["msg"].(string)] = true
+			}
+			if !messages["Found items"] || !messages["Selector miss"] {
+				t.Errorf("got messages %v, want records of the crawler and the parser", messages)
+			}
+		})
+	}
+}
+
+func TestCrawlerDefaultLogger(t *testing.T) {
+	search := newFixtureSearch(t, 4, nil)
+
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(defaultLogger)
+
+	//Without a logger records of the crawler and of its parser go to the default one
+	if _, err := (&Crawler{Verbose: true}).Crawl(context.Background(), search.URL()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(logs.String(), "msg=\"Found items\"") || !strings.Contains(logs.String(), "msg=\"Selector miss\"") {
+		t.Errorf("default logger got %q, want records of the crawler and the parser", logs.String())
+	}
+}
@@ -0,0 +1,323 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Function fetches item detail page and fills fields missing from the listing card: item specifics,
+// exact quantity available and description. With FollowVariations returns an item per variation of the listing, nil when
+// it has none. Returns the error of the detail page fetch, the item keeps its listing data then
+func (c *Crawler) enrichItem(ctx context.Context, item *ItemInfo) ([]ItemInfo, error) {
+	//Affiliate parameters are not sent with crawler requests
+	itemURL := item.ProductURL
+	if item.RawURL != "" {
+		itemURL = item.RawURL
+	}
+
+	pageNode, err := c.fetchDetailNode(ctx, itemURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c.parseDetailPage(ctx, item, pageNode, itemURL)
+
+	if c.FollowVariations {
+		return parseVariations(pageNode, item), nil
+	}
+
+	return nil, nil
+}
+
+// Function fills item fields of the fetched detail page: item specifics, recent sales, gallery, categories,
+// identifiers, quantity available, fast shipping and description
+func (c *Crawler) parseDetailPage(ctx context.Context, item *ItemInfo, pageNode *html.Node, itemURL string) {
+	item.ItemSpecifics = parseItemSpecifics(pageNode)
+	item.RecentSales = parseRecentSales(pageNode)
+	item.Images = parseGalleryImages(pageNode)
+	item.PhotoCount = len(item.Images)
+	item.Categories = parseCategories(pageNode)
+	parseIdentifiers(item, c.logger())
+
+	quantityNode := findFirstElementByAnyAttr(pageNode, "div", "class", []string{"x-quantity__availability", "qtyAvailability"})
+	if quantityNode != nil {
+		quantity := parseQuantityAvailable(getNodeText(quantityNode))
+		if quantity > 0 {
+			item.QuantityAvailable = quantity
+		}
+	}
+
+	//Cards of some layouts don't show the perk, the shipping section of the detail page does
+	shippingNode := findFirstElementByAnyClass(pageNode, "div", []string{"d-shipping-minview", "ux-labels-values--shipping"})
+	if shippingNode != nil && hasFastShippingMarker(getNodeText(shippingNode)) {
+		item.FastShipping = true
+	}
+
+	c.enrichDescription(ctx, item, pageNode, itemURL)
+}
+
+// Function fetches item description the detail page embeds, keeping the item without it on failure
+func (c *Crawler) enrichDescription(ctx context.Context, item *ItemInfo, pageNode *html.Node, itemURL string) {
+	//Description is served as a separate document embedded by iframe
+	descriptionFrame := findFirstElementByAttr(pageNode, "iframe", "id", "desc_ifr")
+	if descriptionFrame == nil {
+		return
+	}
+
+	src, err := getElementAttrByName(descriptionFrame, "src")
+	if err == nil {
+		src, err = resolveURL(itemURL, src)
+	}
+	if err != nil {
+		c.logger().Debug("Item description link not found", "item_id", item.ItemID)
+		return
+	}
+
+	descriptionNode, err := c.fetchDetailNode(ctx, src)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.logger().Warn("Can't get item description, keeping listing data", "item_id", item.ItemID, "err", err)
+		}
+		return
+	}
+	item.Description = getNodeText(descriptionNode)
+}
+
+// Error of items which are not enriched because MaxEnrichFailures was exceeded
+var errEnrichStopped = errors.New("ERROR::Enrichment stopped after too many consecutive detail page failures")
+
+// Function checks if detail pages are fetched: Enrich is set and MaxEnrichFailures wasn't exceeded
+func (c *Crawler) enrichEnabled() bool {
+	if !c.Enrich {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return !c.enrichStopped
+}
+
+// Function counts consecutive detail page failures, stopping enrichment when there are more than MaxEnrichFailures.
+// A burst of failures means detail pages are blocked or changed, so the remaining items keep their listing data
+// instead of spending requests on pages which fail too
+func (c *Crawler) countEnrichResult(ctx context.Context, err error) {
+	//Cancelled fetches don't tell anything about detail pages
+	if ctx.Err() != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		c.enrichFailures = 0
+		return
+	}
+
+	c.enrichFailures++
+	if c.MaxEnrichFailures > 0 && c.enrichFailures > c.MaxEnrichFailures && !c.enrichStopped {
+		c.enrichStopped = true
+		c.logger().Warn("Too many consecutive enrichment failures, keeping listing data of the remaining items", "failures", c.enrichFailures, "max_enrich_failures", c.MaxEnrichFailures)
+	}
+}
+
+// Function enriches again items whose detail page failed with a transient error, after a backoff, and emits them.
+// Items which fail again, or all of them when the crawl is cancelled, are emitted with their listing data
+func (c *Crawler) retryFailedItems(ctx context.Context, failures *atomic.Int64) {
+	c.mu.Lock()
+	items := c.failedItems
+	c.failedItems = nil
+	c.mu.Unlock()
+
+	if len(items) == 0 {
+		return
+	}
+
+	c.logger().Info("Retrying items whose detail page failed", "items", len(items))
+	select {
+	case <-ctx.Done():
+	case <-time.After(withJitter(initialRetryDelay)):
+	}
+
+	recovered := 0
+	for _, item := range items {
+		var variations []ItemInfo
+		err := ctx.Err()
+		if err == nil && !c.enrichEnabled() {
+			err = errEnrichStopped
+		}
+		if err == nil {
+			variations, err = c.enrichItem(ctx, item)
+			c.countEnrichResult(ctx, err)
+		}
+		if err == nil {
+			recovered++
+		} else if ctx.Err() == nil && !errors.Is(err, errEnrichStopped) {
+			c.logger().Warn("Can't enrich item, keeping listing data", "item_id", item.ItemID, "err", err)
+		}
+
+		if len(variations) > 0 {
+			err = c.emitVariations(variations)
+		} else {
+			err = c.emit(item)
+		}
+		if err != nil && !errors.Is(err, ErrSkipItem) {
+			c.logger().Error("Failed to emit item", "err", err, "item_id", item.ItemID)
+			c.metrics().ItemsFailed.Add(1)
+			failures.Add(1)
+		}
+	}
+
+	c.logger().Info("Retried items whose detail page failed", "items", len(items), "recovered", recovered)
+}
+
+// Function fetches detail page after the page delay, sharing cache, retries and rate limit with listing pages
+func (c *Crawler) fetchDetailNode(ctx context.Context, url string) (*html.Node, error) {
+	if c.PageDelay > 0 && !c.isCached(url) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.PageDelay):
+		}
+	}
+
+	body, err := c.fetchPage(ctx, url, false)
+	if err != nil {
+		return nil, err
+	}
+
+	pageNode, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't parse HTML of %s: %w", url, err)
+	}
+
+	return pageNode, nil
+}
+
+// Function to get item specifics (label -> value) of detail page, nil when the page has none. Labels are kept without
+// the trailing colon, labels and values with single spaces; a repeated label keeps its first value, ignoring case
+func parseItemSpecifics(pageNode *html.Node) map[string]string {
+	var specifics map[string]string
+	seen := map[string]bool{}
+
+	for _, labelNode := range findAllElementsByAttr(pageNode, "div", "class", "ux-labels-values__labels", []*html.Node{}) {
+		valueNode := labelNode.NextSibling
+		for valueNode != nil && valueNode.Type != html.ElementNode {
+			valueNode = valueNode.NextSibling
+		}
+		if valueNode == nil {
+			continue
+		}
+
+		class, _ := getElementAttrByName(valueNode, "class")
+		if !strings.Contains(class, "ux-labels-values__values") {
+			continue
+		}
+
+		label := strings.TrimSpace(strings.TrimSuffix(strings.Join(strings.Fields(getNodeText(labelNode)), " "), ":"))
+		value := strings.Join(strings.Fields(getNodeText(valueNode)), " ")
+		if label == "" || value == "" || seen[strings.ToLower(label)] {
+			continue
+		}
+		seen[strings.ToLower(label)] = true
+
+		if specifics == nil {
+			specifics = map[string]string{}
+		}
+		specifics[label] = value
+	}
+
+	return specifics
+}
+
+// Sales velocity message of detail pages: "12 sold in the last 24 hours", "More than 10 sold in past hour",
+// "3 people bought this in the last 7 days"
+var recentSalesRegEx = regexp.MustCompile(`(?i)(\d[\d,]*)\+?\s+(?:people\s+)?(?:have\s+)?(?:sold|bought|purchased)(?:\s+this)?\s+in\s+(?:the\s+)?(?:last|past)\s+(?:(\d+)\s+)?(minute|hour|day|week|month)s?\b`)
+
+// Function to get sales of the last period from the detail page message, nil when the page shows none
+func parseRecentSales(pageNode *html.Node) *RecentSales {
+	matches := recentSalesRegEx.FindStringSubmatch(visibleText(pageNode))
+	if matches == nil {
+		return nil
+	}
+
+	count, err := strconv.Atoi(strings.ReplaceAll(matches[1], ",", ""))
+	if err != nil || count == 0 {
+		return nil
+	}
+
+	//"last hour" is a period of 1 hour
+	length := matches[2]
+	if length == "" {
+		length = "1"
+	}
+	unit := strings.ToLower(matches[3])
+	if length != "1" {
+		unit += "s"
+	}
+
+	return &RecentSales{Count: count, Period: length + " " + unit}
+}
+
+// Function to get text of the page as displayed, without scripts and styles, text nodes joined with spaces
+func visibleText(node *html.Node) string {
+	parts := []string{}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+			return
+		}
+		if n.Type == html.TextNode {
+			text := strings.TrimSpace(n.Data)
+			if text != "" {
+				parts = append(parts, text)
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	return strings.Join(parts, " ")
+}
+
+// Function to get categories of the detail page breadcrumb, ordered root to leaf, without the eBay home link and
+// "Back to search results". Nil when the page has no breadcrumb
+func parseCategories(pageNode *html.Node) []string {
+	breadcrumbNode := findFirstElementByAttr(pageNode, "nav", "class", "breadcrumb")
+	if breadcrumbNode == nil {
+		return nil
+	}
+
+	var categories []string
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "li" {
+			category := strings.TrimSpace(strings.Trim(getNodeText(n), ">›"))
+			if category != "" && !strings.EqualFold(category, "eBay") && !strings.HasPrefix(strings.ToLower(category), "back to") {
+				categories = append(categories, category)
+			}
+			return
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(breadcrumbNode)
+
+	return categories
+}
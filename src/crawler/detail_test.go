@@ -0,0 +1,332 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// Detail page with a single item specific
+const specificsDetailPage = `<html><body><div class="ux-labels-values__labels">Brand:</div><div class="ux-labels-values__values">Dell</div></body></html>`
+
+// Function starts a listing of one item whose detail page fails with 503 the first failures times
+func newFlakyDetailServer(t *testing.T, failures int64) *httptest.Server {
+	t.Helper()
+
+	var detailRequests atomic.Int64
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/itm/") {
+			if detailRequests.Add(1) <= failures {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, specificsDetailPage)
+			return
+		}
+		fmt.Fprint(w, `<html><body><ul><li class="s-item" id="item1"><a class="s-item__link" href="`+server.URL+`/itm/555">`+
+			`<div class="s-item__title"><span role="heading">Dell Laptop</span></div></a><span class="s-item__price">$10.00</span></li></ul></body></html>`)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestRetryFailedItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		retry    bool
+		failures int64
+		brand    string
+	}{
+		{"recovered", true, 1, "Dell"},
+		{"failed again", true, 2, ""},
+		{"without retry", false, 1, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newFlakyDetailServer(t, test.failures)
+
+			c := &Crawler{Logger: discardLogger, Enrich: true, RetryFailedItems: test.retry}
+			items, err := c.Crawl(context.Background(), server.URL+"/sch/i.html")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			//The item is written either way, with listing data when its detail page can't be fetched
+			if len(items) != 1 {
+				t.Fatalf("got %d items, want 1", len(items))
+			}
+			if brand := items[0].ItemSpecifics["Brand"]; brand != test.brand {
+				t.Errorf("brand = %q, want %q", brand, test.brand)
+			}
+		})
+	}
+}
+
+func TestRetryFailedItemsCancelled(t *testing.T) {
+	server := newFlakyDetailServer(t, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Crawler{Logger: discardLogger, Enrich: true, RetryFailedItems: true}
+	c.reset()
+
+	//The item is held back when the pages are done, cancelling then leaves only the retry pass
+	c.failedItems = []*ItemInfo{{ItemID: "555", ProductURL: server.URL + "/itm/555"}}
+	cancel()
+
+	var failures atomic.Int64
+	c.retryFailedItems(ctx, &failures)
+	if len(c.items) != 1 || c.items[0].ItemSpecifics != nil {
+		t.Errorf("got items %v, want the item with listing data", c.items)
+	}
+}
+
+func TestParseRecentSales(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want *RecentSales
+	}{
+		{"split spans", `<div class="x-ebay-signal"><span class="ux-textspans--BOLD">12 sold</span> <span>in the last 24 hours</span></div>`, &RecentSales{12, "24 hours"}},
+		{"more than", `<div><span>More than 10 sold in past hour</span></div>`, &RecentSales{10, "1 hour"}},
+		{"people bought", `<div><span>1,204 people bought this in the last 7 days</span></div>`, &RecentSales{1204, "7 days"}},
+		{"sold total", `<div class="x-quantity__availability"><span>More than 10 available</span> <span>150 sold</span></div>`, nil},
+		{"script only", `<script>var msg = "12 sold in the last 24 hours";</script>`, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pageNode, err := html.Parse(strings.NewReader("<html><body>" + test.page + "</body></html>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := parseRecentSales(pageNode)
+			if (got == nil) != (test.want == nil) || (got != nil && *got != *test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseCategories(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{"breadcrumb", `<nav class="breadcrumbs" aria-label="Breadcrumb"><ul>` +
+			`<li><a href="/sch/i.html"><span>Back to search results</span></a></li>` +
+			`<li><a class="seo-breadcrumb-text" href="https://www.ebay.com/"><span>eBay</span></a></li>` +
+			`<li><a class="seo-breadcrumb-text" href="/b/Electronics/bn_7000259124"><span>Electronics</span></a> &gt;</li>` +
+			`<li><a class="seo-breadcrumb-text" href="/b/Computers/58058/bn_1865247"><span>Computers/Tablets &amp; Networking</span></a> &gt;</li>` +
+			`<li><a class="seo-breadcrumb-text" href="/b/Laptops/175672/bn_1648276"><span>Laptops &amp; Netbooks</span></a></li>` +
+			`</ul></nav>`, "Electronics|Computers/Tablets & Networking|Laptops & Netbooks"},
+		{"no breadcrumb", `<div><ul><li>Electronics</li></ul></div>`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pageNode, err := html.Parse(strings.NewReader("<html><body>" + test.page + "</body></html>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(parseCategories(pageNode), "|"); got != test.want {
+				t.Errorf("got categories %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMaxEnrichFailures(t *testing.T) {
+	var detailRequests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/itm/") {
+			detailRequests.Add(1)
+			http.Error(w, "blocked", http.StatusForbidden)
+			return
+		}
+
+		fmt.Fprint(w, `<html><body><ul>`)
+		for i := 1; i <= 10; i++ {
+			fmt.Fprintf(w, `<li class="s-item" id="item%d"><a class="s-item__link" href="http://%s/itm/%d">`+
+				`<div class="s-item__title"><span role="heading">Dell Laptop</span></div></a><span class="s-item__price">$10.00</span></li>`, i, r.Host, 100+i)
+		}
+		fmt.Fprint(w, `</ul></body></html>`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		max      int
+		requests int64
+	}{
+		{"threshold", 3, 4},
+		{"no limit", 0, 10},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			detailRequests.Store(0)
+
+			c := &Crawler{Logger: discardLogger, Workers: 1, Enrich: true, MaxEnrichFailures: test.max}
+			items, err := c.Crawl(context.Background(), server.URL+"/sch/i.html")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			//Enrichment stops after the failure exceeding the threshold, all items keep their listing data
+			if requests := detailRequests.Load(); requests != test.requests {
+				t.Errorf("got %d detail requests, want %d", requests, test.requests)
+			}
+			if len(items) != 10 {
+				t.Errorf("got %d items, want all 10 with listing data", len(items))
+			}
+		})
+	}
+}
+
+func TestParseItemSpecifics(t *testing.T) {
+	//Labels with colons and odd spacing, a value split over spans and a repeated label
+	pageNode, err := html.Parse(strings.NewReader(`<html><body><div class="ux-layout-section--features">
+<div class="ux-labels-values__labels"><span>Brand:</span></div><div class="ux-labels-values__values"><span>Dell</span></div>
+<div class="ux-labels-values__labels"> Model </div><div class="ux-labels-values__values">  Latitude   7490 </div>
+<div class="ux-labels-values__labels">Screen
+	Size :</div><div class="ux-labels-values__values"><span>14</span> <span>in</span></div>
+<div class="ux-labels-values__labels">brand:</div><div class="ux-labels-values__values">Unbranded</div>
+<div class="ux-labels-values__labels">:</div><div class="ux-labels-values__values">No label</div>
+<div class="ux-labels-values__labels">Color:</div><div class="ux-labels-values__values"> </div>
+</div></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"Brand": "Dell", "Model": "Latitude 7490", "Screen Size": "14 in"}
+	if got := parseItemSpecifics(pageNode); !maps.Equal(got, want) {
+		t.Errorf("got specifics %v, want %v", got, want)
+	}
+}
+
+// Function returns detail page whose gallery has the number of photos, a page without gallery when 0
+func photosDetailPage(photos int) string {
+	page := `<html><body><div class="ux-image-carousel">`
+	for i := 1; i <= photos; i++ {
+		page += fmt.Sprintf(`<div class="ux-image-carousel-item"><img src="https://i.ebayimg.com/images/g/P%d/s-l500.jpg"></div>`, i)
+	}
+
+	return page + `</div></body></html>`
+}
+
+func TestPhotoFilter(t *testing.T) {
+	//Item 555 has 3 photos, 556 one and 557 no gallery, its photo count is unknown
+	photos := map[string]int{"555": 3, "556": 1, "557": 0}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if itemID, ok := strings.CutPrefix(r.URL.Path, "/itm/"); ok {
+			if photos[itemID] == 0 {
+				fmt.Fprint(w, specificsDetailPage)
+				return
+			}
+			fmt.Fprint(w, photosDetailPage(photos[itemID]))
+			return
+		}
+		fmt.Fprint(w, `<html><body><ul>`)
+		for _, itemID := range []string{"555", "556", "557"} {
+			fmt.Fprintf(w, `<li class="s-item" id="item%[2]s"><a class="s-item__link" href="%[1]s/itm/%[2]s">`+
+				`<div class="s-item__title"><span role="heading">Dell Laptop</span></div></a><span class="s-item__price">$10.00</span></li>`, server.URL, itemID)
+		}
+		fmt.Fprint(w, `</ul></body></html>`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		minPhotos int
+		strict    bool
+		want      string
+	}{
+		{"no filter", 0, false, "555,556,557"},
+		{"unknown kept", 2, false, "555,557"},
+		{"strict", 2, true, "555"},
+		{"cutoff is inclusive", 3, false, "555,557"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := NewPhotoFilter(test.minPhotos, test.strict)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := &Crawler{Logger: discardLogger, Enrich: true, Workers: 1, PhotoFilter: filter}
+			items, err := c.Crawl(context.Background(), server.URL+"/sch/i.html")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(itemIDs(items), ","); got != test.want {
+				t.Errorf("got items %s, want %s", got, test.want)
+			}
+			for _, item := range items {
+				if item.PhotoCount != photos[item.ItemID] {
+					t.Errorf("item %s has photo count %d, want %d", item.ItemID, item.PhotoCount, photos[item.ItemID])
+				}
+			}
+			if filtered := c.Stats().Filtered; filtered != 3-len(items) {
+				t.Errorf("got %d filtered items, want %d", filtered, 3-len(items))
+			}
+		})
+	}
+
+	if _, err := NewPhotoFilter(-1, false); err == nil {
+		t.Error("got no error for negative minimal photo count")
+	}
+}
+
+func TestFastShippingDetailPage(t *testing.T) {
+	tests := []struct {
+		name   string
+		detail string
+		want   bool
+	}{
+		{"shipping section", `<html><body><div class="d-shipping-minview"><span>Free Fast 'N Free delivery.</span></div></body></html>`, true},
+		{"labels shipping row", `<html><body><div class="ux-labels-values ux-labels-values--shipping"><span>Shipping:</span><span>Fast &amp; Free</span></div></body></html>`, true},
+		{"slow shipping", `<html><body><div class="d-shipping-minview"><span>$9.99 Standard Shipping</span></div></body></html>`, false},
+		//Perks of other listings shown on the page aren't the item's
+		{"perk outside the shipping section", `<html><body><div class="d-shipping-minview">Economy</div><div class="x-related">Fast 'N Free</div></body></html>`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(r.URL.Path, "/itm/") {
+					fmt.Fprint(w, test.detail)
+					return
+				}
+				fmt.Fprint(w, `<html><body><ul><li class="s-item" id="item1"><a class="s-item__link" href="`+server.URL+`/itm/555">`+
+					`<div class="s-item__title"><span role="heading">Dell Laptop</span></div></a><span class="s-item__price">$10.00</span></li></ul></body></html>`)
+			}))
+			defer server.Close()
+
+			c := &Crawler{Logger: discardLogger, Enrich: true}
+			items, err := c.Crawl(context.Background(), server.URL+"/sch/i.html")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != 1 || items[0].FastShipping != test.want {
+				t.Errorf("got items %+v, want fast shipping %t", items, test.want)
+			}
+		})
+	}
+}
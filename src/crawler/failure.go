@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"errors"
+	"time"
+)
+
+// Item card lookup which found nothing, or card which failed to parse
+type ParseFailure struct {
+	Time     time.Time `json:"time"`
+	PageURL  string    `json:"page_url"`
+	ItemID   string    `json:"item_id,omitempty"`
+	URL      string    `json:"url,omitempty"`      // product URL of the card, empty when its link wasn't found
+	Selector string    `json:"selector,omitempty"` // lookup which found nothing
+	Field    string    `json:"field,omitempty"`    // field of a failed card (link, price, title, condition or url), see ParseError
+	Error    string    `json:"error,omitempty"`    // parse error of a failed card, empty when only an optional field is missing
+}
+
+// Card lookup which found nothing, recorded by parsers on the item
+type selectorMiss struct {
+	selector string
+	href     string
+}
+
+// Function passes selector misses of the parsed item and its parse error to OnParseFailure. The failing lookup
+// is the last miss, so the error is reported with it
+func (c *Crawler) reportParseFailures(item *ItemInfo, pageURL string, err error) {
+	misses := item.selectorMisses
+	item.selectorMisses = nil
+
+	if c.OnParseFailure == nil || (len(misses) == 0 && err == nil) {
+		return
+	}
+
+	now := time.Now().UTC()
+	failures := []ParseFailure{}
+	for _, miss := range misses {
+		failure := ParseFailure{Time: now, PageURL: pageURL, URL: miss.href, Selector: miss.selector}
+		if miss.href != "" {
+			failure.ItemID = c.extractItemID(miss.href)
+		}
+		failures = append(failures, failure)
+	}
+
+	if err != nil {
+		if len(failures) == 0 {
+			failures = append(failures, ParseFailure{Time: now, PageURL: pageURL})
+		}
+		failures[len(failures)-1].Error = err.Error()
+
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			failures[len(failures)-1].Field = parseErr.Field
+		}
+	}
+
+	for _, failure := range failures {
+		c.OnParseFailure(failure)
+	}
+}
@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+)
+
+// Inclusive price range filter, zero bound means no limit
+type PriceFilter struct {
+	Min float64
+	Max float64
+
+	filtered    atomic.Int64
+	unparseable atomic.Int64
+}
+
+// Function validates price bounds and creates filter. Returns nil when no bound is set
+func NewPriceFilter(minPrice float64, maxPrice float64) (*PriceFilter, error) {
+	if minPrice < 0 || maxPrice < 0 {
+		return nil, fmt.Errorf("ERROR::Price bounds must not be negative")
+	}
+
+	if maxPrice > 0 && minPrice > maxPrice {
+		return nil, fmt.Errorf("ERROR::Minimal price %.2f is greater than maximal price %.2f", minPrice, maxPrice)
+	}
+
+	if minPrice == 0 && maxPrice == 0 {
+		return nil, nil
+	}
+
+	return &PriceFilter{Min: minPrice, Max: maxPrice}, nil
+}
+
+// Function checks if item price is within the range. Items with unparseable price are counted and dropped
+func (f *PriceFilter) Keep(item *ItemInfo) bool {
+	price, err := strconv.ParseFloat(item.PriceMin, 64)
+	if err != nil {
+		f.unparseable.Add(1)
+		return false
+	}
+
+	if price < f.Min || (f.Max > 0 && price > f.Max) {
+		f.filtered.Add(1)
+		return false
+	}
+
+	return true
+}
+
+// Function logs number of items dropped by the filter
+func (f *PriceFilter) LogSummary(logger *slog.Logger) {
+	logger.Info("Filtered out items by price", "filtered", f.filtered.Load(), "unparseable", f.unparseable.Load())
+}
+
+// Minimal photo count filter. Items whose count is unknown (no gallery found, not enriched) are kept unless Strict
+type PhotoFilter struct {
+	Min    int
+	Strict bool
+
+	filtered atomic.Int64
+	unknown  atomic.Int64
+}
+
+// Function validates minimal photo count and creates filter. Returns nil when no minimum is set
+func NewPhotoFilter(minPhotos int, strict bool) (*PhotoFilter, error) {
+	if minPhotos < 0 {
+		return nil, fmt.Errorf("ERROR::Minimal number of photos must not be negative")
+	}
+
+	if minPhotos == 0 {
+		return nil, nil
+	}
+
+	return &PhotoFilter{Min: minPhotos, Strict: strict}, nil
+}
+
+// Function checks if item has at least the minimal number of photos
+func (f *PhotoFilter) Keep(item *ItemInfo) bool {
+	if item.PhotoCount == 0 {
+		f.unknown.Add(1)
+		return !f.Strict
+	}
+
+	if item.PhotoCount < f.Min {
+		f.filtered.Add(1)
+		return false
+	}
+
+	return true
+}
+
+// Function logs number of items dropped by the filter and of items whose photo count is unknown
+func (f *PhotoFilter) LogSummary(logger *slog.Logger) {
+	logger.Info("Filtered out items by photo count", "filtered", f.filtered.Load(), "unknown", f.unknown.Load(), "strict", f.Strict)
+}
@@ -0,0 +1,65 @@
+package crawler
+
+import (
+	"regexp"
+
+	"golang.org/x/net/html"
+)
+
+// Containers of detail page gallery images: the main carousel and its thumbnail grid or filmstrip
+var galleryClasses = []string{"ux-image-carousel", "ux-image-grid", "ux-image-filmstrip"}
+
+// Size of eBay image URLs, e.g. s-l64.jpg of a thumbnail, s-l1600 is the largest size the CDN serves
+var imageSizeRegEx = regexp.MustCompile(`/s-l\d+(\.(?:jpe?g|png|webp))`)
+
+// Function to get full resolution URLs of gallery images of the detail page, in gallery order without duplicates.
+// Nil when the page has no gallery
+func parseGalleryImages(pageNode *html.Node) []string {
+	var images []string
+	seen := map[string]bool{}
+
+	for _, class := range galleryClasses {
+		for _, galleryNode := range findAllElementsByAttr(pageNode, "div", "class", class, []*html.Node{}) {
+			for _, src := range galleryImageSources(galleryNode) {
+				src = fullResolutionImageURL(src)
+				if !seen[src] {
+					seen[src] = true
+					images = append(images, src)
+				}
+			}
+		}
+	}
+
+	return images
+}
+
+// Function to get image URLs within gallery node. Carousel images are lazy loaded, so the zoom source or data-src
+// is taken over a placeholder src
+func galleryImageSources(node *html.Node) []string {
+	sources := []string{}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "img" {
+			src, err := getElementAttrByName(n, "data-zoom-src")
+			if err != nil || src == "" {
+				src = getImageSrc(n)
+			}
+			if !isPlaceholderImage(src) {
+				sources = append(sources, src)
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	return sources
+}
+
+// Function returns URL of the largest size of an eBay image, other URLs are kept as they are
+func fullResolutionImageURL(src string) string {
+	return imageSizeRegEx.ReplaceAllString(src, "/s-l1600$1")
+}
@@ -0,0 +1,53 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// Detail page gallery of two images: a lazy loaded carousel and its thumbnail grid, which repeats the first image
+const galleryDetailPage = `<html><body>
+<div class="ux-image-carousel-container"><div class="ux-image-carousel">
+<div class="ux-image-carousel-item active"><img src="https://i.ebayimg.com/images/g/AAA/s-l500.jpg" data-zoom-src="https://i.ebayimg.com/images/g/AAA/s-l1600.jpg"></div>
+<div class="ux-image-carousel-item"><img src="data:image/gif;base64,R0lGOD" data-src="https://i.ebayimg.com/images/g/BBB/s-l500.webp"></div>
+</div></div>
+<div class="ux-image-grid"><img src="https://i.ebayimg.com/images/g/AAA/s-l64.jpg"><img src="https://i.ebayimg.com/images/g/BBB/s-l64.webp"></div>
+<img src="https://i.ebayimg.com/images/g/LOGO/s-l140.png">
+</body></html>`
+
+func TestParseGalleryImages(t *testing.T) {
+	pageNode, err := html.Parse(strings.NewReader(galleryDetailPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "https://i.ebayimg.com/images/g/AAA/s-l1600.jpg,https://i.ebayimg.com/images/g/BBB/s-l1600.webp"
+	if got := strings.Join(parseGalleryImages(pageNode), ","); got != want {
+		t.Errorf("got images %s, want %s", got, want)
+	}
+}
+
+func TestParseGalleryImagesWithoutGallery(t *testing.T) {
+	pageNode, _ := html.Parse(strings.NewReader(`<html><body><img src="https://i.ebayimg.com/images/g/AAA/s-l140.jpg"></body></html>`))
+	if images := parseGalleryImages(pageNode); images != nil {
+		t.Errorf("got images %v of a page without gallery", images)
+	}
+}
+
+func TestFullResolutionImageURL(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"https://i.ebayimg.com/images/g/AAA/s-l64.jpg", "https://i.ebayimg.com/images/g/AAA/s-l1600.jpg"},
+		{"https://i.ebayimg.com/thumbs/images/g/AAA/s-l225.jpeg", "https://i.ebayimg.com/thumbs/images/g/AAA/s-l1600.jpeg"},
+		{"https://example.com/photo.jpg", "https://example.com/photo.jpg"},
+	}
+
+	for _, test := range tests {
+		if got := fullResolutionImageURL(test.src); got != test.want {
+			t.Errorf("fullResolutionImageURL(%q) = %q, want %q", test.src, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Function to find all indicated elements, within an HTML NODE, by Class Name
+func findItemElementsByClass(node *html.Node, elementType string, className string, itemList []*html.Node) []*html.Node {
+	if node.Type == html.ElementNode && node.Data == elementType {
+		class := ""
+		id := ""
+
+		for _, a := range node.Attr {
+			if a.Key == "class" && strings.Contains(a.Val, className) {
+				class = a.Val
+			} else if a.Key == "id" && a.Val != "" {
+				id = a.Val
+			}
+
+			if class != "" && id != "" {
+				itemList = append(itemList, node)
+
+				break
+			}
+		}
+	}
+
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		itemList = findItemElementsByClass(c, elementType, className, itemList)
+	}
+
+	return itemList
+}
+
+// Function to find first element, within an HTML NODE, by Attribute
+func findFirstElementByAttr(node *html.Node, elementType string, attrName string, attrValue string) *html.Node {
+	nodeFound := false
+
+	if node.Type == html.ElementNode && node.Data == elementType {
+		for _, a := range node.Attr {
+			if a.Key == attrName && strings.Contains(a.Val, attrValue) {
+				nodeFound = true
+				return node
+			}
+		}
+	}
+
+	for c := node.FirstChild; c != nil && !nodeFound; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			tempNode := findFirstElementByAttr(c, elementType, attrName, attrValue)
+			if tempNode != nil {
+				return tempNode
+			}
+		}
+	}
+
+	return nil
+}
+
+// Function to find first element, within an HTML NODE, trying attribute values in order
+func findFirstElementByAnyAttr(node *html.Node, elementType string, attrName string, attrValues []string) *html.Node {
+	return findFirstElementByAnyMatch(node, elementType, attrName, attrValues, strings.Contains)
+}
+
+// Function to find first element, within an HTML NODE, with one of the classes as a whole class token, trying classes
+// in order. Unlike substring matching, "s-item__price" doesn't match "s-item__price--original"
+func findFirstElementByAnyClass(node *html.Node, elementType string, classes []string) *html.Node {
+	return findFirstElementByAnyMatch(node, elementType, "class", classes, hasClassToken)
+}
+
+// Function to find first element, within an HTML NODE, with one of the class tokens
+func findFirstElementByClass(node *html.Node, elementType string, class string) *html.Node {
+	return findFirstElementByAnyClass(node, elementType, []string{class})
+}
+
+// Function to find first element, within an HTML NODE, whose attribute matches one of the values, trying values in order
+func findFirstElementByAnyMatch(node *html.Node, elementType string, attrName string, attrValues []string, match func(attrVal string, value string) bool) *html.Node {
+	//Single walk keeping the match of the most preferred value, instead of a walk per value
+	var bestNode *html.Node
+	bestIndex := len(attrValues)
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == elementType {
+			for _, a := range n.Attr {
+				if a.Key != attrName {
+					continue
+				}
+
+				for i, attrValue := range attrValues[:bestIndex] {
+					if match(a.Val, attrValue) {
+						bestNode, bestIndex = n, i
+						break
+					}
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil && bestIndex > 0; c = c.NextSibling {
+			if c.Type == html.ElementNode {
+				walk(c)
+			}
+		}
+	}
+	walk(node)
+
+	return bestNode
+}
+
+// Function to get a value of element, within an HTML NODE: text of all descendants concatenated and trimmed
+func getElementNodeVal(node *html.Node) (string, error) {
+	var value strings.Builder
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				value.WriteString(c.Data)
+			}
+
+			walk(c)
+		}
+	}
+	walk(node)
+
+	text := strings.TrimSpace(value.String())
+	if text == "" {
+		return "", fmt.Errorf("ERROR::No text node found")
+	}
+
+	return text, nil
+}
+
+// Function to get a value of a given attribute of a node by attribute name
+func getElementAttrByName(node *html.Node, attrName string) (string, error) {
+	if node.Type == html.ElementNode {
+		for _, a := range node.Attr {
+			if a.Key == attrName {
+				return a.Val, nil
+			}
+		}
+
+		return "", fmt.Errorf("ERROR::Attribute %s not found", attrName)
+	} else {
+		return "", fmt.Errorf("ERROR::Node is not an element")
+	}
+}
+
+// Function checks if the class attribute value has the class as one of its space separated tokens
+func hasClassToken(classAttr string, class string) bool {
+	if class == "" {
+		return false
+	}
+
+	for i := 0; i+len(class) <= len(classAttr); {
+		j := strings.Index(classAttr[i:], class)
+		if j < 0 {
+			return false
+		}
+
+		start, end := i+j, i+j+len(class)
+		if (start == 0 || isClassSpace(classAttr[start-1])) && (end == len(classAttr) || isClassSpace(classAttr[end])) {
+			return true
+		}
+		i = start + 1
+	}
+
+	return false
+}
+
+// Function checks if byte separates class tokens (HTML ASCII whitespace)
+func isClassSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\f' || b == '\r'
+}
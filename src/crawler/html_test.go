@@ -0,0 +1,220 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// Function parses HTML snippet into a document node
+func parseSnippet(t *testing.T, snippet string) *html.Node {
+	t.Helper()
+
+	node, err := html.Parse(strings.NewReader(snippet))
+	if err != nil {
+		t.Fatalf("can't parse snippet: %s", err)
+	}
+
+	return node
+}
+
+// Function returns data-n attributes of nodes joined with commas, naming nodes of the snippets
+func nodeNames(nodes []*html.Node) string {
+	names := []string{}
+	for _, node := range nodes {
+		name, _ := getElementAttrByName(node, "data-n")
+		names = append(names, name)
+	}
+
+	return strings.Join(names, ",")
+}
+
+func TestFindItemElementsByClass(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{"class and id", `<ul><li data-n="a" class="s-item" id="1"></li><li data-n="b" class="s-item s-item--large" id="2"></li></ul>`, "a,b"},
+		{"without id", `<ul><li data-n="a" class="s-item"></li><li data-n="b" class="s-item" id=""></li><li data-n="c" class="s-item" id="3"></li></ul>`, "c"},
+		{"partial class", `<ul><li data-n="a" class="s-item__wrapper" id="1"></li><li data-n="b" class="item" id="2"></li></ul>`, "a"},
+		{"other element", `<div data-n="a" class="s-item" id="1"></div>`, ""},
+		{"nested", `<ul><li data-n="a" class="s-item" id="1"><ul><li data-n="b" class="s-item" id="2"></li></ul></li></ul>`, "a,b"},
+		{"no match", `<p>no items</p>`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := nodeNames(findItemElementsByClass(parseSnippet(t, test.snippet), "li", "s-item", []*html.Node{}))
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFindFirstElementByAttr(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{"first of siblings", `<span data-n="a" class="price"></span><span data-n="b" class="price"></span>`, "a"},
+		{"depth first", `<div><div><span data-n="a" class="price"></span></div></div><span data-n="b" class="price"></span>`, "a"},
+		{"outer before nested", `<span data-n="a" class="price"><span data-n="b" class="price"></span></span>`, "a"},
+		{"partial value", `<span data-n="a" class="s-item__price--original"></span>`, "a"},
+		{"other attribute", `<span data-n="a" id="price"></span><span data-n="b" class="price"></span>`, "b"},
+		{"other element", `<div data-n="a" class="price"></div>`, ""},
+		{"no match", `<span data-n="a" class="title"></span>`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := findFirstElementByAttr(parseSnippet(t, test.snippet), "span", "class", "price")
+			got := ""
+			if node != nil {
+				got = nodeNames([]*html.Node{node})
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHasClassToken(t *testing.T) {
+	tests := []struct {
+		classAttr string
+		want      bool
+	}{
+		{"s-item__price", true},
+		{"bold s-item__price", true},
+		{"s-item__price\tbold", true},
+		{"s-item__price--original", false},
+		{"s-item__price-current", false},
+		{"x-s-item__price", false},
+		{"s-item__price--original s-item__price", true},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if got := hasClassToken(test.classAttr, "s-item__price"); got != test.want {
+			t.Errorf("hasClassToken(%q) = %t, want %t", test.classAttr, got, test.want)
+		}
+	}
+}
+
+func TestFindFirstElementByClass(t *testing.T) {
+	//Attribute match takes any value containing the class, class match only whole class tokens
+	const snippet = `<span data-n="a" class="s-item__price--original">$150.00</span><span data-n="b" class="bold s-item__price">$120.00</span>`
+
+	byAttr := findFirstElementByAttr(parseSnippet(t, snippet), "span", "class", "s-item__price")
+	if got := nodeNames([]*html.Node{byAttr}); got != "a" {
+		t.Errorf("attribute match got %q, want the original price a", got)
+	}
+
+	byClass := findFirstElementByClass(parseSnippet(t, snippet), "span", "s-item__price")
+	if byClass == nil {
+		t.Fatal("class match found nothing, want the price b")
+	}
+	if got := nodeNames([]*html.Node{byClass}); got != "b" {
+		t.Errorf("class match got %q, want the price b", got)
+	}
+}
+
+func TestFindAllElementsByAttr(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{"document order", `<div><a data-n="a" href="/itm/1"></a></div><a data-n="b" href="/itm/2"></a>`, "a,b"},
+		{"nested", `<a data-n="a" href="/itm/1"><a data-n="b" href="/itm/2"></a></a>`, "a,b"},
+		{"partial value", `<a data-n="a" href="https://www.ebay.com/itm/1?hash=x"></a><a data-n="b" href="/sch/i.html"></a>`, "a"},
+		{"no match", `<a data-n="a" href="/sch/i.html"></a>`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := nodeNames(findAllElementsByAttr(parseSnippet(t, test.snippet), "a", "href", "/itm/", []*html.Node{}))
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetNodeText(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{"single", `<div data-n="a">Dell Laptop</div>`, "Dell Laptop"},
+		{"nested spans", `<div data-n="a"><span> Free </span><span>delivery</span></div>`, "Free delivery"},
+		{"whitespace between", "<div data-n=\"a\">\n  <span>12 sold</span>\n  <b>5 watching</b>\n</div>", "12 sold 5 watching"},
+		{"empty", `<div data-n="a"><span> </span></div>`, ""},
+		{"punctuation after element", `<div data-n="a"><span>Brand New</span>, Factory Sealed <b>Only 2 left</b>!</div>`, "Brand New, Factory Sealed Only 2 left!"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := findFirstElementByAttr(parseSnippet(t, test.snippet), "div", "data-n", "a")
+			if got := getNodeText(node); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetElementNodeVal(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+		wantErr bool
+	}{
+		{"single", `<div data-n="a">Dell Laptop</div>`, "Dell Laptop", false},
+		{"interrupted by bold", `<div data-n="a"> Dell <b>Latitude</b> 5490 Laptop </div>`, "Dell Latitude 5490 Laptop", false},
+		{"nested inline", `<div data-n="a"><span>Think<i>Pad</i></span> X220</div>`, "ThinkPad X220", false},
+		{"whitespace only", `<div data-n="a"> <span> </span> </div>`, "", true},
+		{"empty", `<div data-n="a"></div>`, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := findFirstElementByAttr(parseSnippet(t, test.snippet), "div", "data-n", "a")
+			got, err := getElementNodeVal(node)
+			if got != test.want || (err != nil) != test.wantErr {
+				t.Errorf("got %q, %v, want %q with error %t", got, err, test.want, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetElementAttrByName(t *testing.T) {
+	link := findFirstElementByAttr(parseSnippet(t, `<a href="/itm/1" class="">Dell</a>`), "a", "href", "/itm/")
+
+	tests := []struct {
+		name    string
+		node    *html.Node
+		attr    string
+		want    string
+		wantErr bool
+	}{
+		{"present", link, "href", "/itm/1", false},
+		{"empty value", link, "class", "", false},
+		{"missing", link, "id", "", true},
+		{"text node", link.FirstChild, "href", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := getElementAttrByName(test.node, test.attr)
+			if got != test.want || (err != nil) != test.wantErr {
+				t.Errorf("got %q, %v, want %q with error %t", got, err, test.want, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package crawler
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// Item specifics labels of product identifiers, in order of preference for each identifier
+var identifierLabels = []struct {
+	name   string
+	labels []string
+}{
+	{"EAN", []string{"EAN", "EAN-13", "GTIN"}},
+	{"UPC", []string{"UPC"}},
+	{"ISBN", []string{"ISBN", "ISBN-13", "ISBN-10"}},
+	{"MPN", []string{"MPN", "Manufacturer Part Number"}},
+}
+
+// Values sellers fill in identifier specifics of products which have none
+var missingIdentifiers = map[string]bool{
+	"doesnotapply":  true,
+	"notapplicable": true,
+	"na":            true,
+	"n/a":           true,
+	"none":          true,
+	"unknown":       true,
+}
+
+// Function fills product identifiers (EAN, UPC, ISBN, MPN) from item specifics of the detail page. Identifiers are kept
+// without spaces and dashes, EAN, UPC and ISBN with an invalid check digit are kept too but logged as warning
+func parseIdentifiers(item *ItemInfo, logger *slog.Logger) {
+	for _, identifier := range identifierLabels {
+		value := ""
+		for _, label := range identifier.labels {
+			value = normalizeIdentifier(item.ItemSpecifics[label])
+			if value != "" {
+				break
+			}
+		}
+		if value == "" {
+			continue
+		}
+
+		valid := true
+		switch identifier.name {
+		case "EAN":
+			item.EAN = value
+			valid = validGTIN(value)
+		case "UPC":
+			item.UPC = value
+			valid = validGTIN(value)
+		case "ISBN":
+			item.ISBN = value
+			valid = validISBN(value)
+		case "MPN":
+			item.MPN = value
+		}
+
+		if !valid {
+			logger.Warn("Invalid product identifier check digit", "item_id", item.ItemID, "identifier", identifier.name, "value", value)
+		}
+	}
+}
+
+// Function returns identifier without spaces and dashes, the first one when several are listed, empty for
+// placeholders like "Does not apply"
+func normalizeIdentifier(value string) string {
+	value, _, _ = strings.Cut(value, ",")
+	value = strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\u00a0' {
+			return -1
+		}
+		return r
+	}, value)
+
+	if missingIdentifiers[strings.ToLower(value)] {
+		return ""
+	}
+
+	return strings.ToUpper(value)
+}
+
+// Function checks GTIN check digit of EAN-8, UPC-A, EAN-13 and GTIN-14 codes
+func validGTIN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	sum := 0
+	for i := len(code) - 1; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+
+		//Digits are weighted 3 and 1 alternately from the right, the check digit included with weight 1
+		if (len(code)-1-i)%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	return sum%10 == 0
+}
+
+// Function checks check digit of ISBN-13 (an EAN-13) and ISBN-10, whose check digit X means 10
+func validISBN(code string) bool {
+	if len(code) == 13 {
+		return validGTIN(code)
+	}
+	if len(code) != 10 {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 10; i++ {
+		digit := int(code[i] - '0')
+		if i == 9 && code[i] == 'X' {
+			digit = 10
+		} else if digit < 0 || digit > 9 {
+			return false
+		}
+		sum += (10 - i) * digit
+	}
+
+	return sum%11 == 0
+}
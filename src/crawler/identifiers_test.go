@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// Detail page with item specifics of a book with a valid EAN and a UPC with a wrong check digit
+const identifiersDetailPage = `<html><body>
+<div class="ux-labels-values__labels">EAN:</div><div class="ux-labels-values__values">978-0-306-40615-7</div>
+<div class="ux-labels-values__labels">UPC:</div><div class="ux-labels-values__values">0 36000 29145 3</div>
+<div class="ux-labels-values__labels">ISBN:</div><div class="ux-labels-values__values">0-306-40615-2</div>
+<div class="ux-labels-values__labels">MPN:</div><div class="ux-labels-values__values">Does not apply</div>
+</body></html>`
+
+func TestParseIdentifiers(t *testing.T) {
+	pageNode, err := html.Parse(strings.NewReader(identifiersDetailPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	item := &ItemInfo{ItemID: "555", ItemSpecifics: parseItemSpecifics(pageNode)}
+	parseIdentifiers(item, slog.New(slog.NewTextHandler(&logs, nil)))
+
+	if item.EAN != "9780306406157" || item.UPC != "036000291453" || item.ISBN != "0306406152" || item.MPN != "" {
+		t.Errorf("got EAN %q UPC %q ISBN %q MPN %q", item.EAN, item.UPC, item.ISBN, item.MPN)
+	}
+
+	//Only the UPC has a wrong check digit, the right one is 2
+	if strings.Count(logs.String(), "Invalid product identifier") != 1 || !strings.Contains(logs.String(), "identifier=UPC") {
+		t.Errorf("got warnings %q, want one for the UPC", logs.String())
+	}
+}
+
+func TestValidGTIN(t *testing.T) {
+	tests := []struct {
+		code  string
+		valid bool
+	}{
+		{"036000291452", true},   // UPC-A
+		{"036000291453", false},  // UPC-A with wrong check digit
+		{"4006381333931", true},  // EAN-13
+		{"4006381333932", false}, // EAN-13 with wrong check digit
+		{"96385074", true},       // EAN-8
+		{"12345", false},
+		{"03600029145A", false},
+	}
+
+	for _, test := range tests {
+		if valid := validGTIN(test.code); valid != test.valid {
+			t.Errorf("validGTIN(%q) = %t, want %t", test.code, valid, test.valid)
+		}
+	}
+}
+
+func TestValidISBN(t *testing.T) {
+	tests := []struct {
+		code  string
+		valid bool
+	}{
+		{"0306406152", true},
+		{"0306406153", false},
+		{"080442957X", true},
+		{"9780306406157", true},
+		{"X306406152", false},
+	}
+
+	for _, test := range tests {
+		if valid := validISBN(test.code); valid != test.valid {
+			t.Errorf("validISBN(%q) = %t, want %t", test.code, valid, test.valid)
+		}
+	}
+}
+
+func TestNormalizeIdentifier(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{" 978-0-306-40615-7 ", "9780306406157"},
+		{"mx-123 ab", "MX123AB"},
+		{"036000291452, 036000291469", "036000291452"},
+		{"Does Not Apply", ""},
+		{"N/A", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := normalizeIdentifier(test.value); got != test.want {
+			t.Errorf("normalizeIdentifier(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
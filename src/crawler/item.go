@@ -0,0 +1,363 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Version of ItemInfo JSON shape, bumped whenever a field is removed or changes its meaning
+const SchemaVersion int = 1
+
+type ItemInfo struct {
+	SchemaVersion     int               `json:"schema_version"`
+	CrawledAt         string            `json:"crawled_at"` // RFC 3339 UTC time the item was parsed
+	ItemID            string            `json:"item_id"`
+	Title             string            `json:"title"`
+	RawTitle          string            `json:"raw_title,omitempty"`
+	Condition         string            `json:"condition"`
+	RawCondition      string            `json:"raw_condition,omitempty"`
+	Subtitle          string            `json:"subtitle,omitempty"`
+	Price             string            `json:"price"`
+	PriceCents        int64             `json:"price_cents"`
+	PriceMin          string            `json:"price_min"`
+	PriceMax          string            `json:"price_max"`
+	OriginalPrice     string            `json:"original_price,omitempty"`
+	DiscountPercent   float64           `json:"discount_percent,omitempty"`
+	Currency          string            `json:"currency,omitempty"`
+	Shipping          string            `json:"shipping,omitempty"`
+	ShippingRaw       string            `json:"shipping_raw,omitempty"`
+	Location          string            `json:"location,omitempty"`
+	ProductURL        string            `json:"product_url"`
+	RawURL            string            `json:"raw_url,omitempty"` // link as found on the page, when it differs from product URL
+	ImageURL          string            `json:"image_url,omitempty"`
+	Images            []string          `json:"images,omitempty"`      // full resolution gallery images of the detail page
+	PhotoCount        int               `json:"photo_count,omitempty"` // number of gallery images, 0 when unknown
+	StoreName         string            `json:"store_name,omitempty"`
+	Category          string            `json:"category,omitempty"`   // item category label, or category of the results page
+	Categories        []string          `json:"categories,omitempty"` // detail page breadcrumb, root to leaf
+	Source            string            `json:"source,omitempty"`
+	SourceURL         string            `json:"source_url,omitempty"` // results page the item was found on
+	SaleEndsAt        *time.Time        `json:"sale_ends_at,omitempty"`
+	Ended             bool              `json:"ended,omitempty"` // listing of a watched item ended or was removed
+	RefurbGrade       RefurbGrade       `json:"refurb_grade,omitempty"`
+	ReserveNotMet     bool              `json:"reserve_not_met,omitempty"`
+	QuantityAvailable int               `json:"quantity_available,omitempty"`
+	IsBanner          bool              `json:"is_banner,omitempty"`
+	Related           bool              `json:"related,omitempty"` // listed after "Results matching fewer words"
+	IsSponsored       bool              `json:"is_sponsored,omitempty"`
+	BrandOutlet       bool              `json:"brand_outlet,omitempty"`
+	BrandName         string            `json:"brand_name,omitempty"`
+	FastShipping      bool              `json:"fast_shipping,omitempty"`
+	ListingType       string            `json:"listing_type"`
+	Bids              int               `json:"bids"`
+	BestOffer         bool              `json:"best_offer,omitempty"` // seller accepts offers ("or Best Offer")
+	ItemsSold         int               `json:"items_sold,omitempty"`
+	Watchers          int               `json:"watchers,omitempty"`
+	SellerName        string            `json:"seller_name,omitempty"`
+	SellerRating      string            `json:"seller_rating,omitempty"`
+	VariationID       string            `json:"variation_id,omitempty"` // variation of a listing expanded with FollowVariations
+	Variation         map[string]string `json:"variation,omitempty"`    // selected values of the variation, e.g. Color: Red
+	RecentSales       *RecentSales      `json:"recent_sales,omitempty"` // "12 sold in the last 24 hours" of the detail page
+	ItemSpecifics     map[string]string `json:"item_specifics,omitempty"`
+	EAN               string            `json:"ean,omitempty"` // product identifiers of item specifics, without spaces and dashes
+	UPC               string            `json:"upc,omitempty"`
+	ISBN              string            `json:"isbn,omitempty"`
+	MPN               string            `json:"mpn,omitempty"`
+	Description       string            `json:"description,omitempty"`
+
+	//Index of the crawled page and position on it, orders items of the crawl result
+	page     int
+	position int
+
+	//Card lookups which found nothing while parsing
+	selectorMisses []selectorMiss
+}
+
+// Sales velocity the detail page shows, e.g. 12 in the period "24 hours"
+type RecentSales struct {
+	Count  int    `json:"count"`
+	Period string `json:"period"`
+}
+
+// Default pattern extracting item ID from product URL, the first group is the ID
+const DefaultItemIDPattern string = `itm\/([0-9]+)\?`
+
+// Space grouped amounts of regional sites ("1 299,00") first, spaces group only whole thousands
+var priceRegEx = regexp.MustCompile(`\d{1,3}(?:[ \x{00a0}\x{202f}]\d{3})+(?:[.,]\d+)?|\d[\d\.,]*\d|\d`)
+var itemIDRegEx = regexp.MustCompile(DefaultItemIDPattern)
+
+// Patterns tried in order when the item ID pattern doesn't match the product URL
+var fallbackItemIDRegExes = []*regexp.Regexp{
+	regexp.MustCompile(`itm\/(?:[^\/?#]+\/)?([0-9]+)(?:[\/?#]|$)`), // itm/<id> without query string, legacy itm/<slug>/<id>
+	regexp.MustCompile(`\/p\/([0-9]+)`),                            // product pages
+	regexp.MustCompile(`[?&](?:item|itemId)=([0-9]+)`),             // redirect links
+}
+
+// Prefix of item IDs derived from product URL hash when no pattern matches
+const hashedItemIDPrefix string = "url-"
+
+// Function extracts item ID from product URL trying known patterns in order.
+// When none matches, the ID is derived from hash of the URL, so the item is still written under a stable name
+func (c *Crawler) extractItemID(href string) string {
+	return extractItemID(c.itemIDRegEx(), href, c.logger())
+}
+
+// Function extracts item ID from product URL with the pattern, then with fallback patterns, then from URL hash
+func extractItemID(pattern *regexp.Regexp, href string, logger *slog.Logger) string {
+	for _, re := range append([]*regexp.Regexp{pattern}, fallbackItemIDRegExes...) {
+		matches := re.FindStringSubmatch(href)
+		if len(matches) >= 2 && matches[1] != "" {
+			return matches[1]
+		}
+	}
+
+	sum := sha256.Sum256([]byte(href))
+	itemID := hashedItemIDPrefix + hex.EncodeToString(sum[:8])
+	loggerOrDefault(logger).Debug("Item ID not found in product URL, using URL hash", "url", href, "item_id", itemID)
+
+	return itemID
+}
+
+// Function to get the store display name from the store page header
+func getStoreName(pageNode *html.Node) string {
+	storeNode := findFirstElementByAttr(pageNode, "h1", "class", "str-seller-card__store-name")
+	if storeNode == nil {
+		return ""
+	}
+
+	linkNode := findFirstElementByAttr(storeNode, "a", "href", "")
+	if linkNode != nil {
+		storeNode = linkNode
+	}
+
+	storeName, err := getElementNodeVal(storeNode)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(storeName)
+}
+
+// Function to get category of the results page from its breadcrumb (last step, the eBay home step alone is no category),
+// falling back to the category page header. Empty when absent
+func getPageCategory(pageNode *html.Node) string {
+	breadcrumbNode := findFirstElementByAnyAttr(pageNode, "nav", "class", []string{"breadcrumbs", "str-breadcrumb"})
+	if breadcrumbNode != nil {
+		steps := []string{}
+
+		var walk func(n *html.Node)
+		walk = func(n *html.Node) {
+			if n.Type == html.ElementNode && n.Data == "li" {
+				if text := strings.TrimSpace(getNodeText(n)); text != "" {
+					steps = append(steps, text)
+				}
+				return
+			}
+
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+		walk(breadcrumbNode)
+
+		if len(steps) > 1 {
+			return steps[len(steps)-1]
+		}
+	}
+
+	headerNode := findFirstElementByClass(pageNode, "h1", "b-pageheader__text")
+	if headerNode == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(getNodeText(headerNode))
+}
+
+// Function to get total number of results from the results header ("1,234 results"). Returns 0 when absent
+func parseResultCount(pageNode *html.Node) int {
+	count, _ := parseResultCountHeader(pageNode)
+
+	return count
+}
+
+// Function to get total number of results from the results header, reporting whether the header has a count
+func parseResultCountHeader(pageNode *html.Node) (int, bool) {
+	countNode := findFirstElementByAnyAttr(pageNode, "h1", "class", []string{"srp-controls__count-heading", "str-result-count"})
+	if countNode == nil {
+		return 0, false
+	}
+
+	number := leadingNumberRegEx.FindString(getNodeText(countNode))
+	count, err := strconv.Atoi(strings.ReplaceAll(number, ",", ""))
+	if err != nil {
+		return 0, false
+	}
+
+	return count, true
+}
+
+// Message eBay shows instead of results when nothing matches the search
+const noResultsText string = "No exact matches found"
+
+// Function checks if the page is a genuine empty search result, with "0 results" header or eBay no matches message,
+// as opposed to a blocked or changed page which has no items either
+func isNoResultsPage(pageNode *html.Node) bool {
+	count, found := parseResultCountHeader(pageNode)
+	if found {
+		return count == 0
+	}
+
+	headingNode := findFirstElementByAttr(pageNode, "h3", "class", "srp-save-null-search__heading")
+	if headingNode != nil {
+		return true
+	}
+
+	return hasCardMarker(getNodeText(pageNode), noResultsText)
+}
+
+// Function to process selected nodes (items) with the layout parser, applying listing options and filters.
+// Returns nil item when the item is filtered out
+func (c *Crawler) processItemNode(node *html.Node, storeName string, pageURL string) (*ItemInfo, error) {
+	parsed, err := c.itemParser().ParseItem(node)
+	c.reportParseFailures(&parsed, pageURL, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.finishItem(&parsed, storeName, pageURL)
+}
+
+// Function to apply listing options and filters to a parsed item (a card or a banner link): normalizes its condition,
+// product URL and title, applies affiliate parameters and sets the page it was found on. Returns nil item when the item
+// is filtered out
+func (c *Crawler) finishItem(item *ItemInfo, storeName string, pageURL string) (*ItemInfo, error) {
+	if c.NormalizeCondition && item.Condition != "" {
+		item.RawCondition = item.Condition
+		item.Condition = normalizeCondition(item.Condition)
+	}
+
+	productURL, err := normalizeProductURL(pageURL, item.ProductURL)
+	if err != nil {
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			parseErr.ItemID = item.ItemID
+		}
+		return nil, err
+	}
+	if productURL != item.ProductURL {
+		item.RawURL = item.ProductURL
+	}
+	item.ProductURL = productURL
+
+	if c.Affiliate != nil {
+		if item.RawURL == "" {
+			item.RawURL = item.ProductURL
+		}
+		item.ProductURL, err = c.Affiliate.Apply(item.ProductURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	title := item.Title
+	item.Title = cleanTitle(title)
+	if c.StripEmoji {
+		item.RawTitle = title
+		item.Title = stripEmoji(item.Title)
+	}
+	item.StoreName = storeName
+	item.SourceURL = pageURL
+
+	if c.SkipSponsored && item.IsSponsored {
+		return nil, nil
+	}
+
+	if c.FastShippingOnly && !item.FastShipping {
+		return nil, nil
+	}
+
+	if c.PriceFilter != nil && !c.PriceFilter.Keep(item) {
+		return nil, nil
+	}
+
+	if c.TitleFilter != nil && !c.TitleFilter.Keep(item) {
+		return nil, nil
+	}
+
+	if c.SellerFilter != nil && !c.SellerFilter.Keep(item) {
+		return nil, nil
+	}
+
+	return item, nil
+}
+
+// Function records on the item which card lookup found nothing, for Crawler.OnParseFailure, and logs it at debug level
+// when verbose is set
+func logSelectorMiss(logger *slog.Logger, verbose bool, item *ItemInfo, selector string, href string) {
+	item.selectorMisses = append(item.selectorMisses, selectorMiss{selector: selector, href: href})
+
+	if verbose {
+		loggerOrDefault(logger).Debug("Selector miss", "selector", selector, "url", href)
+	}
+}
+
+// Title of the template card eBay puts first in search results
+const placeholderItemTitle string = "Shop on eBay"
+
+// Function checks if item node is eBay template or ad card rather than a listing: it has no item link or the template title
+func isPlaceholderItem(node *html.Node, selectors ClassicSelectors) bool {
+	if findFirstElementByAttr(node, "a", "class", selectors.Link) == nil {
+		return true
+	}
+
+	titleDivNode := findFirstElementByClass(node, "div", selectors.Title)
+	if titleDivNode == nil {
+		return false
+	}
+
+	return strings.EqualFold(getNodeText(titleDivNode), placeholderItemTitle)
+}
+
+// Function to drop placeholder nodes from item list, returns remaining nodes and number of dropped ones
+func skipPlaceholderItems(itemElementList []*html.Node, selectors ClassicSelectors) ([]*html.Node, int) {
+	items := make([]*html.Node, 0, len(itemElementList))
+	for _, node := range itemElementList {
+		if !isPlaceholderItem(node, selectors) {
+			items = append(items, node)
+		}
+	}
+
+	return items, len(itemElementList) - len(items)
+}
+
+// Function to get product URLs of item nodes, skipping nodes without a link. Item link class is tried first,
+// then any item page link, so cards of every layout are covered
+func getItemURLs(itemElementList []*html.Node, linkClass string) []string {
+	itemURLs := []string{}
+	for _, node := range itemElementList {
+		itemLink := findFirstElementByAttr(node, "a", "class", linkClass)
+		if itemLink == nil {
+			itemLink = findFirstElementByAttr(node, "a", "href", "/itm/")
+		}
+		if itemLink == nil {
+			continue
+		}
+
+		href, err := getElementAttrByName(itemLink, "href")
+		if err != nil || href == "" {
+			continue
+		}
+
+		itemURLs = append(itemURLs, href)
+	}
+
+	return itemURLs
+}
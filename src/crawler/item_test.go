@@ -0,0 +1,109 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Header of a seller store page, above the store items
+const storeHeader = `<div class="str-seller-card"><h1 class="str-seller-card__store-name">` +
+	`<a href="https://www.ebay.com/str/garlandcomputer"> Garland Computer </a></h1></div>`
+
+func TestStoreName(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"store page", storeHeader, "Garland Computer"},
+		{"store name without link", `<h1 class="str-seller-card__store-name">Garland Computer</h1>`, "Garland Computer"},
+		{"search page", "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			search := newFixtureSearch(t, 2, func(w http.ResponseWriter, page int) bool {
+				fmt.Fprint(w, strings.Replace(fixtureResultsPage(2, []string{"101", "102"}, ""), "<body>", "<body>"+test.header, 1))
+				return true
+			})
+
+			c := &Crawler{Logger: discardLogger}
+			items, err := c.Crawl(context.Background(), search.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.Stats().StoreName != test.want {
+				t.Errorf("stats store name = %q, want %q", c.Stats().StoreName, test.want)
+			}
+			for _, item := range items {
+				if item.StoreName != test.want {
+					t.Errorf("item %s store name = %q, want %q", item.ItemID, item.StoreName, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractItemID(t *testing.T) {
+	tests := []struct {
+		name string
+		href string
+		want string
+	}{
+		{"default pattern", "https://www.ebay.com/itm/123456789012?hash=item1c", "123456789012"},
+		{"without query string", "https://www.ebay.com/itm/123456789012", "123456789012"},
+		{"legacy slug", "https://www.ebay.com/itm/Dell-Latitude-5490/123456789012?hash=item1c", "123456789012"},
+		{"fragment", "https://www.ebay.com/itm/123456789012#seeMore", "123456789012"},
+		{"product page", "https://www.ebay.com/p/987654321", "987654321"},
+		{"redirect link", "https://www.ebay.com/ulk/itm?item=123456789012&mkevt=1", "123456789012"},
+		{"redirect itemId", "https://rover.ebay.com/rover/1/711-53200-19255-0/1?itemId=123456789012", "123456789012"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := extractItemID(itemIDRegEx, test.href, discardLogger); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	//Unknown link shapes get a stable ID from the URL hash
+	const href = "https://www.ebay.com/sch/unknown-link"
+	itemID := extractItemID(itemIDRegEx, href, discardLogger)
+	if !strings.HasPrefix(itemID, hashedItemIDPrefix) || len(itemID) != len(hashedItemIDPrefix)+16 {
+		t.Errorf("got %q, want %s followed by 16 hex digits", itemID, hashedItemIDPrefix)
+	}
+	if again := extractItemID(itemIDRegEx, href, discardLogger); again != itemID {
+		t.Errorf("got %q for the same URL, want %q", again, itemID)
+	}
+	if other := extractItemID(itemIDRegEx, href+"/other", discardLogger); other == itemID {
+		t.Errorf("got the same ID %q for another URL", other)
+	}
+}
+
+func TestPlaceholderItemsSkipped(t *testing.T) {
+	search := newFixtureSearch(t, 2, func(w http.ResponseWriter, page int) bool {
+		//eBay template card with its title, then an ad without item link, then the real listings
+		placeholders := `<li class="s-item" id="item0"><a class="s-item__link" href="https://ebay.com/itm/123456"><div class="s-item__title"><span role="heading">Shop on eBay</span></div></a>` +
+			`<span class="s-item__price">$20.00</span></li><li class="s-item s-item--ad"><div class="s-item__title">Sponsored ad</div></li>`
+		fmt.Fprint(w, strings.Replace(fixtureResultsPage(2, []string{"101", "102"}, ""), "<ul>", "<ul>"+placeholders, 1))
+		return true
+	})
+
+	c := &Crawler{Logger: discardLogger}
+	items, err := c.Crawl(context.Background(), search.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(itemIDs(items), ","); got != "101,102" {
+		t.Errorf("got items %s, want 101,102", got)
+	}
+	if stats := c.Stats(); stats.ItemsFound != 2 || stats.Failures != 0 {
+		t.Errorf("got %d items and %d failures, want 2 items without failures", stats.ItemsFound, stats.Failures)
+	}
+}
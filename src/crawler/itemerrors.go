@@ -0,0 +1,46 @@
+package crawler
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Thread-safe collection of item processing results
+type itemErrors struct {
+	mu        sync.Mutex
+	processed int
+	errs      []error
+}
+
+// Function records processed item and its error, if any
+func (e *itemErrors) Add(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.processed++
+	if err != nil {
+		e.errs = append(e.errs, err)
+	}
+}
+
+// Function returns number of processed and failed items
+func (e *itemErrors) Counts() (int, int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.processed, len(e.errs)
+}
+
+// Function logs number of processed and failed items, first line of each failure reason is logged at debug level
+func (e *itemErrors) LogSummary(logger *slog.Logger) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	logger.Info("Processed items", "processed", e.processed, "failed", len(e.errs))
+
+	for _, err := range e.errs {
+		reason, _, _ := strings.Cut(err.Error(), "\n")
+		logger.Debug("Item failed", "reason", reason)
+	}
+}
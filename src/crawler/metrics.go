@@ -0,0 +1,22 @@
+package crawler
+
+import "sync/atomic"
+
+// Live counters of a crawl, safe to read while the crawl is running
+type Metrics struct {
+	PagesFetched    atomic.Int64
+	ItemsParsed     atomic.Int64
+	ItemsFailed     atomic.Int64
+	HTTPErrors      atomic.Int64 // failed requests and error responses, retries included
+	BytesDownloaded atomic.Int64 // decoded response bodies
+	CurrentPage     atomic.Int64 // number of the page being crawled, starting with 1
+}
+
+// Function returns configured metrics or private ones nobody reads
+func (c *Crawler) metrics() *Metrics {
+	if c.Metrics == nil {
+		return &c.unusedMetrics
+	}
+
+	return c.Metrics
+}
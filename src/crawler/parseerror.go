@@ -0,0 +1,37 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Causes of item card parse failures, matched with errors.Is
+var (
+	ErrLinkNotFound      = errors.New("item link not found")
+	ErrPriceNotFound     = errors.New("price not found")
+	ErrTitleNotFound     = errors.New("title not found")
+	ErrConditionNotFound = errors.New("condition not found")
+	ErrInvalidURL        = errors.New("product URL is invalid")
+)
+
+// Item card field which failed to parse, matched with errors.As. Error gives the human-readable message,
+// errors.Is matches the cause
+type ParseError struct {
+	Field   string // link, price, title, condition or url
+	ItemID  string // empty when the failure comes before the item link is read
+	Message string
+	Err     error // one of the Err* causes
+}
+
+func (e *ParseError) Error() string {
+	return e.Message
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Function returns parse error of the card field with the cause and formatted message
+func newParseError(cause error, field string, itemID string, format string, args ...any) *ParseError {
+	return &ParseError{Field: field, ItemID: itemID, Message: fmt.Sprintf(format, args...), Err: cause}
+}
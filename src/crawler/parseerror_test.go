@@ -0,0 +1,155 @@
+package crawler
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// Function parses HTML fixture and returns its first li node of the class
+func parseFixtureItem(t testing.TB, fixture string, class string) *html.Node {
+	t.Helper()
+
+	pageNode, err := html.Parse(strings.NewReader(fixture))
+	if err != nil {
+		t.Fatalf("can't parse fixture: %s", err)
+	}
+
+	nodes := findItemElementsByClass(pageNode, "li", class, []*html.Node{})
+	if len(nodes) == 0 {
+		t.Fatalf("fixture has no li.%s", class)
+	}
+
+	return nodes[0]
+}
+
+func TestClassicParserParseError(t *testing.T) {
+	const link = `<a class="s-item__link" href="https://www.ebay.com/itm/123456">`
+	const title = `<div class="s-item__title"><span role="heading">Dell Laptop</span></div>`
+	const price = `<span class="s-item__price">$10.00</span>`
+
+	tests := []struct {
+		name   string
+		item   string
+		cause  error
+		field  string
+		itemID string
+	}{
+		{"no link", `<li class="s-item" id="item1">` + title + price + `</li>`, ErrLinkNotFound, "link", ""},
+		{"no href", `<li class="s-item" id="item1"><a class="s-item__link">` + title + `</a>` + price + `</li>`, ErrLinkNotFound, "link", ""},
+		{"no price", `<li class="s-item" id="item1">` + link + title + `</a></li>`, ErrPriceNotFound, "price", "123456"},
+		{"empty price", `<li class="s-item" id="item1">` + link + title + `</a><span class="s-item__price"></span></li>`, ErrPriceNotFound, "price", "123456"},
+		{"no title div", `<li class="s-item" id="item1">` + link + `</a>` + price + `</li>`, ErrTitleNotFound, "title", "123456"},
+		{"no title span", `<li class="s-item" id="item1">` + link + `<div class="s-item__title">Dell</div></a>` + price + `</li>`, ErrTitleNotFound, "title", "123456"},
+		{"no condition span", `<li class="s-item" id="item1">` + link + title + `</a><div class="s-item__subtitle">Dell</div>` + price + `</li>`, ErrConditionNotFound, "condition", "123456"},
+	}
+
+	parser := &EbayClassicParser{Logger: discardLogger}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parser.ParseItem(parseFixtureItem(t, "<ul>"+test.item+"</ul>", "s-item"))
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("error %v is not a *ParseError", err)
+			}
+			if !errors.Is(err, test.cause) {
+				t.Errorf("error %v doesn't match cause %v", err, test.cause)
+			}
+			if parseErr.Field != test.field {
+				t.Errorf("field = %q, want %q", parseErr.Field, test.field)
+			}
+			if parseErr.ItemID != test.itemID {
+				t.Errorf("item ID = %q, want %q", parseErr.ItemID, test.itemID)
+			}
+			if !strings.HasPrefix(err.Error(), "ERROR::") {
+				t.Errorf("message %q has no ERROR:: prefix", err.Error())
+			}
+		})
+	}
+}
+
+func TestCardParserParseError(t *testing.T) {
+	const link = `<a class="su-link" href="https://www.ebay.com/itm/555">`
+	const title = `<div class="s-card__title"><span class="su-styled-text">ThinkPad X220</span></div>`
+	const price = `<span class="s-card__price">$120.00</span>`
+
+	tests := []struct {
+		name   string
+		item   string
+		cause  error
+		field  string
+		itemID string
+	}{
+		{"no link", `<li class="s-card" id="item1">` + title + price + `</li>`, ErrLinkNotFound, "link", ""},
+		{"no price", `<li class="s-card" id="item1">` + link + title + `</a></li>`, ErrPriceNotFound, "price", "555"},
+		{"empty price", `<li class="s-card" id="item1">` + link + title + `</a><span class="s-card__price"></span></li>`, ErrPriceNotFound, "price", "555"},
+		{"no title", `<li class="s-card" id="item1">` + link + `</a>` + price + `</li>`, ErrTitleNotFound, "title", "555"},
+	}
+
+	parser := &EbayCardParser{Logger: discardLogger}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parser.ParseItem(parseFixtureItem(t, "<ul>"+test.item+"</ul>", "s-card"))
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("error %v is not a *ParseError", err)
+			}
+			if !errors.Is(err, test.cause) {
+				t.Errorf("error %v doesn't match cause %v", err, test.cause)
+			}
+			if parseErr.Field != test.field {
+				t.Errorf("field = %q, want %q", parseErr.Field, test.field)
+			}
+			if parseErr.ItemID != test.itemID {
+				t.Errorf("item ID = %q, want %q", parseErr.ItemID, test.itemID)
+			}
+		})
+	}
+}
+
+func TestNormalizeProductURLParseError(t *testing.T) {
+	tests := []struct {
+		name string
+		href string
+	}{
+		{"unparseable", "http://[::1"},
+		{"not http", "javascript:void(0)"},
+		{"relative without page", "/itm/123"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := normalizeProductURL("", test.href)
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("error %v is not a *ParseError", err)
+			}
+			if !errors.Is(err, ErrInvalidURL) || parseErr.Field != "url" {
+				t.Errorf("got cause %v field %q, want ErrInvalidURL field url", parseErr.Err, parseErr.Field)
+			}
+		})
+	}
+}
+
+func TestParseItemUnparseablePriceKept(t *testing.T) {
+	node := parseFixtureItem(t, `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/123456">`+
+		`<div class="s-item__title"><span role="heading">Dell Laptop</span></div></a>`+
+		`<span class="s-item__price">See price</span></li></ul>`, "s-item")
+
+	item, err := (&EbayClassicParser{Logger: discardLogger}).ParseItem(node)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if item.Price != "See price" || item.PriceCents != -1 {
+		t.Errorf("price = %q (%d cents), want displayed price without cents", item.Price, item.PriceCents)
+	}
+}
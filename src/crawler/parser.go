@@ -0,0 +1,201 @@
+package crawler
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Parser of item cards of a results page layout. Listing options (condition normalization, affiliate
+// parameters, title cleanup) and filters are applied by the crawler to parsed items
+type ItemParser interface {
+	// Returns item card nodes of the page, template and ad cards excluded
+	FindItems(pageNode *html.Node) []*html.Node
+	// Returns listing data of the card, the title is kept as displayed
+	ParseItem(node *html.Node) (ItemInfo, error)
+}
+
+// Parser of the classic results layout: li.s-item cards with s-item__* elements
+type EbayClassicParser struct {
+	Selectors    ClassicSelectors // class names of card elements, empty ones fall back to DefaultClassicSelectors
+	PriceClasses []string         // price span classes to try in order, DefaultPriceClasses when empty
+	ItemIDRegEx  *regexp.Regexp   // pattern extracting item ID from product URL, DefaultItemIDPattern when nil
+	Logger       *slog.Logger     // logger for diagnostics, slog.Default() when nil
+	Verbose      bool             // log at debug level each item card lookup which found nothing
+	// Decimal separator of prices of the crawled site, DomainDecimalSeparator. Empty guesses it from each amount
+	DecimalSeparator string
+}
+
+func (p *EbayClassicParser) FindItems(pageNode *html.Node) []*html.Node {
+	selectors := p.Selectors.withDefaults()
+	itemElementList := findItemElementsByClass(pageNode, "li", selectors.Item, []*html.Node{})
+
+	itemElementList, placeholders := skipPlaceholderItems(itemElementList, selectors)
+	if placeholders > 0 {
+		loggerOrDefault(p.Logger).Debug("Skipped placeholder items", "items", placeholders)
+	}
+
+	return itemElementList
+}
+
+func (p *EbayClassicParser) ParseItem(node *html.Node) (ItemInfo, error) {
+	item := ItemInfo{}
+	selectors := p.Selectors.withDefaults()
+
+	itemLink := findFirstElementByAttr(node, "a", "class", selectors.Link)
+	if itemLink == nil {
+		p.logSelectorMiss(&item, "a."+selectors.Link, "")
+		return item, newParseError(ErrLinkNotFound, "link", "", "ERROR::Item link node not found")
+	}
+
+	href, err := getElementAttrByName(itemLink, "href")
+	if err != nil {
+		p.logSelectorMiss(&item, "a."+selectors.Link+"[href]", "")
+		return item, newParseError(ErrLinkNotFound, "link", "", "ERROR::%s", err)
+	}
+
+	itemID := extractItemID(patternOrDefault(p.ItemIDRegEx), href, p.Logger)
+
+	priceClasses := priceClassesOrDefault(p.PriceClasses)
+	priceNode := findFirstElementByAnyClass(node, "span", priceClasses)
+	if priceNode == nil {
+		p.logSelectorMiss(&item, "span."+strings.Join(priceClasses, "|"), href)
+		return item, newParseError(ErrPriceNotFound, "price", itemID, "ERROR::Price node not found")
+	}
+
+	price, err := getElementNodeVal(priceNode)
+	if err != nil {
+		p.logSelectorMiss(&item, "price text", href)
+		return item, newParseError(ErrPriceNotFound, "price", itemID, "ERROR::Price value not found\n%s", err)
+	}
+
+	titleDivNode := findFirstElementByClass(node, "div", selectors.Title)
+	if titleDivNode == nil {
+		p.logSelectorMiss(&item, "div."+selectors.Title, href)
+		return item, newParseError(ErrTitleNotFound, "title", itemID, "ERROR::Title DIV node not found")
+	}
+	titleNode := findFirstElementByAttr(titleDivNode, "span", "role", "heading")
+	if titleNode == nil {
+		p.logSelectorMiss(&item, "div."+selectors.Title+` span[role="heading"]`, href)
+		return item, newParseError(ErrTitleNotFound, "title", itemID, "ERROR::Title SPAN node not found")
+	}
+
+	title, err := getTitleText(titleNode)
+	if err != nil {
+		p.logSelectorMiss(&item, "title text", href)
+		return item, newParseError(ErrTitleNotFound, "title", itemID, "ERROR::Title value not found\n%s", err)
+	}
+
+	condition := ""
+	subtitle := ""
+
+	subtitleNode := findFirstElementByAttr(node, "div", "class", selectors.Subtitle)
+	if subtitleNode == nil {
+		loggerOrDefault(p.Logger).Debug("Condition DIV node not found", "item_id", itemID)
+		p.logSelectorMiss(&item, "div."+selectors.Subtitle, href)
+	} else {
+		subtitle = getNodeText(subtitleNode)
+
+		conditionNode := findFirstElementByAttr(subtitleNode, "span", "class", selectors.Condition)
+		if conditionNode == nil {
+			p.logSelectorMiss(&item, "div."+selectors.Subtitle+" span."+selectors.Condition, href)
+			return item, newParseError(ErrConditionNotFound, "condition", itemID, "ERROR::Condition SPAN node not found")
+		}
+
+		condition, err = getElementNodeVal(conditionNode)
+		if err != nil {
+			p.logSelectorMiss(&item, "condition text", href)
+			return item, newParseError(ErrConditionNotFound, "condition", itemID, "ERROR::Condition value not found\n%s", err)
+		}
+	}
+
+	item.ItemID = itemID
+	item.ProductURL = href
+	item.Title = title
+	item.Condition = condition
+	item.Subtitle = subtitle
+	setItemPrice(&item, price, p.DecimalSeparator, p.Logger)
+	item.OriginalPrice, item.DiscountPercent = parseOriginalPrice(node, item.Price, p.DecimalSeparator)
+	setCardDetails(&item, node)
+	item.BrandOutlet, item.BrandName = parseBrandOutlet(node)
+	item.FastShipping = parseFastShipping(node)
+	item.Shipping, item.ShippingRaw = parseShipping(node, p.DecimalSeparator)
+	item.Location = parseLocation(node)
+	item.ListingType, item.Bids = parseListingType(node)
+	item.SellerName, item.SellerRating = parseSellerInfo(node)
+	item.ItemsSold, item.Watchers = parsePopularity(node)
+
+	return item, nil
+}
+
+func (p *EbayClassicParser) logSelectorMiss(item *ItemInfo, selector string, href string) {
+	logSelectorMiss(p.Logger, p.Verbose, item, selector, href)
+}
+
+// Function sets displayed price of item with its parsed amount and currency.
+// Unparseable amount is kept as displayed, so the item isn't lost
+func setItemPrice(item *ItemInfo, price string, decimalSeparator string, logger *slog.Logger) {
+	item.Price = price
+	item.PriceCents = -1
+
+	priceMin, priceMax, currency, err := parsePriceRange(price, decimalSeparator)
+	if err != nil {
+		loggerOrDefault(logger).Debug("Price amount not parsed, keeping displayed price", "url", item.ProductURL, "err", err)
+		return
+	}
+
+	item.Price = priceMin
+	item.PriceCents = priceCents(priceMin)
+	item.Currency = currency
+	item.PriceMin = priceMin
+	item.PriceMax = priceMax
+}
+
+// Function sets item fields read from card text and image, which don't depend on the layout
+func setCardDetails(item *ItemInfo, node *html.Node) {
+	cardText := getNodeText(node)
+
+	if saleEndsAt := parseSaleEndsAt(cardText, time.Now()); !saleEndsAt.IsZero() {
+		item.SaleEndsAt = &saleEndsAt
+	}
+	//Labels are matched in the bid and purchase option rows only, titles may contain the same words
+	rowsText := getAttributeRowsText(node)
+	//Refurbishment badge is the condition of the subtitle or a badge of the attribute rows
+	item.RefurbGrade = parseRefurbGrade(item.Subtitle + "\n" + rowsText)
+	item.ReserveNotMet = hasCardMarker(rowsText, "Reserve not met")
+	item.BestOffer = hasCardMarker(rowsText, "Best Offer")
+	item.QuantityAvailable = parseQuantityAvailable(rowsText)
+	item.ImageURL = parseImageURL(node)
+	item.IsSponsored = isSponsored(node)
+	item.Category = parseCategory(node)
+}
+
+// Function returns logger or the default one when nil
+func loggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+
+	return logger
+}
+
+// Function returns item ID pattern or the default one when nil
+func patternOrDefault(pattern *regexp.Regexp) *regexp.Regexp {
+	if pattern == nil {
+		return itemIDRegEx
+	}
+
+	return pattern
+}
+
+// Function returns price classes or the defaults when empty
+func priceClassesOrDefault(classes []string) []string {
+	if len(classes) == 0 {
+		return DefaultPriceClasses
+	}
+
+	return classes
+}
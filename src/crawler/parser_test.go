@@ -0,0 +1,271 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// Results page of 240 classic item cards with the details a real search shows: condition, list and original price,
+// shipping, location, bids, seller and popularity
+func benchmarkResultsPage() string {
+	page := strings.Builder{}
+	page.WriteString(`<html><body><h1 class="srp-controls__count-heading">1,200 results</h1><ul class="srp-results srp-list">`)
+	for i := 1; i <= 240; i++ {
+		fmt.Fprintf(&page, `<li class="s-item s-item__pl-on-bottom" id="item%[1]d" data-viewport="{}">`+
+			`<div class="s-item__wrapper clearfix"><div class="s-item__image-section"><div class="s-item__image">`+
+			`<a href="https://www.ebay.com/itm/%[1]d?hash=item%[1]d&amdata=enc"><div class="s-item__image-wrapper image-treatment">`+
+			`<img src="https://i.ebayimg.com/images/g/%[1]d/s-l500.webp" alt="Dell Latitude %[1]d"></div></a></div></div>`+
+			`<div class="s-item__info clearfix"><a class="s-item__link" href="https://www.ebay.com/itm/%[1]d?hash=item%[1]d&amdata=enc">`+
+			`<div class="s-item__title"><span role="heading" aria-level="3"><span class="LIGHT_HIGHLIGHT">New Listing</span>Dell Latitude 7490 Laptop i5 16GB %[1]d</span></div>`+
+			`<span class="clipped">Opens in a new window or tab</span></a>`+
+			`<div class="s-item__subtitle"><span class="SECONDARY_INFO">Pre-Owned</span> · Dell · 14 in</div>`+
+			`<div class="s-item__details clearfix"><div class="s-item__detail s-item__detail--primary"><span class="s-item__price">$%[1]d.99</span></div>`+
+			`<div class="s-item__detail s-item__detail--primary"><span class="s-item__additionalPrice"><span class="STRIKETHROUGH">$%[2]d.00</span></span></div>`+
+			`<div class="s-item__detail s-item__detail--primary"><span class="s-item__bids s-item__bidCount">%[3]d bids</span> · <span class="s-item__time-left">2d 4h</span></div>`+
+			`<div class="s-item__detail s-item__detail--primary"><span class="s-item__shipping s-item__logisticsCost">+$12.50 shipping</span></div>`+
+			`<div class="s-item__detail s-item__detail--primary"><span class="s-item__location s-item__itemLocation">from United States</span></div>`+
+			`<div class="s-item__detail s-item__detail--primary"><span class="s-item__hotness s-item__itemHotness"><span class="BOLD">%[3]d sold</span></span></div>`+
+			`<div class="s-item__detail s-item__detail--primary"><span class="s-item__dynamic s-item__watchCountTotal">%[1]d watchers</span></div>`+
+			`</div><div class="s-item__seller-info"><span class="s-item__seller-info-text">laptop_outlet (12,345) 99.1%%</span></div>`+
+			`</div></div></li>`, i, i+50, i%7)
+	}
+	page.WriteString(`</ul><a class="pagination__next icon-link" href="https://www.ebay.com/sch/i.html?_nkw=laptop&_pgn=2">next</a></body></html>`)
+
+	return page.String()
+}
+
+// Function parses the benchmark results page
+func parseBenchmarkPage(b *testing.B) *html.Node {
+	b.Helper()
+
+	pageNode, err := html.Parse(strings.NewReader(benchmarkResultsPage()))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return pageNode
+}
+
+func BenchmarkFindItems(b *testing.B) {
+	pageNode := parseBenchmarkPage(b)
+	parser := &EbayClassicParser{Logger: discardLogger}
+	if nodes := parser.FindItems(pageNode); len(nodes) != 240 {
+		b.Fatalf("found %d items, want 240", len(nodes))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		parser.FindItems(pageNode)
+	}
+}
+
+func BenchmarkParseItem(b *testing.B) {
+	parser := &EbayClassicParser{Logger: discardLogger}
+	nodes := parser.FindItems(parseBenchmarkPage(b))
+
+	//Each op parses every card of the page
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, node := range nodes {
+			if _, err := parser.ParseItem(node); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// Function returns classic auction card with the bid row and the title
+func auctionCard(title string, bidRow string) string {
+	return `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">` +
+		`<div class="s-item__title"><span role="heading">` + title + `</span></div></a><span class="s-item__price">$120.00</span>` +
+		`<span class="s-item__bids s-item__bidCount">` + bidRow + `</span></li></ul>`
+}
+
+func TestReserveNotMet(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser ItemParser
+		card   string
+		class  string
+		want   bool
+	}{
+		{"reserve not met", &EbayClassicParser{}, auctionCard("ThinkPad X220", "3 bids · Reserve not met"), "s-item", true},
+		{"reserve met", &EbayClassicParser{}, auctionCard("ThinkPad X220", "3 bids"), "s-item", false},
+		{"marker in title", &EbayClassicParser{}, auctionCard("ThinkPad X220 Relisted, Reserve Not Met Before", "3 bids"), "s-item", false},
+		{"cards layout", &EbayCardParser{}, `<ul><li class="s-card" id="item1"><a class="su-link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-card__title"><span class="su-styled-text">ThinkPad X220</span></div></a><span class="s-card__price">$120.00</span>` +
+			`<div class="s-card__attribute-row"><span>5 bids</span> · <span>RESERVE NOT MET</span></div></li></ul>`, "s-card", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, err := test.parser.ParseItem(parseFixtureItem(t, test.card, test.class))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.ReserveNotMet != test.want {
+				t.Errorf("ReserveNotMet = %t, want %t", item.ReserveNotMet, test.want)
+			}
+		})
+	}
+}
+
+func TestBestOffer(t *testing.T) {
+	const link = `<a class="s-item__link" href="https://www.ebay.com/itm/555">`
+	const price = `<span class="s-item__price">$120.00</span>`
+
+	tests := []struct {
+		name string
+		card string
+		want bool
+	}{
+		{"or Best Offer", `<li class="s-item" id="item1">` + link + `<div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a>` + price +
+			`<span class="s-item__dynamic s-item__purchaseOptionsWithIcon">or Best Offer</span></li>`, true},
+		{"purchase options", `<li class="s-item" id="item1">` + link + `<div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a>` + price +
+			`<span class="s-item__purchase-options">Buy It Now or best offer</span></li>`, true},
+		{"without label", `<li class="s-item" id="item1">` + link + `<div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a>` + price +
+			`<span class="s-item__purchase-options">Buy It Now</span></li>`, false},
+		{"label in title", `<li class="s-item" id="item1">` + link + `<div class="s-item__title"><span role="heading">ThinkPad X220 Best Offer Bundle</span></div></a>` + price + `</li>`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, err := (&EbayClassicParser{}).ParseItem(parseFixtureItem(t, "<ul>"+test.card+"</ul>", "s-item"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.BestOffer != test.want {
+				t.Errorf("BestOffer = %t, want %t", item.BestOffer, test.want)
+			}
+		})
+	}
+}
+
+func TestPriceClasses(t *testing.T) {
+	const link = `<a class="s-item__link" href="https://www.ebay.com/itm/555"><div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a>`
+
+	tests := []struct {
+		name    string
+		classes []string
+		prices  string
+		want    string
+	}{
+		{"alternate class", nil, `<span class="s-item__detail-price">$120.00</span>`, "120.00"},
+		{"current price class", nil, `<div><span class="s-item__price-current bold">$99.50</span></div>`, "99.50"},
+		{"preferred class first", nil, `<span class="s-item__price-current">$99.50</span><span class="s-item__price">$120.00</span>`, "120.00"},
+		{"original price isn't the price", nil, `<span class="s-item__price--original">$150.00</span><span class="s-item__detail-price">$120.00</span>`, "120.00"},
+		{"configured classes", []string{"x-price-primary", "x-price"}, `<span class="x-price">$80.00</span><span class="s-item__price">$120.00</span>`, "80.00"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := parseFixtureItem(t, `<ul><li class="s-item" id="item1">`+link+test.prices+`</li></ul>`, "s-item")
+			item, err := (&EbayClassicParser{PriceClasses: test.classes}).ParseItem(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.Price != test.want {
+				t.Errorf("price = %q, want %q", item.Price, test.want)
+			}
+		})
+	}
+
+	//None of the classes matches
+	node := parseFixtureItem(t, `<ul><li class="s-item" id="item1">`+link+`<span class="s-item__price">$120.00</span></li></ul>`, "s-item")
+	_, err := (&EbayClassicParser{PriceClasses: []string{"x-price"}}).ParseItem(node)
+	if !errors.Is(err, ErrPriceNotFound) {
+		t.Errorf("got %v, want ErrPriceNotFound", err)
+	}
+}
+
+func TestPriceRange(t *testing.T) {
+	const link = `<a class="s-item__link" href="https://www.ebay.com/itm/555"><div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a>`
+
+	tests := []struct {
+		name                        string
+		price                       string
+		wantPrice, wantMin, wantMax string
+	}{
+		{"single", "$120.00", "120.00", "120.00", "120.00"},
+		{"range", "$10.00 to $25.00", "10.00", "10.00", "25.00"},
+		{"range with grouping", "US $999.00 to US $1,299.00", "999.00", "999.00", "1299.00"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := parseFixtureItem(t, `<ul><li class="s-item" id="item1">`+link+`<span class="s-item__price">`+test.price+`</span></li></ul>`, "s-item")
+			item, err := (&EbayClassicParser{}).ParseItem(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.Price != test.wantPrice || item.PriceMin != test.wantMin || item.PriceMax != test.wantMax || item.Currency != "USD" {
+				t.Errorf("price = %q, range %q-%q %s, want %q, range %q-%q USD", item.Price, item.PriceMin, item.PriceMax, item.Currency, test.wantPrice, test.wantMin, test.wantMax)
+			}
+		})
+	}
+}
+
+func TestListingType(t *testing.T) {
+	tests := []struct {
+		name     string
+		card     string
+		wantType string
+		wantBids int
+	}{
+		{"auction", auctionCard("ThinkPad X220", "3 bids"), "auction", 3},
+		{"auction with grouping", auctionCard("ThinkPad X220", "1,204 bids · 2d 4h"), "auction", 1204},
+		{"auction without count", auctionCard("ThinkPad X220", "Place bid"), "auction", 0},
+		{"fixed price", `<ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/555">` +
+			`<div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a><span class="s-item__price">$120.00</span>` +
+			`<span class="s-item__purchase-options">Buy It Now</span></li></ul>`, "fixed", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, err := (&EbayClassicParser{}).ParseItem(parseFixtureItem(t, test.card, "s-item"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.ListingType != test.wantType || item.Bids != test.wantBids {
+				t.Errorf("got %s with %d bids, want %s with %d bids", item.ListingType, item.Bids, test.wantType, test.wantBids)
+			}
+		})
+	}
+}
+
+func TestOverriddenSelectors(t *testing.T) {
+	//Renamed classes of every element the selectors cover
+	const page = `<html><body><ul><li class="x-card" id="item1"><a class="x-card__link" href="https://www.ebay.com/itm/555">` +
+		`<div class="x-card__title"><span role="heading">ThinkPad X220</span></div></a>` +
+		`<div class="x-card__subtitle"><span class="x-condition">Pre-Owned</span></div><span class="x-card__price">$120.00</span></li>` +
+		`<li class="s-item" id="item2"><a class="s-item__link" href="https://www.ebay.com/itm/556">` +
+		`<div class="s-item__title"><span role="heading">Default card</span></div></a><span class="s-item__price">$99.00</span></li></ul></body></html>`
+
+	pageNode, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser := &EbayClassicParser{
+		Selectors:    ClassicSelectors{Item: "x-card", Link: "x-card__link", Title: "x-card__title", Subtitle: "x-card__subtitle", Condition: "x-condition"},
+		PriceClasses: []string{"x-card__price"},
+	}
+	nodes := parser.FindItems(pageNode)
+	if len(nodes) != 1 {
+		t.Fatalf("found %d items, want the x-card one", len(nodes))
+	}
+
+	item, err := parser.ParseItem(nodes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.ItemID != "555" || item.Title != "ThinkPad X220" || item.Price != "120.00" || item.Condition != "Pre-Owned" {
+		t.Errorf("got item %s %q %s %q, want 555 ThinkPad X220 120.00 Pre-Owned", item.ItemID, item.Title, item.Price, item.Condition)
+	}
+}
@@ -0,0 +1,194 @@
+package crawler
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Currency codes by symbols and prefixes eBay shows before the amount
+var currencySymbols = map[string]string{
+	"$":    "USD",
+	"US $": "USD",
+	"US$":  "USD",
+	"USD":  "USD",
+	"C $":  "CAD",
+	"C$":   "CAD",
+	"CAD":  "CAD",
+	"AU $": "AUD",
+	"AU$":  "AUD",
+	"AUD":  "AUD",
+	"£":    "GBP",
+	"GBP":  "GBP",
+	"€":    "EUR",
+	"EUR":  "EUR",
+	"CHF":  "CHF",
+	"ZŁ":   "PLN",
+	"PLN":  "PLN",
+	"S$":   "SGD",
+	"HK$":  "HKD",
+	"RM":   "MYR",
+	"PHP":  "PHP",
+}
+
+// Function parses displayed price into normalized decimal amount (dot separator, no grouping) and currency code.
+// decimalSeparator is the one of the crawled site (DomainDecimalSeparator), empty guesses it from the amount
+func parsePrice(raw string, decimalSeparator string) (string, string, error) {
+	loc := priceRegEx.FindStringIndex(raw)
+	if loc == nil {
+		return "", "", fmt.Errorf("ERROR::Price value %q cannot be parsed", raw)
+	}
+
+	amount := normalizeAmount(raw[loc[0]:loc[1]], decimalSeparator)
+
+	//Regional sites may show the currency after the amount ("12,50 EUR", "1.299,00 €")
+	currency := ""
+	symbol := strings.TrimSpace(raw[:loc[0]])
+	if symbol == "" {
+		suffix, _, _ := strings.Cut(strings.TrimSpace(raw[loc[1]:]), " ")
+		if _, ok := currencySymbols[strings.ToUpper(suffix)]; ok {
+			symbol = suffix
+		}
+	}
+	if symbol != "" {
+		code, ok := currencySymbols[strings.ToUpper(symbol)]
+		if !ok {
+			code = strings.ToUpper(symbol)
+		}
+		currency = code
+	}
+
+	return amount, currency, nil
+}
+
+// Function parses displayed price which may be a range ("$10.00 to $25.00"). Single price gives equal min and max
+func parsePriceRange(raw string, decimalSeparator string) (string, string, string, error) {
+	lowRaw, highRaw, isRange := strings.Cut(raw, " to ")
+
+	priceMin, currency, err := parsePrice(lowRaw, decimalSeparator)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if !isRange {
+		return priceMin, priceMin, currency, nil
+	}
+
+	priceMax, maxCurrency, err := parsePrice(highRaw, decimalSeparator)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if currency == "" {
+		currency = maxCurrency
+	}
+
+	return priceMin, priceMax, currency, nil
+}
+
+// Replacer removing grouping separators, including spaces of regional sites ("1 299,00 €"), built once
+// as building it on each call is costly
+var groupingReplacer = strings.NewReplacer(",", "", ".", "", " ", "", "\u00a0", "", "\u202f", "")
+
+// Function converts amount with grouping and decimal separators into plain decimal with dot. With the decimal
+// separator of the site known the other one is grouping, so "1.299" of ebay.de is 1299. Otherwise it is guessed from
+// the amount, which takes a single "1.299" for a decimal
+func normalizeAmount(amount string, decimalSeparator string) string {
+	//A repeated separator is grouping ("1.299.000"), whichever site the amount is from
+	if decimalSeparator == "" || strings.Count(amount, decimalSeparator) > 1 {
+		decimalSeparator = guessDecimalSeparator(amount)
+	}
+
+	integerPart := amount
+	fractionPart := ""
+	if separatorIndex := strings.LastIndex(amount, decimalSeparator); decimalSeparator != "" && separatorIndex != -1 {
+		integerPart = amount[:separatorIndex]
+		fractionPart = amount[separatorIndex+1:]
+	}
+
+	integerPart = groupingReplacer.Replace(integerPart)
+	if fractionPart == "" {
+		return integerPart
+	}
+
+	return integerPart + "." + fractionPart
+}
+
+// Function guesses decimal separator of amount, empty when it has none
+func guessDecimalSeparator(amount string) string {
+	lastComma := strings.LastIndex(amount, ",")
+	lastDot := strings.LastIndex(amount, ".")
+
+	switch {
+	case lastComma != -1 && lastDot != -1:
+		//Both present: the last one is decimal separator ("1,299.00" or "1.299,00")
+		if lastComma > lastDot {
+			return ","
+		}
+		return "."
+	case lastComma != -1:
+		//Only comma: decimal when it is single and followed by 1-2 digits ("10,50"), grouping otherwise ("1,299")
+		if strings.Count(amount, ",") == 1 && len(amount)-lastComma-1 <= 2 {
+			return ","
+		}
+	case lastDot != -1:
+		//Only dot: decimal unless it is repeated ("1.299.000")
+		if strings.Count(amount, ".") == 1 {
+			return "."
+		}
+	}
+
+	return ""
+}
+
+// Function converts normalized decimal amount into integer cents, rounding further fraction digits half up.
+// Returns -1 when the amount can't be parsed
+func priceCents(amount string) int64 {
+	integerPart, fractionPart, _ := strings.Cut(amount, ".")
+
+	units, err := strconv.ParseInt(integerPart, 10, 64)
+	if err != nil || units < 0 {
+		return -1
+	}
+
+	fraction := (fractionPart + "000")[:3]
+	thousandths, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil || strings.TrimLeft(fractionPart, "0123456789") != "" {
+		return -1
+	}
+
+	return units*100 + (thousandths+5)/10
+}
+
+// Function to get struck-through original price of a discounted item and the percentage saved. Empty and zero when absent
+func parseOriginalPrice(node *html.Node, price string, decimalSeparator string) (string, float64) {
+	originalNode := findFirstElementByAnyAttr(node, "span", "class", []string{"s-item__original-price", "s-item__trending-price"})
+	if originalNode == nil {
+		return "", 0
+	}
+
+	originalPrice, _, err := parsePrice(getNodeText(originalNode), decimalSeparator)
+	if err != nil {
+		return "", 0
+	}
+
+	return originalPrice, discountPercent(originalPrice, price)
+}
+
+// Function computes percentage saved against the original price, rounded to one decimal. Zero when there is no saving
+func discountPercent(originalPrice string, price string) float64 {
+	original, err := strconv.ParseFloat(originalPrice, 64)
+	if err != nil || original <= 0 {
+		return 0
+	}
+
+	current, err := strconv.ParseFloat(price, 64)
+	if err != nil || current >= original {
+		return 0
+	}
+
+	return math.Round((original-current)/original*1000) / 10
+}
@@ -0,0 +1,159 @@
+package crawler
+
+import "testing"
+
+func TestNormalizeAmount(t *testing.T) {
+	tests := []struct {
+		amount, decimalSeparator, want string
+	}{
+		{"1.299", ",", "1299"},
+		{"1.299,00", ",", "1299.00"},
+		{"12,50", ",", "12.50"},
+		{"1.299.000", ",", "1299000"},
+		{"1 299,99", ",", "1299.99"},
+		{"1,299", ".", "1299"},
+		{"1,299.00", ".", "1299.00"},
+		{"10.50", ".", "10.50"},
+		{"1.299.000", ".", "1299000"},
+		//Unknown site: the amount decides
+		{"1.299", "", "1.299"},
+		{"1,299", "", "1299"},
+		{"10,50", "", "10.50"},
+		{"1.299,00", "", "1299.00"},
+	}
+
+	for _, test := range tests {
+		if got := normalizeAmount(test.amount, test.decimalSeparator); got != test.want {
+			t.Errorf("normalizeAmount(%q, %q) = %q, want %q", test.amount, test.decimalSeparator, got, test.want)
+		}
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		raw, amount, currency string
+	}{
+		{"$19.99", "19.99", "USD"},
+		{"US $1,299.00", "1299.00", "USD"},
+		{"£5", "5", "GBP"},
+		{"EUR 10,50", "10.50", "EUR"},
+		{"19.99", "19.99", ""},
+	}
+
+	for _, test := range tests {
+		amount, currency, err := parsePrice(test.raw, "")
+		if err != nil {
+			t.Errorf("price %q: %s", test.raw, err)
+			continue
+		}
+		if amount != test.amount || currency != test.currency {
+			t.Errorf("price %q = %s %s, want %s %s", test.raw, amount, currency, test.amount, test.currency)
+		}
+	}
+
+	if _, _, err := parsePrice("Price not available", ""); err == nil {
+		t.Error("price without amount parsed, want error")
+	}
+}
+
+func TestParsePriceRegional(t *testing.T) {
+	tests := []struct {
+		domain, raw, amount, currency string
+		cents                         int64
+	}{
+		{"ebay.de", "EUR 1.299,00", "1299.00", "EUR", 129900},
+		{"ebay.de", "1.299 €", "1299", "EUR", 129900},
+		{"ebay.fr", "12,50 EUR", "12.50", "EUR", 1250},
+		{"ebay.it", "1.299", "1299", "", 129900},
+		{"ebay.co.uk", "£1,299.99", "1299.99", "GBP", 129999},
+		{"ebay.com", "$1,299", "1299", "USD", 129900},
+	}
+
+	for _, test := range tests {
+		separator := DomainDecimalSeparator("https://www." + test.domain)
+		amount, currency, err := parsePrice(test.raw, separator)
+		if err != nil {
+			t.Errorf("%s price %q: %s", test.domain, test.raw, err)
+			continue
+		}
+		if amount != test.amount || currency != test.currency || priceCents(amount) != test.cents {
+			t.Errorf("%s price %q = %s %s (%d cents), want %s %s (%d cents)", test.domain, test.raw, amount, currency, priceCents(amount), test.amount, test.currency, test.cents)
+		}
+	}
+}
+
+func TestDomainDecimalSeparator(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://www.ebay.de/sch/i.html?_nkw=laptop", ","},
+		{"https://ebay.at/", ","},
+		{"https://www.ebay.co.uk/sch/garlandcomputer/m.html", "."},
+		{"https://www.ebay.com", "."},
+		{"http://127.0.0.1:8765/p1", ""},
+	}
+
+	for _, test := range tests {
+		if got := DomainDecimalSeparator(test.url); got != test.want {
+			t.Errorf("DomainDecimalSeparator(%q) = %q, want %q", test.url, got, test.want)
+		}
+	}
+}
+
+func TestBuildSearchURLDomains(t *testing.T) {
+	tests := []struct {
+		domain string
+		params SearchParams
+		want   string
+	}{
+		{"ebay.de", SearchParams{Query: "laptop"}, "https://www.ebay.de/sch/i.html?_nkw=laptop"},
+		{"ebay.co.uk", SearchParams{Seller: "garlandcomputer"}, "https://www.ebay.co.uk/sch/garlandcomputer/m.html"},
+		{"www.ebay.com.au", SearchParams{Query: "laptop", ItemsPerPage: 60}, "https://www.ebay.com.au/sch/i.html?_ipg=60&_nkw=laptop"},
+	}
+
+	for _, test := range tests {
+		baseURL, err := DomainBaseURL(test.domain)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test.params.BaseURL = baseURL
+		got, err := BuildSearchURL(test.params)
+		if err != nil || got != test.want {
+			t.Errorf("%s URL = %s (%v), want %s", test.domain, got, err, test.want)
+		}
+	}
+
+	if _, err := DomainBaseURL("ebay.example"); err == nil {
+		t.Error("got no error for unknown domain")
+	}
+}
+
+func TestOriginalPrice(t *testing.T) {
+	const link = `<a class="s-item__link" href="https://www.ebay.com/itm/555"><div class="s-item__title"><span role="heading">ThinkPad X220</span></div></a>`
+
+	tests := []struct {
+		name         string
+		prices       string
+		wantOriginal string
+		wantDiscount float64
+	}{
+		{"discounted", `<span class="s-item__price">$75.00</span><span class="s-item__original-price"><span class="STRIKETHROUGH">$100.00</span></span>`, "100.00", 25},
+		{"trending price", `<span class="s-item__price">$19.99</span><span class="s-item__trending-price">$29.99</span>`, "29.99", 33.3},
+		{"not discounted", `<span class="s-item__price">$75.00</span>`, "", 0},
+		{"original not higher", `<span class="s-item__price">$75.00</span><span class="s-item__original-price">$75.00</span>`, "75.00", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := parseFixtureItem(t, `<ul><li class="s-item" id="item1">`+link+test.prices+`</li></ul>`, "s-item")
+			item, err := (&EbayClassicParser{}).ParseItem(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.OriginalPrice != test.wantOriginal || item.DiscountPercent != test.wantDiscount {
+				t.Errorf("original price %q discount %v, want %q %v", item.OriginalPrice, item.DiscountPercent, test.wantOriginal, test.wantDiscount)
+			}
+		})
+	}
+}
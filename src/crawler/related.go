@@ -0,0 +1,68 @@
+package crawler
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Class of the results river answer eBay puts before loosely matching items
+const relatedStartClass string = "srp-river-answer--REWRITE_START"
+
+// Heading text of the loosely matching items, used when the answer class is missing
+const relatedHeadingText string = "matching fewer words"
+
+// Function returns index of the first item card following eBay "Results matching fewer words" heading, items from it on
+// match only some of the keywords and are not from the target search or seller. Returns len(itemElementList) without the heading
+func relatedItemsStart(pageNode *html.Node, itemElementList []*html.Node) int {
+	cardIndex := make(map[*html.Node]int, len(itemElementList))
+	for i, node := range itemElementList {
+		cardIndex[node] = i
+	}
+
+	//Cards are in document order, so the first card after the heading starts the related ones
+	start := len(itemElementList)
+	afterHeading := false
+
+	var walk func(n *html.Node) bool
+	walk = func(n *html.Node) bool {
+		if n.Type == html.ElementNode {
+			if i, ok := cardIndex[n]; ok {
+				if afterHeading {
+					start = i
+					return true
+				}
+
+				//Heading is never inside a card
+				return false
+			}
+
+			if !afterHeading && isRelatedHeading(n) {
+				afterHeading = true
+				return false
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if walk(c) {
+				return true
+			}
+		}
+
+		return false
+	}
+	walk(pageNode)
+
+	return start
+}
+
+// Function checks if node is the river answer starting loosely matching items
+func isRelatedHeading(node *html.Node) bool {
+	for _, a := range node.Attr {
+		if a.Key == "class" && strings.Contains(a.Val, "srp-river-answer") {
+			return strings.Contains(a.Val, relatedStartClass) || hasCardMarker(getNodeText(node), relatedHeadingText)
+		}
+	}
+
+	return false
+}
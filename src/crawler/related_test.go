@@ -0,0 +1,85 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// River answer eBay puts between exact and loosely matching items
+const relatedAnswer = `<li class="srp-river-answer srp-river-answer--REWRITE_START"><div class="section-notice"><h3>Results matching fewer words</h3></div></li>`
+
+// Function returns results page with exact cards 111 and 112, the river answer, and cards 201 and 202 after it
+func relatedResultsPage(answer string) string {
+	card := func(itemID string) string {
+		return `<li class="s-item" id="item` + itemID + `"><a class="s-item__link" href="https://www.ebay.com/itm/` + itemID + `">` +
+			`<div class="s-item__title"><span role="heading">Dell Latitude ` + itemID + `</span></div></a><span class="s-item__price">$10.00</span></li>`
+	}
+
+	return `<html><body><ul class="srp-results">` + card("111") + card("112") + answer + card("201") + card("202") + `</ul></body></html>`
+}
+
+func TestRelatedItems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, relatedResultsPage(relatedAnswer))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		crawler *Crawler
+		want    string
+	}{
+		{"related skipped by default", &Crawler{Logger: discardLogger}, "111,112"},
+		{"related included", &Crawler{Logger: discardLogger, IncludeRelated: true}, "111,112,201,202"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, err := test.crawler.Crawl(context.Background(), server.URL+"/sch/i.html?_nkw=dell+latitude")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(itemIDs(items), ","); got != test.want {
+				t.Fatalf("got items %s, want %s", got, test.want)
+			}
+			for _, item := range items {
+				if item.Related != strings.HasPrefix(item.ItemID, "2") {
+					t.Errorf("item %s has Related %t", item.ItemID, item.Related)
+				}
+			}
+		})
+	}
+}
+
+func TestRelatedItemsStart(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   int
+	}{
+		{"answer class", relatedAnswer, 2},
+		{"heading text without answer class", `<li class="srp-river-answer"><h3>Results matching FEWER words</h3></li>`, 2},
+		{"other river answer", `<li class="srp-river-answer"><h3>Shop on eBay</h3></li>`, 4},
+		{"heading text outside river answer", `<li class="s-item__subtitle">Results matching fewer words</li>`, 4},
+		{"no answer", "", 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pageNode := parseSnippet(t, relatedResultsPage(test.answer))
+			itemElementList := findItemElementsByClass(pageNode, "li", "s-item", nil)
+			if len(itemElementList) != 4 {
+				t.Fatalf("found %d cards, want 4", len(itemElementList))
+			}
+
+			if got := relatedItemsStart(pageNode, itemElementList); got != test.want {
+				t.Errorf("related items start at %d, want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package crawler
+
+// Function registers page about to be crawled, returns its index
+func (c *Crawler) trackPage(pageURL string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pageURLs = append(c.pageURLs, pageURL)
+	c.pagesDone = append(c.pagesDone, false)
+
+	return len(c.pageURLs) - 1
+}
+
+// Function marks all items of the page as processed
+func (c *Crawler) pageDone(index int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pagesDone[index] = true
+}
+
+// Function returns URL of the first page of the last crawl whose items were not all processed, together with
+// the number of pages before it. A crawl stopped by an error or cancellation can be resumed from this page.
+// Empty URL means every crawled page was processed
+func (c *Crawler) ResumePoint() (string, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, done := range c.pagesDone {
+		if !done {
+			return c.pageURLs[i], i
+		}
+	}
+
+	return "", len(c.pagesDone)
+}
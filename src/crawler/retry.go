@@ -0,0 +1,131 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const initialRetryDelay = time.Second
+const maxRetryDelay = 30 * time.Second
+
+// Longest Retry-After wait honored, longer values are cut to it
+const maxRetryAfter = 5 * time.Minute
+
+// Error returned for HTTP responses with non-2xx status code
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	RetryAfter time.Duration // wait requested by Retry-After header, 0 when absent
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("ERROR::HTTP status %d %s for %s", e.StatusCode, http.StatusText(e.StatusCode), e.URL)
+}
+
+// Function checks if the request can be retried after the error: 5xx and 429 responses, bot checks, timeouts and
+// connections reset or closed before the whole response arrived. Other errors, like responses over the size limit,
+// unsupported URLs or certificate pin mismatches, are permanent and not retried
+func isRetryableError(err error) bool {
+	if errors.Is(err, errBodyTooLarge) {
+		return false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	//Bot checks are lifted after a while, like rate limits
+	var challengeErr *ChallengeError
+	if errors.As(err, &challengeErr) {
+		return true
+	}
+
+	//Request which hit its own timeout, fetchWithRetry doesn't retry when the crawl context is done
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Function parses Retry-After header value given as seconds or HTTP date. Returns 0 when absent or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		wait = date.Sub(now)
+	}
+
+	if wait < 0 {
+		return 0
+	}
+
+	return min(wait, maxRetryAfter)
+}
+
+// Function adds random jitter of up to half the delay, so parallel clients don't retry at the same moment
+func withJitter(delay time.Duration) time.Duration {
+	return delay + rand.N(delay/2+1)
+}
+
+// Function makes GET request with getPageHTML, retrying transient failures with exponential backoff and jitter.
+// Retry-After of rate limited responses is waited for when it is longer than the backoff
+func (c *Crawler) fetchWithRetry(ctx context.Context, url string, maxRetries int) (string, error) {
+	delay := initialRetryDelay
+
+	for attempt := 0; ; attempt++ {
+		body, err := c.getPageHTML(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+
+		if attempt >= maxRetries || ctx.Err() != nil || !isRetryableError(err) {
+			return "", err
+		}
+
+		wait := withJitter(delay)
+
+		var httpErr *HTTPError
+		var challengeErr *ChallengeError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests {
+			wait = max(wait, httpErr.RetryAfter)
+			c.logger().Warn("Rate limited, backing off", "attempt", attempt+1, "attempts", maxRetries+1, "delay", wait, "retry_after", httpErr.RetryAfter, "url", url)
+		} else if errors.As(err, &challengeErr) {
+			c.logger().Warn("Blocked by eBay bot check, backing off", "attempt", attempt+1, "attempts", maxRetries+1, "delay", wait, "marker", challengeErr.Marker, "url", url)
+		} else {
+			c.logger().Warn("Request failed, retrying", "attempt", attempt+1, "attempts", maxRetries+1, "delay", wait, "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", err
+		case <-time.After(wait):
+		}
+
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	//Unsupported scheme fails in the client, before any connection is made
+	_, unsupportedScheme := (&Crawler{Logger: discardLogger}).getPageHTML(context.Background(), "ftp://www.ebay.com/sch/i.html")
+	if unsupportedScheme == nil {
+		t.Fatal("got no error for ftp URL")
+	}
+
+	connectionReset := &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server error", &HTTPError{StatusCode: http.StatusBadGateway}, true},
+		{"rate limited", &HTTPError{StatusCode: http.StatusTooManyRequests}, true},
+		{"not found", &HTTPError{StatusCode: http.StatusNotFound}, false},
+		{"bot check", &ChallengeError{Marker: "Pardon the interruption"}, true},
+		{"request timeout", fmt.Errorf("ERROR::Request timed out: %w", context.DeadlineExceeded), true},
+		{"network timeout", fmt.Errorf("ERROR::Can't make http request: %w", os.ErrDeadlineExceeded), true},
+		{"connection reset", fmt.Errorf("ERROR::Can't read http response body: %w", connectionReset), true},
+		{"connection closed", fmt.Errorf("ERROR::Can't read http response body: %w", io.ErrUnexpectedEOF), true},
+		{"body too large", fmt.Errorf("ERROR::Response body is too large: %w", errBodyTooLarge), false},
+		{"unsupported scheme", unsupportedScheme, false},
+		{"certificate pin mismatch", fmt.Errorf("ERROR::Can't make http request: %w", errors.New("ERROR::Server certificate fingerprint does not match pinned")), false},
+		{"cancelled", fmt.Errorf("ERROR::Can't make http request: %w", context.Canceled), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isRetryableError(test.err); got != test.want {
+				t.Errorf("isRetryableError(%v) = %t, want %t", test.err, got, test.want)
+			}
+		})
+	}
+}
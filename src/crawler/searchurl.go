@@ -0,0 +1,253 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const DefaultBaseURL string = "https://www.ebay.com"
+
+// Regional eBay sites, used to build base URL from a domain
+var Domains = []string{
+	"ebay.com", "ebay.ca", "ebay.co.uk", "ebay.ie", "ebay.de", "ebay.at", "ebay.ch", "ebay.fr", "ebay.be", "ebay.nl",
+	"ebay.it", "ebay.es", "ebay.pl", "ebay.com.au", "ebay.com.hk", "ebay.com.sg", "ebay.com.my", "ebay.ph",
+}
+
+// Regional sites showing prices with decimal comma ("1.299,00 €"), the others use decimal dot
+var decimalCommaDomains = []string{"ebay.de", "ebay.at", "ebay.fr", "ebay.be", "ebay.nl", "ebay.it", "ebay.es", "ebay.pl"}
+
+// Function returns decimal separator of prices of the eBay site of the URL, "," for ebay.de, "." for ebay.com.
+// Empty for other hosts (e.g. a mock server), whose amounts are guessed
+func DomainDecimalSeparator(siteURL string) string {
+	u, err := url.Parse(siteURL)
+	if err != nil {
+		return ""
+	}
+
+	domain := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	switch {
+	case slices.Contains(decimalCommaDomains, domain):
+		return ","
+	case slices.Contains(Domains, domain):
+		return "."
+	}
+
+	return ""
+}
+
+// Function returns base URL of the regional eBay site, e.g. https://www.ebay.de for ebay.de
+func DomainBaseURL(domain string) (string, error) {
+	domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+	if !slices.Contains(Domains, domain) {
+		return "", fmt.Errorf("ERROR::Unknown eBay domain %s. Possible values are: %s", domain, strings.Join(Domains, ", "))
+	}
+
+	return "https://www." + domain, nil
+}
+
+// Parameters used to build eBay search/store URL
+type SearchParams struct {
+	URL       string // full eBay listing URL, takes precedence over Seller and Query
+	BaseURL   string // scheme and host used with Seller and Query, defaults to DefaultBaseURL
+	Seller    string // store name
+	Query     string // search keywords, used when neither URL nor Seller is set
+	Condition int    // LH_ItemCondition value, 0 or less means no filter
+	// Listings per page (_ipg), one of ItemsPerPageValues. 0 keeps eBay default
+	ItemsPerPage int
+	Sort         int // _sop sort order code, 0 keeps eBay default
+}
+
+// Values of _ipg eBay accepts
+var ItemsPerPageValues = []int{60, 120, 240}
+
+// Function builds eBay search/store URL from the provided parameters
+func BuildSearchURL(params SearchParams) (string, error) {
+	if params.ItemsPerPage != 0 && !slices.Contains(ItemsPerPageValues, params.ItemsPerPage) {
+		return "", fmt.Errorf("ERROR::Items per page must be one of %v, got %d", ItemsPerPageValues, params.ItemsPerPage)
+	}
+
+	var searchURL *url.URL
+
+	switch {
+	case params.URL != "":
+		parsedURL, err := url.Parse(params.URL)
+		if err != nil {
+			return "", fmt.Errorf("ERROR::Can't parse URL %s: %s", params.URL, err)
+		}
+
+		if parsedURL.Scheme == "" || parsedURL.Host == "" {
+			return "", fmt.Errorf("ERROR::URL %s must be absolute", params.URL)
+		}
+
+		searchURL = parsedURL
+	case params.Seller != "" || params.Query != "":
+		baseURL := params.BaseURL
+		if baseURL == "" {
+			baseURL = DefaultBaseURL
+		}
+
+		baseURL, err := ParseBaseURL(baseURL)
+		if err != nil {
+			return "", err
+		}
+
+		if params.Seller != "" {
+			searchURL, err = url.Parse(fmt.Sprintf("%s/sch/%s/m.html", baseURL, url.PathEscape(params.Seller)))
+		} else {
+			searchURL, err = url.Parse(baseURL + "/sch/i.html")
+		}
+		if err != nil {
+			return "", fmt.Errorf("ERROR::Can't build search URL: %s", err)
+		}
+	default:
+		return "", fmt.Errorf("ERROR::Either seller, query or URL must be provided")
+	}
+
+	query := url.Values{}
+	if params.URL == "" && params.Seller == "" {
+		query.Set("_nkw", params.Query)
+	}
+	if params.Condition > 0 {
+		query.Set("LH_ItemCondition", strconv.Itoa(params.Condition))
+	}
+	if params.ItemsPerPage > 0 {
+		query.Set("_ipg", strconv.Itoa(params.ItemsPerPage))
+	}
+	if params.Sort > 0 {
+		query.Set("_sop", strconv.Itoa(params.Sort))
+	}
+	setQueryParams(searchURL, query)
+
+	return searchURL.String(), nil
+}
+
+// Function sets query parameters on the URL, keeping parameters it already has and replacing ones with the same name
+func setQueryParams(u *url.URL, params url.Values) {
+	query := u.Query()
+	for name, values := range params {
+		query[name] = values
+	}
+	u.RawQuery = query.Encode()
+}
+
+// Function to validate base URL and reduce it to scheme and host
+func ParseBaseURL(rawURL string) (string, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("ERROR::Can't parse base URL %s: %s", rawURL, err)
+	}
+
+	if parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return "", fmt.Errorf("ERROR::Base URL %s must contain scheme and host", rawURL)
+	}
+
+	return fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host), nil
+}
+
+// Function checks if page URL is an http(s) URL on the host of the start URL or one of allowed hosts (or their subdomains).
+// Subdomains of the start host without www are allowed as well, so www.ebay.com and ebay.com links are both followed
+func isAllowedHost(pageURL string, startURL string, allowedHosts []string) bool {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return false
+	}
+
+	startParsedURL, err := url.Parse(startURL)
+	if err == nil {
+		if strings.EqualFold(parsedURL.Host, startParsedURL.Host) {
+			return true
+		}
+		allowedHosts = append([]string{strings.TrimPrefix(strings.ToLower(startParsedURL.Hostname()), "www.")}, allowedHosts...)
+	}
+
+	host := strings.ToLower(parsedURL.Hostname())
+	for _, allowed := range allowedHosts {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed != "" && (host == allowed || strings.HasSuffix(host, "."+allowed)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Function to resolve href, possibly relative, against the URL of the page it was found on
+func resolveURL(base string, href string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("ERROR::Can't parse page URL %s: %s", base, err)
+	}
+
+	hrefURL, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("ERROR::Can't parse link %s: %s", href, err)
+	}
+
+	return baseURL.ResolveReference(hrefURL).String(), nil
+}
+
+// Query parameters eBay adds to product links for tracking, dropped from canonical product URLs
+var trackingParams = map[string]bool{
+	"_trkparms": true,
+	"_trksid":   true,
+	"_from":     true,
+	"_skw":      true,
+	"hash":      true,
+	"amdata":    true,
+	"itmmeta":   true,
+	"itmprp":    true,
+	"mkevt":     true,
+	"mkcid":     true,
+	"mkrid":     true,
+	"campid":    true,
+	"customid":  true,
+	"toolid":    true,
+}
+
+// Function returns canonical product URL of the link: protocol-relative links get https, relative ones are resolved
+// against the page URL, tracking parameters and fragment are dropped. Fails when the link isn't an absolute http(s) URL
+func normalizeProductURL(pageURL string, href string) (string, error) {
+	productURL, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", newParseError(ErrInvalidURL, "url", "", "ERROR::Can't parse product URL %s: %s", href, err)
+	}
+
+	if productURL.Scheme == "" && productURL.Host != "" {
+		productURL.Scheme = "https"
+	}
+
+	if productURL.Host == "" && pageURL != "" {
+		baseURL, err := url.Parse(pageURL)
+		if err == nil {
+			productURL = baseURL.ResolveReference(productURL)
+		}
+	}
+
+	if (productURL.Scheme != "http" && productURL.Scheme != "https") || productURL.Host == "" {
+		return "", newParseError(ErrInvalidURL, "url", "", "ERROR::Product URL %s is not an absolute http(s) URL", href)
+	}
+
+	query := productURL.Query()
+	for key := range query {
+		if trackingParams[key] {
+			query.Del(key)
+		}
+	}
+	productURL.RawQuery = query.Encode()
+	productURL.Fragment = ""
+
+	return productURL.String(), nil
+}
+
+// Function returns canonical product URL of the link, or the link as it is when it can't be normalized
+func canonicalProductURL(pageURL string, href string) string {
+	productURL, err := normalizeProductURL(pageURL, href)
+	if err != nil {
+		return href
+	}
+
+	return productURL
+}
@@ -0,0 +1,127 @@
+package crawler
+
+import "testing"
+
+func TestIsAllowedHost(t *testing.T) {
+	const startURL = "https://www.ebay.com/sch/i.html?_nkw=laptop"
+
+	tests := []struct {
+		pageURL      string
+		allowedHosts []string
+		want         bool
+	}{
+		{"https://www.ebay.com/sch/i.html?_nkw=laptop&_pgn=2", nil, true},
+		{"https://WWW.EBAY.COM/sch/i.html?_pgn=2", nil, true},
+		{"https://ebay.com/sch/i.html?_pgn=2", nil, true},
+		{"https://m.ebay.com/sch/i.html?_pgn=2", nil, true},
+		{"https://evil.example/sch/i.html?_pgn=2", nil, false},
+		{"https://ebay.com.evil.example/sch/i.html?_pgn=2", nil, false},
+		{"https://notebay.com/sch/i.html?_pgn=2", nil, false},
+		{"javascript:alert(1)", nil, false},
+		{"ftp://www.ebay.com/sch/i.html", nil, false},
+		{"https://www.ebay.de/sch/i.html?_pgn=2", nil, false},
+		{"https://www.ebay.de/sch/i.html?_pgn=2", []string{" EBAY.DE "}, true},
+		{"https://mirror.example.org/sch/i.html", []string{"example.org"}, true},
+	}
+
+	for _, test := range tests {
+		if got := isAllowedHost(test.pageURL, startURL, test.allowedHosts); got != test.want {
+			t.Errorf("isAllowedHost(%q, %v) = %t, want %t", test.pageURL, test.allowedHosts, got, test.want)
+		}
+	}
+}
+
+func TestBuildSearchURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  SearchParams
+		want    string
+		wantErr bool
+	}{
+		{"seller", SearchParams{Seller: "garlandcomputer"}, "https://www.ebay.com/sch/garlandcomputer/m.html", false},
+		{"seller escaped", SearchParams{Seller: "garland computer"}, "https://www.ebay.com/sch/garland%20computer/m.html", false},
+		{"query", SearchParams{Query: "dell laptop"}, "https://www.ebay.com/sch/i.html?_nkw=dell+laptop", false},
+		{"seller over query", SearchParams{Seller: "garlandcomputer", Query: "laptop"}, "https://www.ebay.com/sch/garlandcomputer/m.html", false},
+		{"base URL", SearchParams{Seller: "garlandcomputer", BaseURL: "http://127.0.0.1:8080/any/path"}, "http://127.0.0.1:8080/sch/garlandcomputer/m.html", false},
+		{"condition", SearchParams{Query: "laptop", Condition: 3000}, "https://www.ebay.com/sch/i.html?LH_ItemCondition=3000&_nkw=laptop", false},
+		{"items per page", SearchParams{Seller: "garlandcomputer", ItemsPerPage: 240}, "https://www.ebay.com/sch/garlandcomputer/m.html?_ipg=240", false},
+		{"sort", SearchParams{Seller: "garlandcomputer", Sort: 15}, "https://www.ebay.com/sch/garlandcomputer/m.html?_sop=15", false},
+		{"all parameters", SearchParams{Query: "laptop", Condition: 1000, ItemsPerPage: 120, Sort: 10},
+			"https://www.ebay.com/sch/i.html?LH_ItemCondition=1000&_ipg=120&_nkw=laptop&_sop=10", false},
+		{"URL keeps its parameters", SearchParams{URL: "https://www.ebay.com/sch/i.html?_nkw=laptop&_udhi=500", Condition: 3000},
+			"https://www.ebay.com/sch/i.html?LH_ItemCondition=3000&_nkw=laptop&_udhi=500", false},
+		{"URL parameter replaced", SearchParams{URL: "https://www.ebay.com/sch/i.html?_nkw=laptop&_sop=1", Sort: 12},
+			"https://www.ebay.com/sch/i.html?_nkw=laptop&_sop=12", false},
+		{"URL over seller", SearchParams{URL: "https://www.ebay.com/b/Laptops/175672/bn_1648276", Seller: "garlandcomputer"},
+			"https://www.ebay.com/b/Laptops/175672/bn_1648276", false},
+		{"no condition filter", SearchParams{Query: "laptop", Condition: -1}, "https://www.ebay.com/sch/i.html?_nkw=laptop", false},
+		{"unsupported items per page", SearchParams{Query: "laptop", ItemsPerPage: 50}, "", true},
+		{"relative URL", SearchParams{URL: "/sch/i.html?_nkw=laptop"}, "", true},
+		{"base URL without host", SearchParams{Query: "laptop", BaseURL: "ebay.com"}, "", true},
+		{"nothing to crawl", SearchParams{}, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := BuildSearchURL(test.params)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %t", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	const base = "https://www.ebay.com/sch/i.html?_nkw=laptop&_pgn=1"
+
+	tests := []struct {
+		href string
+		want string
+	}{
+		{"https://www.ebay.com/sch/i.html?_nkw=laptop&_pgn=2", "https://www.ebay.com/sch/i.html?_nkw=laptop&_pgn=2"},
+		{"/sch/i.html?_nkw=laptop&_pgn=2", "https://www.ebay.com/sch/i.html?_nkw=laptop&_pgn=2"},
+		{"?_nkw=laptop&_pgn=2", "https://www.ebay.com/sch/i.html?_nkw=laptop&_pgn=2"},
+		{"m.html?_pgn=2", "https://www.ebay.com/sch/m.html?_pgn=2"},
+		{"//www.ebay.com/sch/i.html?_pgn=2", "https://www.ebay.com/sch/i.html?_pgn=2"},
+	}
+
+	for _, test := range tests {
+		got, err := resolveURL(base, test.href)
+		if err != nil || got != test.want {
+			t.Errorf("resolveURL(%q) = %q, %v, want %q", test.href, got, err, test.want)
+		}
+	}
+
+	if _, err := resolveURL(base, "https://www.ebay.com/%zz"); err == nil {
+		t.Error("invalid link resolved, want error")
+	}
+}
+
+func TestNormalizeProductURL(t *testing.T) {
+	const pageURL = "https://www.ebay.com/sch/i.html?_nkw=laptop"
+
+	tests := []struct {
+		name string
+		href string
+		want string
+	}{
+		{"tracking parameters", "https://www.ebay.com/itm/123456?_trkparms=amclksrc%3DITM&_trksid=p2047675&hash=item1c&amdata=enc", "https://www.ebay.com/itm/123456"},
+		{"other parameters kept", "https://www.ebay.com/itm/123456?var=7&hash=item1c&itmmeta=01", "https://www.ebay.com/itm/123456?var=7"},
+		{"protocol-relative", "//www.ebay.com/itm/123456?hash=item1c", "https://www.ebay.com/itm/123456"},
+		{"relative", "/itm/123456?_trksid=p2047675", "https://www.ebay.com/itm/123456"},
+		{"fragment", "https://www.ebay.com/itm/123456#seeMore", "https://www.ebay.com/itm/123456"},
+		{"surrounding spaces", "  https://www.ebay.com/itm/123456  ", "https://www.ebay.com/itm/123456"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := normalizeProductURL(pageURL, test.href)
+			if err != nil || got != test.want {
+				t.Errorf("got %q, %v, want %q", got, err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package crawler
+
+// Class names the classic parser finds item card elements by. eBay renames classes now and then,
+// overriding them fixes a run without waiting for a release
+type ClassicSelectors struct {
+	Item      string // class of li item cards
+	Link      string // class of the product link
+	Title     string // class of the title div, whose span[role="heading"] holds the title
+	Subtitle  string // class of the subtitle div
+	Condition string // class of the condition span within the subtitle
+}
+
+// Class names of the classic layout
+var DefaultClassicSelectors = ClassicSelectors{
+	Item:      "s-item",
+	Link:      "s-item__link",
+	Title:     "s-item__title",
+	Subtitle:  "s-item__subtitle",
+	Condition: "SECONDARY_INFO",
+}
+
+// Function returns selectors with empty class names taken from DefaultClassicSelectors
+func (s ClassicSelectors) withDefaults() ClassicSelectors {
+	if s.Item == "" {
+		s.Item = DefaultClassicSelectors.Item
+	}
+	if s.Link == "" {
+		s.Link = DefaultClassicSelectors.Link
+	}
+	if s.Title == "" {
+		s.Title = DefaultClassicSelectors.Title
+	}
+	if s.Subtitle == "" {
+		s.Subtitle = DefaultClassicSelectors.Subtitle
+	}
+	if s.Condition == "" {
+		s.Condition = DefaultClassicSelectors.Condition
+	}
+
+	return s
+}
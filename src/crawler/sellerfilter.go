@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"sync/atomic"
+)
+
+// Seller blocklist: item is dropped when its seller name matches a blocked name, ignoring case. Names with *, ? or [
+// are shell patterns, e.g. "*_outlet". Items without a parsed seller name are kept
+type SellerFilter struct {
+	names    map[string]bool
+	patterns []string
+
+	filtered atomic.Int64
+}
+
+// Function creates seller filter of blocked names and patterns. Returns nil when no name is given
+func NewSellerFilter(blocked []string) (*SellerFilter, error) {
+	filter := &SellerFilter{names: map[string]bool{}}
+
+	for _, name := range blocked {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		if !strings.ContainsAny(name, "*?[") {
+			filter.names[name] = true
+			continue
+		}
+
+		_, err := path.Match(name, "")
+		if err != nil {
+			return nil, fmt.Errorf("ERROR::Can't compile seller pattern %s: %s", name, err)
+		}
+		filter.patterns = append(filter.patterns, name)
+	}
+
+	if len(filter.names) == 0 && len(filter.patterns) == 0 {
+		return nil, nil
+	}
+
+	return filter, nil
+}
+
+// Function checks if seller of the item is not blocked
+func (f *SellerFilter) Keep(item *ItemInfo) bool {
+	if item.SellerName == "" {
+		return true
+	}
+
+	seller := strings.ToLower(item.SellerName)
+	blocked := f.names[seller]
+	for _, pattern := range f.patterns {
+		if blocked {
+			break
+		}
+		blocked, _ = path.Match(pattern, seller)
+	}
+
+	if blocked {
+		f.filtered.Add(1)
+	}
+
+	return !blocked
+}
+
+// Function logs number of items dropped by the filter
+func (f *SellerFilter) LogSummary(logger *slog.Logger) {
+	logger.Info("Filtered out items by seller", "filtered", f.filtered.Load())
+}
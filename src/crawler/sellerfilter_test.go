@@ -0,0 +1,94 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Function returns classic result card of the seller
+func sellerCard(itemID string, seller string) string {
+	return `<li class="s-item" id="item` + itemID + `"><a class="s-item__link" href="https://www.ebay.com/itm/` + itemID + `?hash=x">` +
+		`<div class="s-item__title"><span role="heading">Dell Laptop</span></div></a><span class="s-item__price">$10.00</span>` +
+		`<span class="s-item__seller-info-text">` + seller + ` (1,234) 99.5%</span></li>`
+}
+
+func TestSellerFilterCrawl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><ul>`+sellerCard("111", "goodstore")+sellerCard("222", "Cheap_Refurbs")+
+			sellerCard("333", "goodstore")+sellerCard("444", "laptops_outlet")+`</ul></body></html>`)
+	}))
+	defer server.Close()
+
+	filter, err := NewSellerFilter([]string{"cheap_refurbs", "*_outlet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Crawler{Logger: discardLogger, SellerFilter: filter}
+	items, err := c.Crawl(context.Background(), server.URL+"/sch/i.html?_nkw=laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(itemIDs(items), ","); got != "111,333" {
+		t.Errorf("got items %s, want only items of goodstore 111,333", got)
+	}
+	if stats := c.Stats(); stats.Filtered != 2 {
+		t.Errorf("filtered = %d, want 2", stats.Filtered)
+	}
+}
+
+func TestSellerFilterKeep(t *testing.T) {
+	filter, err := NewSellerFilter([]string{" Cheap_Refurbs ", "*_outlet", "store?", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		seller string
+		keep   bool
+	}{
+		{"cheap_refurbs", false},
+		{"CHEAP_REFURBS", false},
+		{"cheap_refurbs2", true},
+		{"laptops_outlet", false},
+		{"outlet", true},
+		{"store1", false},
+		{"store12", true},
+		{"", true},
+	}
+
+	for _, test := range tests {
+		if keep := filter.Keep(&ItemInfo{SellerName: test.seller}); keep != test.keep {
+			t.Errorf("Keep(%q) = %t, want %t", test.seller, keep, test.keep)
+		}
+	}
+}
+
+func TestNewSellerFilter(t *testing.T) {
+	if filter, err := NewSellerFilter([]string{"", "  "}); filter != nil || err != nil {
+		t.Errorf("got %v, %v for empty names, want nil filter", filter, err)
+	}
+
+	if _, err := NewSellerFilter([]string{"store[a"}); err == nil {
+		t.Error("got no error for malformed pattern")
+	}
+}
+
+func TestCardParserSeller(t *testing.T) {
+	node := parseFixtureItem(t, `<ul><li class="s-card" id="item1"><a class="su-link" href="https://www.ebay.com/itm/555">`+
+		`<div class="s-card__title"><span class="su-styled-text">ThinkPad X220</span></div></a><span class="s-card__price">$120.00</span>`+
+		`<div class="s-card__attribute-row"><span>techstore</span> <span>99.5% positive (1.2K)</span></div></li></ul>`, "s-card")
+
+	item, err := (&EbayCardParser{Logger: discardLogger}).ParseItem(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.SellerName != "techstore" || item.SellerRating != "99.5%" {
+		t.Errorf("seller %q rating %q, want techstore 99.5%%", item.SellerName, item.SellerRating)
+	}
+}
@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync/atomic"
+)
+
+// Title keyword filter: item is kept when its title matches at least one include pattern (if any) and no exclude pattern
+type TitleFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+
+	filtered atomic.Int64
+}
+
+// Function creates title filter from case-insensitive keywords, or regular expressions when useRegex is set.
+// Returns nil when no keyword is given
+func NewTitleFilter(include []string, exclude []string, useRegex bool) (*TitleFilter, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+
+	filter := new(TitleFilter)
+
+	var err error
+	filter.include, err = compileTitlePatterns(include, useRegex)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.exclude, err = compileTitlePatterns(exclude, useRegex)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// Function compiles keywords into case-insensitive patterns, quoting them unless they are regular expressions
+func compileTitlePatterns(keywords []string, useRegex bool) ([]*regexp.Regexp, error) {
+	patterns := []*regexp.Regexp{}
+	for _, keyword := range keywords {
+		expr := keyword
+		if !useRegex {
+			expr = regexp.QuoteMeta(keyword)
+		}
+
+		pattern, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("ERROR::Can't compile title pattern %s: %s", keyword, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, nil
+}
+
+// Function checks if item title passes the filter
+func (f *TitleFilter) Keep(item *ItemInfo) bool {
+	keep := len(f.include) == 0
+	for _, pattern := range f.include {
+		if pattern.MatchString(item.Title) {
+			keep = true
+			break
+		}
+	}
+
+	for _, pattern := range f.exclude {
+		if keep && pattern.MatchString(item.Title) {
+			keep = false
+		}
+	}
+
+	if !keep {
+		f.filtered.Add(1)
+	}
+
+	return keep
+}
+
+// Function logs number of items dropped by the filter
+func (f *TitleFilter) LogSummary(logger *slog.Logger) {
+	logger.Info("Filtered out items by title", "filtered", f.filtered.Load())
+}
@@ -0,0 +1,162 @@
+package crawler
+
+import (
+	"encoding/json"
+	"maps"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Key of the variation model eBay embeds in a script of multi-variation detail pages for the variation selector
+const variationModelKey string = `"MSKU":`
+
+// Variation model of a detail page: selector menus with their values, and variation IDs by combination of selected
+// value IDs joined with "_" in menu order
+type variationModel struct {
+	SelectMenus []struct {
+		DisplayLabel     string `json:"displayLabel"`
+		MenuItemValueIDs []int  `json:"menuItemValueIds"`
+	} `json:"selectMenus"`
+	MenuItemMap map[string]struct {
+		DisplayName string `json:"displayName"`
+	} `json:"menuItemMap"`
+	VariationCombinations map[string]json.Number `json:"variationCombinations"`
+	VariationsMap         map[string]struct {
+		BinModel struct {
+			Price struct {
+				Value struct {
+					Value    float64 `json:"value"`
+					Currency string  `json:"currency"`
+				} `json:"value"`
+			} `json:"price"`
+		} `json:"binModel"`
+	} `json:"variationsMap"`
+}
+
+// Function returns an item per variation of the detail page, nil when the listing has no variations. Variations are
+// copies of the item with the variation ID appended to the item ID, their selected values and own price
+func parseVariations(pageNode *html.Node, item *ItemInfo) []ItemInfo {
+	model := findVariationModel(pageNode)
+	if model == nil || len(model.VariationCombinations) == 0 {
+		return nil
+	}
+
+	//Menu label of each value ID
+	labels := map[string]string{}
+	for _, menu := range model.SelectMenus {
+		for _, valueID := range menu.MenuItemValueIDs {
+			labels[strconv.Itoa(valueID)] = menu.DisplayLabel
+		}
+	}
+
+	//Combinations in order of their variation IDs, so the output doesn't depend on map order
+	combinations := make([]string, 0, len(model.VariationCombinations))
+	for combination := range model.VariationCombinations {
+		combinations = append(combinations, combination)
+	}
+	sort.Slice(combinations, func(i, j int) bool {
+		return variationIDLess(model.VariationCombinations[combinations[i]].String(), model.VariationCombinations[combinations[j]].String())
+	})
+
+	variations := []ItemInfo{}
+	for _, combination := range combinations {
+		variationID := model.VariationCombinations[combination].String()
+
+		traits := map[string]string{}
+		for _, valueID := range strings.Split(combination, "_") {
+			value, ok := model.MenuItemMap[valueID]
+			if ok && labels[valueID] != "" {
+				traits[labels[valueID]] = value.DisplayName
+			}
+		}
+
+		//Maps and slices of the item are copied, so changing one variation doesn't change the others
+		variation := *item
+		variation.ItemSpecifics = maps.Clone(item.ItemSpecifics)
+		variation.Images = slices.Clone(item.Images)
+		variation.Categories = slices.Clone(item.Categories)
+		variation.ItemID = item.ItemID + "-" + variationID
+		variation.VariationID = variationID
+		variation.Variation = traits
+		variation.ProductURL = variationURL(item.ProductURL, variationID)
+		if item.RawURL != "" {
+			variation.RawURL = variationURL(item.RawURL, variationID)
+		}
+
+		//Variation without a price of its own keeps the card price range
+		price := model.VariationsMap[variationID].BinModel.Price.Value
+		if price.Value > 0 {
+			amount := strconv.FormatFloat(price.Value, 'f', 2, 64)
+			variation.Price = amount
+			variation.PriceMin = amount
+			variation.PriceMax = amount
+			variation.PriceCents = priceCents(amount)
+			if price.Currency != "" {
+				variation.Currency = price.Currency
+			}
+		}
+
+		variations = append(variations, variation)
+	}
+
+	return variations
+}
+
+// Function compares variation IDs as numbers, so "9" goes before "10". IDs which aren't numbers are compared as text
+func variationIDLess(a string, b string) bool {
+	aNumber, aErr := strconv.ParseInt(a, 10, 64)
+	bNumber, bErr := strconv.ParseInt(b, 10, 64)
+	if aErr == nil && bErr == nil {
+		return aNumber < bNumber
+	}
+
+	return a < b
+}
+
+// Function finds and decodes the variation model of the detail page, nil when the page has none
+func findVariationModel(pageNode *html.Node) *variationModel {
+	var model *variationModel
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "script" && n.FirstChild != nil {
+			script := n.FirstChild.Data
+			start := strings.Index(script, variationModelKey)
+			if start != -1 {
+				//The model is followed by the rest of the script, the decoder stops after it
+				decoded := new(variationModel)
+				err := json.NewDecoder(strings.NewReader(script[start+len(variationModelKey):])).Decode(decoded)
+				if err == nil {
+					model = decoded
+				}
+			}
+			return
+		}
+
+		for c := n.FirstChild; c != nil && model == nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(pageNode)
+
+	return model
+}
+
+// Function returns product URL selecting the variation, like links of eBay variation pickers
+func variationURL(productURL string, variationID string) string {
+	u, err := url.Parse(productURL)
+	if err != nil {
+		return productURL
+	}
+
+	query := u.Query()
+	query.Set("var", variationID)
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
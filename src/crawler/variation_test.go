@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// Detail page of a listing in two colors, the model is followed by the rest of the script like on eBay pages
+const variationsDetailPage = `<html><body><h1 class="x-item-title__mainTitle">T-shirt</h1>
+<script>$MOD_DATA = {"MSKU":{"selectMenus":[{"displayLabel":"Color","menuItemValueIds":[0,1]}],
+"menuItemMap":{"0":{"valueId":0,"displayName":"Red"},"1":{"valueId":1,"displayName":"Blue"}},
+"variationCombinations":{"0":401,"1":402},
+"variationsMap":{"401":{"binModel":{"price":{"value":{"value":10.5,"currency":"USD"}}}},"402":{"binModel":{"price":{"value":{"value":12,"currency":"USD"}}}}}}, "other":{}};</script>
+</body></html>`
+
+func TestParseVariations(t *testing.T) {
+	pageNode, err := html.Parse(strings.NewReader(variationsDetailPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := &ItemInfo{ItemID: "555", Title: "T-shirt", Price: "10.50", PriceMin: "10.50", PriceMax: "12.00", ProductURL: "https://www.ebay.com/itm/555"}
+	variations := parseVariations(pageNode, item)
+	if len(variations) != 2 {
+		t.Fatalf("got %d variations, want 2", len(variations))
+	}
+
+	tests := []struct {
+		itemID, color, price, url string
+	}{
+		{"555-401", "Red", "10.50", "https://www.ebay.com/itm/555?var=401"},
+		{"555-402", "Blue", "12.00", "https://www.ebay.com/itm/555?var=402"},
+	}
+	for i, test := range tests {
+		variation := variations[i]
+		if variation.ItemID != test.itemID || variation.Variation["Color"] != test.color || variation.Price != test.price || variation.PriceMax != test.price {
+			t.Errorf("variation %d = %s %v %s-%s, want %s Color %s %s", i, variation.ItemID, variation.Variation, variation.PriceMin, variation.PriceMax, test.itemID, test.color, test.price)
+		}
+		if variation.ProductURL != test.url || variation.Title != "T-shirt" {
+			t.Errorf("variation %d has URL %s title %q, want %s with the listing title", i, variation.ProductURL, variation.Title, test.url)
+		}
+	}
+}
+
+func TestParseVariationsOrderAndCopies(t *testing.T) {
+	//Variation 10 follows variation 9, which text order would put after it
+	page := `<html><body><script>$MOD_DATA = {"MSKU":{"selectMenus":[{"displayLabel":"Size","menuItemValueIds":[0,1]}],
+"menuItemMap":{"0":{"valueId":0,"displayName":"S"},"1":{"valueId":1,"displayName":"M"}},
+"variationCombinations":{"0":10,"1":9},"variationsMap":{}}};</script></body></html>`
+	pageNode, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := &ItemInfo{ItemID: "555", ItemSpecifics: map[string]string{"Brand": "Acme"}, Images: []string{"a.jpg"}, Categories: []string{"Clothing"}}
+	variations := parseVariations(pageNode, item)
+	if got := strings.Join(itemIDs(variations), ","); got != "555-9,555-10" {
+		t.Fatalf("got variations %s, want 555-9,555-10", got)
+	}
+
+	//Each variation has its own specifics, images and categories
+	variations[0].ItemSpecifics["Size"] = "M"
+	variations[0].Images[0] = "m.jpg"
+	variations[0].Categories[0] = "Shirts"
+	if _, ok := variations[1].ItemSpecifics["Size"]; ok || variations[1].Images[0] != "a.jpg" || variations[1].Categories[0] != "Clothing" {
+		t.Errorf("changing variation 9 changed variation 10: %+v", variations[1])
+	}
+	if len(item.ItemSpecifics) != 1 || item.Images[0] != "a.jpg" || item.Categories[0] != "Clothing" {
+		t.Errorf("changing variation 9 changed the listing: %+v", item)
+	}
+}
+
+func TestParseVariationsSingleListing(t *testing.T) {
+	pageNode, _ := html.Parse(strings.NewReader(`<html><body><script>var x = 1;</script></body></html>`))
+	if variations := parseVariations(pageNode, &ItemInfo{ItemID: "555"}); variations != nil {
+		t.Errorf("got variations %v of a listing without them", variations)
+	}
+}
+
+func TestFollowVariations(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/itm/") {
+			fmt.Fprint(w, variationsDetailPage)
+			return
+		}
+		fmt.Fprint(w, `<html><body><ul><li class="s-item" id="item1"><a class="s-item__link" href="`+server.URL+`/itm/555?hash=x">`+
+			`<div class="s-item__title"><span role="heading">T-shirt</span></div></a><span class="s-item__price">$10.50 to $12.00</span></li></ul></body></html>`)
+	}))
+	defer server.Close()
+
+	priceFilter, _ := NewPriceFilter(0, 11)
+	tests := []struct {
+		name   string
+		filter *PriceFilter
+		want   string
+	}{
+		{"all variations", nil, "555-401,555-402"},
+		{"price filter", priceFilter, "555-401"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Crawler{Logger: discardLogger, Enrich: true, FollowVariations: true, PriceFilter: test.filter}
+			items, err := c.Crawl(context.Background(), server.URL+"/sch/i.html")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(itemIDs(items), ","); got != test.want {
+				t.Errorf("got items %s, want %s", got, test.want)
+			}
+		})
+	}
+}
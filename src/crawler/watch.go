@@ -0,0 +1,112 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/net/html"
+)
+
+// Messages of detail pages of listings which ended, matched ignoring case
+var endedListingMarkers = []string{"this listing has ended", "this listing was ended", "this item is no longer available"}
+
+// Function fetches detail pages <base>/itm/<id> of the item IDs and emits the items parsed from them, in the order of
+// the IDs, like items of a crawl: they pass OnItem and Stats count them. Listings which ended, or whose page is gone
+// (404, 410), are emitted with Ended. Items whose page fails otherwise are counted as failures and skipped.
+// Returns the items together with ctx.Err() when cancelled
+func (c *Crawler) WatchItems(ctx context.Context, baseURL string, itemIDs []string) ([]ItemInfo, error) {
+	c.reset()
+
+	var failures atomic.Int64
+	defer func() {
+		c.mu.Lock()
+		c.stats.Failures = int(failures.Load())
+		c.mu.Unlock()
+	}()
+
+	for i, itemID := range itemIDs {
+		if ctx.Err() != nil {
+			return c.sortedItems(), ctx.Err()
+		}
+
+		item, err := c.watchItem(ctx, baseURL, itemID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return c.sortedItems(), ctx.Err()
+			}
+			c.logger().Error("Failed to fetch watched item", "err", err, "item_id", itemID)
+			c.metrics().ItemsFailed.Add(1)
+			failures.Add(1)
+			continue
+		}
+		item.position = i
+
+		c.mu.Lock()
+		c.stats.Pages++
+		c.stats.ItemsFound++
+		c.mu.Unlock()
+
+		err = c.emit(item)
+		if err != nil && !errors.Is(err, ErrSkipItem) {
+			c.logger().Error("Failed to emit item", "err", err, "item_id", itemID)
+			c.metrics().ItemsFailed.Add(1)
+			failures.Add(1)
+		}
+	}
+
+	return c.sortedItems(), nil
+}
+
+// Function fetches and parses the detail page of the watched item
+func (c *Crawler) watchItem(ctx context.Context, baseURL string, itemID string) (*ItemInfo, error) {
+	itemURL := strings.TrimSuffix(baseURL, "/") + "/itm/" + itemID
+	item := &ItemInfo{ItemID: itemID, ProductURL: itemURL, PriceCents: -1, SourceURL: itemURL}
+
+	pageNode, err := c.fetchDetailNode(ctx, itemURL)
+	if err != nil {
+		//Removed listings have no page anymore
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && (httpErr.StatusCode == http.StatusNotFound || httpErr.StatusCode == http.StatusGone) {
+			c.logger().Info("Watched item page not found, listing removed", "item_id", itemID, "status", httpErr.StatusCode)
+			item.Ended = true
+			return item, nil
+		}
+		return nil, err
+	}
+
+	parseListing(item, pageNode, c.DecimalSeparator, c.logger())
+	c.parseDetailPage(ctx, item, pageNode, itemURL)
+
+	if item.Ended {
+		c.logger().Info("Watched item listing ended", "item_id", itemID)
+	}
+
+	return item, nil
+}
+
+// Function fills title, price, condition and ended state of the item from its detail page
+func parseListing(item *ItemInfo, pageNode *html.Node, decimalSeparator string, logger *slog.Logger) {
+	if titleNode := findFirstElementByClass(pageNode, "h1", "x-item-title__mainTitle"); titleNode != nil {
+		item.Title = cleanTitle(getNodeText(titleNode))
+	}
+
+	if priceNode := findFirstElementByClass(pageNode, "div", "x-price-primary"); priceNode != nil {
+		setItemPrice(item, strings.TrimSpace(getNodeText(priceNode)), decimalSeparator, logger)
+	}
+
+	if conditionNode := findFirstElementByClass(pageNode, "div", "x-item-condition-text"); conditionNode != nil {
+		item.Condition = strings.TrimSpace(getNodeText(conditionNode))
+	}
+
+	pageText := strings.ToLower(visibleText(pageNode))
+	for _, marker := range endedListingMarkers {
+		if strings.Contains(pageText, marker) {
+			item.Ended = true
+			break
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Detail pages of watched items by path, other paths are not found
+var watchedItemPages = map[string]string{
+	"/itm/101": `<html><body><h1 class="x-item-title__mainTitle"><span>Dell Latitude 5490</span></h1>` +
+		`<div class="x-price-primary"><span>US $120.50</span></div><div class="x-item-condition-text"><span>Used</span></div>` +
+		`<div class="ux-labels-values__labels">Brand:</div><div class="ux-labels-values__values">Dell</div></body></html>`,
+	"/itm/102": `<html><body><h1 class="x-item-title__mainTitle"><span>Lenovo ThinkPad T480</span></h1>` +
+		`<div class="x-price-primary"><span>US $99.00</span></div><div class="d-statusmessage">This listing has ended.</div></body></html>`,
+}
+
+func TestWatchItems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := watchedItemPages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	c := &Crawler{Logger: discardLogger}
+	items, err := c.WatchItems(context.Background(), server.URL, []string{"101", "102", "103"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3", len(items))
+	}
+
+	active := items[0]
+	if active.ItemID != "101" || active.Title != "Dell Latitude 5490" || active.PriceCents != 12050 || active.Condition != "Used" || active.Ended {
+		t.Errorf("got active item %+v", active)
+	}
+	if active.ProductURL != server.URL+"/itm/101" {
+		t.Errorf("product URL = %q, want %q", active.ProductURL, server.URL+"/itm/101")
+	}
+	if brand := active.ItemSpecifics["Brand"]; brand != "Dell" {
+		t.Errorf("brand = %q, want Dell", brand)
+	}
+
+	if ended := items[1]; ended.ItemID != "102" || ended.Title != "Lenovo ThinkPad T480" || !ended.Ended {
+		t.Errorf("got ended item %+v", ended)
+	}
+
+	//Removed listing has no page, its item keeps only the ID
+	if removed := items[2]; removed.ItemID != "103" || !removed.Ended || removed.Title != "" {
+		t.Errorf("got removed item %+v", removed)
+	}
+
+	if stats := c.Stats(); stats.ItemsFound != 3 || stats.Failures != 0 {
+		t.Errorf("got stats %+v, want 3 items without failures", stats)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"ebay-crawler/crawler"
+)
+
+// Item property used to detect already seen items
+type dedupKeyType string
+
+const (
+	dedupByID         dedupKeyType = "id"
+	dedupByURL        dedupKeyType = "url"
+	dedupByTitle      dedupKeyType = "title"
+	dedupByTitlePrice dedupKeyType = "title+price"
+)
+
+// Key used to detect already seen items
+var dedupKey = dedupByID
+
+// Function validates dedup-key flag value
+func parseDedupKey(value string) (dedupKeyType, error) {
+	switch key := dedupKeyType(value); key {
+	case dedupByID, dedupByURL, dedupByTitle, dedupByTitlePrice:
+		return key, nil
+	default:
+		return "", fmt.Errorf("ERROR::Unknown dedup key %s. Possible values are: id, url, title or title+price", value)
+	}
+}
+
+// Function returns dedup key of the item according to the configured dedup key type, used for the seen store and for duplicates within a run
+func getDedupKey(item *crawler.ItemInfo) string {
+	switch dedupKey {
+	case dedupByURL:
+		return "url:" + item.ProductURL
+	case dedupByTitle:
+		return "title:" + strings.ToLower(strings.TrimSpace(item.Title))
+	case dedupByTitlePrice:
+		return "title+price:" + strings.ToLower(strings.TrimSpace(item.Title)) + "|" + item.Price
+	default:
+		return item.ItemID
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"ebay-crawler/crawler"
+)
+
+// Test server of a store page with listings sharing ID, title or price. Store "relisted" has the same listings
+// under new item IDs
+func newDedupServer(t *testing.T) *httptest.Server {
+	listings := []struct{ path, title, price string }{
+		{"101", "Dell Laptop", "$10.00"},
+		{"102", "Dell Laptop", "$12.00"},
+		{"103", "dell laptop ", "$10.00"},
+		{"dell-laptop-16gb/101", "Dell Laptop 16GB", "$10.00"},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		relisted := strings.HasPrefix(r.URL.Path, "/sch/relisted/")
+
+		fmt.Fprint(w, `<html><body><ul>`)
+		for i, listing := range listings {
+			path := listing.path
+			if relisted {
+				path = strings.Replace(path, "10", "50", 1)
+			}
+			fmt.Fprintf(w, `<li class="s-item" id="item%d"><a class="s-item__link" href="https://www.ebay.com/itm/%s">`+
+				`<div class="s-item__title"><span role="heading">%s</span></div></a><span class="s-item__price">%s</span></li>`,
+				i, path, listing.title, listing.price)
+		}
+		fmt.Fprint(w, `</ul></body></html>`)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// Function runs the crawler on the seller store and returns sorted product URLs of the written items
+func runDedup(t *testing.T, server *httptest.Server, seller string, key string, seenDBPath string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	err := run([]string{"-seller", seller, "-base-url", server.URL, "-dedup-key", key, "-seen-db", seenDBPath,
+		"-output", "json", "-output-dir", dir, "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "items.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []crawler.ItemInfo{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatal(err)
+	}
+
+	urls := []string{}
+	for _, item := range items {
+		urls = append(urls, strings.TrimPrefix(item.ProductURL, "https://www.ebay.com/itm/"))
+	}
+
+	slices.Sort(urls)
+
+	return strings.Join(urls, ",")
+}
+
+func TestDedupKey(t *testing.T) {
+	server := newDedupServer(t)
+
+	tests := []struct {
+		key string
+		//Items written by the first run, then by a run on the relisted store with the same seen store
+		want, wantRelisted string
+	}{
+		{"id", "101,102,103", "501,502,503"},
+		{"url", "101,102,103,dell-laptop-16gb/101", "501,502,503,dell-laptop-16gb/501"},
+		{"title", "101,dell-laptop-16gb/101", ""},
+		{"title+price", "101,102,dell-laptop-16gb/101", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			seenDBPath := filepath.Join(t.TempDir(), "seen.txt")
+
+			if got := runDedup(t, server, "store", test.key, seenDBPath); got != test.want {
+				t.Errorf("got items %s, want %s", got, test.want)
+			}
+			if got := runDedup(t, server, "relisted", test.key, seenDBPath); got != test.wantRelisted {
+				t.Errorf("got relisted items %s, want %s", got, test.wantRelisted)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Function prints indented outline of node tree (tag, id, classes). When class is set only subtrees of matching elements are printed
+func dumpNodeTree(w io.Writer, node *html.Node, class string) {
+	if class == "" {
+		dumpNode(w, node, 0)
+		return
+	}
+
+	for _, match := range findAllElementsByClass(node, class, []*html.Node{}) {
+		dumpNode(w, match, 0)
+	}
+}
+
+// Function to find all elements, within an HTML NODE, having class containing the provided one
+func findAllElementsByClass(node *html.Node, class string, nodeList []*html.Node) []*html.Node {
+	if node.Type == html.ElementNode {
+		for _, a := range node.Attr {
+			if a.Key == "class" && strings.Contains(a.Val, class) {
+				return append(nodeList, node)
+			}
+		}
+	}
+
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		nodeList = findAllElementsByClass(c, class, nodeList)
+	}
+
+	return nodeList
+}
+
+// Function prints element node and its element children with the given depth
+func dumpNode(w io.Writer, node *html.Node, depth int) {
+	if node.Type == html.ElementNode {
+		line := strings.Repeat("  ", depth) + node.Data
+
+		for _, a := range node.Attr {
+			if a.Key == "id" && a.Val != "" {
+				line += "#" + a.Val
+			}
+		}
+
+		for _, a := range node.Attr {
+			if a.Key == "class" {
+				for _, className := range strings.Fields(a.Val) {
+					line += "." + className
+				}
+			}
+		}
+
+		fmt.Fprintln(w, line)
+		depth++
+	}
+
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		dumpNode(w, c, depth)
+	}
+}
+
+// Function reads HTML file and prints its node tree outline to stderr
+func dumpTreeFromFile(path string, class string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't open input file %s: %s", path, err)
+	}
+	defer file.Close()
+
+	pageHTML, err := html.Parse(file)
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't parse input file %s: %s", path, err)
+	}
+
+	dumpNodeTree(os.Stderr, pageHTML, class)
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// Results page of two cards, one with an id
+const dumpTreeFixture = `<html><head><title>Dell</title></head><body><ul class="srp-results srp-list">` +
+	`<li class="s-item s-item__pl-on-bottom" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/111"><span role="heading">Dell Laptop</span></a></li>` +
+	`<li class="s-item"><div class="s-item__info"><span class="s-item__price">$10.00</span></div></li>` +
+	`</ul></body></html>`
+
+func TestDumpNodeTree(t *testing.T) {
+	pageHTML, err := html.Parse(strings.NewReader(dumpTreeFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		class string
+		want  string
+	}{
+		{"whole tree", "", `html
+  head
+    title
+  body
+    ul.srp-results.srp-list
+      li#item1.s-item.s-item__pl-on-bottom
+        a.s-item__link
+          span
+      li.s-item
+        div.s-item__info
+          span.s-item__price
+`},
+		{"subtrees of class", "s-item", `li#item1.s-item.s-item__pl-on-bottom
+  a.s-item__link
+    span
+li.s-item
+  div.s-item__info
+    span.s-item__price
+`},
+		{"no match", "s-card", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := bytes.Buffer{}
+			dumpNodeTree(&out, pageHTML, test.class)
+			if out.String() != test.want {
+				t.Errorf("got outline\n%s\nwant\n%s", out.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestDumpTreeFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.html")
+	if err := os.WriteFile(path, []byte(dumpTreeFixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run([]string{"-dump-tree", "-input", path, "-dump-class", "s-item__price"}); err != nil {
+		t.Errorf("got %v dumping the tree of %s", err, path)
+	}
+
+	if err := run([]string{"-dump-tree"}); exitCode(err) != exitBadFlags {
+		t.Errorf("got %v, want bad flags error without -input", err)
+	}
+
+	if err := run([]string{"-dump-tree", "-input", filepath.Join(t.TempDir(), "missing.html")}); err == nil || !strings.Contains(err.Error(), "Can't open input file") {
+		t.Errorf("got %v, want error of the missing input file", err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Encoder used to transcode output, nil means output stays UTF-8
+var outputEncoder *encoding.Encoder
+
+// Supported output encodings by their flag names
+var outputEncodings = map[string]encoding.Encoding{
+	"windows-1251": charmap.Windows1251,
+	"windows-1252": charmap.Windows1252,
+	"latin1":       charmap.ISO8859_1,
+	"iso-8859-1":   charmap.ISO8859_1,
+	"iso-8859-15":  charmap.ISO8859_15,
+}
+
+// Function creates output encoder for the provided encoding name and unsupported character policy (error or replace)
+func newOutputEncoder(name string, policy string) (*encoding.Encoder, error) {
+	name = strings.ToLower(name)
+	if name == "" || name == "utf-8" || name == "utf8" {
+		return nil, nil
+	}
+
+	enc, ok := outputEncodings[name]
+	if !ok {
+		names := make([]string, 0, len(outputEncodings))
+		for encName := range outputEncodings {
+			names = append(names, encName)
+		}
+		return nil, fmt.Errorf("ERROR::Unknown output encoding %s. Possible values are: utf-8, %s", name, strings.Join(names, ", "))
+	}
+
+	switch policy {
+	case "error":
+		return enc.NewEncoder(), nil
+	case "replace":
+		return encoding.ReplaceUnsupported(enc.NewEncoder()), nil
+	default:
+		return nil, fmt.Errorf("ERROR::Unknown encoding error policy %s. Possible values are: error, replace", policy)
+	}
+}
+
+// Function transcodes UTF-8 output to the configured output encoding
+func encodeOutput(data []byte) ([]byte, error) {
+	if outputEncoder == nil {
+		return data, nil
+	}
+
+	encoded, err := outputEncoder.Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't encode output: %s", err)
+	}
+
+	return encoded, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Function returns results page of one item with the title
+func titledItemPage(title string) string {
+	return strings.Replace(fixtureItemsPage("111"), "Dell Laptop 111", title, 1)
+}
+
+func TestOutputEncodingLatin1CSV(t *testing.T) {
+	server := newPageServer(t, titledItemPage("Café Laptop Größe 15"))
+
+	dir := t.TempDir()
+	err := run([]string{"-url", server.URL + "/sch/i.html", "-output", "csv", "-output-dir", dir, "-output-encoding", "latin1", "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "items.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//é is the single byte 0xE9 in Latin-1, which isn't valid UTF-8
+	if utf8.Valid(data) || !strings.Contains(string(data), "Caf\xe9") {
+		t.Fatalf("items.csv isn't Latin-1: %q", data)
+	}
+
+	decoded, err := charmap.ISO8859_1.NewDecoder().Bytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(decoded), "Café Laptop Größe 15") {
+		t.Errorf("decoded items.csv has no title: %s", decoded)
+	}
+}
+
+func TestOutputEncodingUnsupportedCharacters(t *testing.T) {
+	//€ has no Latin-1 code
+	server := newPageServer(t, titledItemPage("Laptop 500 €"))
+
+	err := run([]string{"-url", server.URL + "/sch/i.html", "-output", "csv", "-output-dir", t.TempDir(), "-output-encoding", "latin1", "-delay", "0"})
+	if err == nil {
+		t.Error("got no error for a character the encoding can't represent")
+	}
+
+	dir := t.TempDir()
+	err = run([]string{"-url", server.URL + "/sch/i.html", "-output", "csv", "-output-dir", dir, "-output-encoding", "latin1", "-encoding-errors", "replace", "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "items.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Laptop 500 \x1a") {
+		t.Errorf("got %q, want € replaced", data)
+	}
+}
+
+func TestNewOutputEncoder(t *testing.T) {
+	tests := []struct {
+		name, policy string
+		wantNil      bool
+		wantErr      bool
+	}{
+		{"utf-8", "error", true, false},
+		{"", "error", true, false},
+		{"Windows-1251", "error", false, false},
+		{"latin1", "replace", false, false},
+		{"koi8-r", "error", true, true},
+		{"latin1", "ignore", true, true},
+	}
+
+	for _, test := range tests {
+		encoder, err := newOutputEncoder(test.name, test.policy)
+		if (encoder == nil) != test.wantNil || (err != nil) != test.wantErr {
+			t.Errorf("newOutputEncoder(%q, %q) = %v, %v", test.name, test.policy, encoder, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"ebay-crawler/crawler"
+)
+
+// File parse failures of item cards are appended to as JSON lines, for triage after a run
+type failuresFile struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	records int
+	err     error
+}
+
+// Function opens failures file for appending, creating it when missing
+func openFailuresFile(path string) (*failuresFile, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't open failures file %s: %s", path, err)
+	}
+
+	return &failuresFile{path: path, file: file}, nil
+}
+
+// Function appends the failure as a JSON line. The first write error is logged, later records are dropped
+func (f *failuresFile) Add(failure crawler.ParseFailure) {
+	line, _ := json.Marshal(failure)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return
+	}
+
+	_, f.err = f.file.Write(append(line, '\n'))
+	if f.err != nil {
+		slog.Error(fmt.Sprintf("ERROR::Can't write failures file %s: %s", f.path, f.err))
+		return
+	}
+	f.records++
+}
+
+// Function closes the file, logging how many failures were recorded
+func (f *failuresFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	slog.Info("Recorded parse failures", "path", f.path, "records", f.records)
+
+	err := f.file.Close()
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't close failures file %s: %s", f.path, err)
+	}
+
+	return nil
+}
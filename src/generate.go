@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"ebay-crawler/crawler"
+)
+
+// Number of generated items per fake results page, eBay default page size
+const generatedPageSize int = 60
+
+// Words fake item titles and conditions are picked from
+var (
+	generatedBrands     = []string{"Apple", "Dell", "HP", "Lenovo", "Samsung", "Sony", "Logitech", "Asus", "Canon", "Bose"}
+	generatedProducts   = []string{"Laptop", "Monitor", "Keyboard", "Mouse", "Headphones", "Tablet", "Camera", "Speaker", "Router", "Charger"}
+	generatedDetails    = []string{"16GB RAM", "24\"", "Wireless", "USB-C", "Black", "Bundle", "2023", "Bluetooth", "Pro", "Lot of 2"}
+	generatedConditions = []string{"Brand New", "Pre-Owned", "Open Box", "Certified - Refurbished", "For parts or not working"}
+)
+
+// Function generates n fake items and writes them through the output like crawled items, to test output writers and
+// consumers without crawling. Items have the shape of parsed ones: unique numeric item IDs, canonical product URLs,
+// normalized prices and link to the fake results page they are "found" on
+func generateItems(n int, baseURL string, write func(item *crawler.ItemInfo) error) ([]crawler.ItemInfo, crawler.Stats) {
+	items := make([]crawler.ItemInfo, 0, n)
+	stats := crawler.Stats{}
+	seenIDs := map[string]bool{}
+
+	for i := 0; i < n; i++ {
+		itemID := newItemID(seenIDs)
+		page := i/generatedPageSize + 1
+		if i%generatedPageSize == 0 {
+			stats.Pages++
+		}
+		stats.ItemsFound++
+
+		item := generateItem(itemID, fmt.Sprintf("%s/sch/i.html?_nkw=generated&_pgn=%d", baseURL, page), baseURL)
+
+		err := write(&item)
+		if err != nil {
+			stats.Failures++
+			slog.Error("Failed to write generated item", "err", err, "item_id", item.ItemID)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	return items, stats
+}
+
+// Function returns fake item with random title, price, condition and listing type
+func generateItem(itemID string, sourceURL string, baseURL string) crawler.ItemInfo {
+	cents := 100 + rand.Int64N(200000)
+	price := fmt.Sprintf("%d.%02d", cents/100, cents%100)
+
+	item := crawler.ItemInfo{
+		SchemaVersion: crawler.SchemaVersion,
+		CrawledAt:     time.Now().UTC().Format(time.RFC3339),
+		ItemID:        itemID,
+		Title:         fmt.Sprintf("%s %s %s", pick(generatedBrands), pick(generatedProducts), pick(generatedDetails)),
+		Condition:     pick(generatedConditions),
+		Price:         price,
+		PriceCents:    cents,
+		PriceMin:      price,
+		PriceMax:      price,
+		Currency:      "USD",
+		ProductURL:    baseURL + "/itm/" + itemID,
+		ImageURL:      fmt.Sprintf("https://i.ebayimg.com/thumbs/images/g/%s/s-l140.jpg", itemID),
+		Source:        "generated",
+		SourceURL:     sourceURL,
+		ListingType:   "fixed",
+	}
+
+	if rand.IntN(5) == 0 {
+		item.ListingType = "auction"
+		item.Bids = rand.IntN(40)
+	}
+
+	return item
+}
+
+// Function returns random 12 digit item ID, like the ones of eBay listings, which isn't in seenIDs yet
+func newItemID(seenIDs map[string]bool) string {
+	for {
+		itemID := strconv.FormatInt(100000000000+rand.Int64N(300000000000), 10)
+		if !seenIDs[itemID] {
+			seenIDs[itemID] = true
+			return itemID
+		}
+	}
+}
+
+// Function returns random element of the list
+func pick(list []string) string {
+	return list[rand.IntN(len(list))]
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"ebay-crawler/crawler"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	err := run([]string{"-generate", "130", "-output", "json", "-output-dir", dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "items.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []crawler.ItemInfo{}
+	err = json.Unmarshal(data, &items)
+	if err != nil {
+		t.Fatalf("items.json isn't an item array: %s", err)
+	}
+	if len(items) != 130 {
+		t.Fatalf("got %d items, want 130", len(items))
+	}
+
+	//Generated items have the shape of crawled ones
+	itemIDRegEx := regexp.MustCompile(`^[1-9][0-9]{11}$`)
+	seenIDs := map[string]bool{}
+	for _, item := range items {
+		if !itemIDRegEx.MatchString(item.ItemID) || seenIDs[item.ItemID] {
+			t.Errorf("item ID %q is not a unique 12 digit number", item.ItemID)
+		}
+		seenIDs[item.ItemID] = true
+
+		if item.ProductURL != crawler.DefaultBaseURL+"/itm/"+item.ItemID {
+			t.Errorf("item %s product URL = %q, want canonical item URL", item.ItemID, item.ProductURL)
+		}
+		if item.Title == "" || item.Condition == "" || item.Currency != "USD" {
+			t.Errorf("item %s lacks title, condition or currency: %+v", item.ItemID, item)
+		}
+		if fmt.Sprintf("%d.%02d", item.PriceCents/100, item.PriceCents%100) != item.Price || item.PriceMin != item.Price || item.PriceMax != item.Price {
+			t.Errorf("item %s price %q (%d cents, range %q-%q) isn't normalized", item.ItemID, item.Price, item.PriceCents, item.PriceMin, item.PriceMax)
+		}
+		if _, err := time.Parse(time.RFC3339, item.CrawledAt); err != nil || item.SchemaVersion != crawler.SchemaVersion {
+			t.Errorf("item %s crawled at %q schema %d, want RFC 3339 time and schema %d", item.ItemID, item.CrawledAt, item.SchemaVersion, crawler.SchemaVersion)
+		}
+	}
+
+	//Items are spread over fake results pages of eBay's default size
+	if items[0].SourceURL == items[len(items)-1].SourceURL {
+		t.Errorf("first and last of 130 items are both on page %s", items[0].SourceURL)
+	}
+}
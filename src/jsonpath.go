@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Compiled JSONPath subset: $, .name, ['name'], ['name1','name2'] and [index]
+type jsonPath struct {
+	steps []jsonPathStep
+}
+
+// Single JSONPath step, either a list of member names or an array index
+type jsonPathStep struct {
+	names []string
+	index int
+}
+
+// Function compiles JSONPath expression, returning error for unsupported or malformed expressions
+func compileJSONPath(expr string) (*jsonPath, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("ERROR::JSONPath %s must start with $", expr)
+	}
+
+	path := &jsonPath{}
+	rest := expr[1:]
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+
+			name := rest[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("ERROR::JSONPath %s has an empty member name", expr)
+			}
+
+			path.steps = append(path.steps, jsonPathStep{names: []string{name}})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("ERROR::JSONPath %s has unclosed bracket", expr)
+			}
+
+			step, err := parseJSONPathBracket(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("ERROR::JSONPath %s: %s", expr, err)
+			}
+
+			path.steps = append(path.steps, step)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("ERROR::JSONPath %s has unexpected character %q", expr, rest[0])
+		}
+	}
+
+	return path, nil
+}
+
+// Function parses content of JSONPath brackets: quoted names separated by commas or an array index
+func parseJSONPathBracket(content string) (jsonPathStep, error) {
+	content = strings.TrimSpace(content)
+
+	index, err := strconv.Atoi(content)
+	if err == nil {
+		return jsonPathStep{index: index}, nil
+	}
+
+	step := jsonPathStep{}
+	for _, name := range strings.Split(content, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) < 2 || (name[0] != '\'' && name[0] != '"') || name[len(name)-1] != name[0] {
+			return step, fmt.Errorf("member name %s must be quoted", name)
+		}
+
+		step.names = append(step.names, name[1:len(name)-1])
+	}
+
+	return step, nil
+}
+
+// Function applies JSONPath to JSON document. Union of names produces an object with the selected members
+func (p *jsonPath) Apply(data []byte) (interface{}, error) {
+	var value interface{}
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't parse JSON: %s", err)
+	}
+
+	for _, step := range p.steps {
+		switch current := value.(type) {
+		case map[string]interface{}:
+			if step.names == nil {
+				return nil, fmt.Errorf("ERROR::JSONPath index applied to an object")
+			}
+
+			if len(step.names) == 1 {
+				value = current[step.names[0]]
+				continue
+			}
+
+			selected := map[string]interface{}{}
+			for _, name := range step.names {
+				if member, ok := current[name]; ok {
+					selected[name] = member
+				}
+			}
+			value = selected
+		case []interface{}:
+			if step.names != nil {
+				return nil, fmt.Errorf("ERROR::JSONPath member name applied to an array")
+			}
+
+			if step.index < 0 || step.index >= len(current) {
+				value = nil
+				continue
+			}
+			value = current[step.index]
+		default:
+			value = nil
+		}
+	}
+
+	return value, nil
+}
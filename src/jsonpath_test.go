@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONPathTitleAndPrice(t *testing.T) {
+	server := newPageServer(t, fixtureItemsPage("111", "222"))
+
+	dir := t.TempDir()
+	err := run([]string{"-url", server.URL + "/sch/i.html", "-output", "json", "-output-dir", dir, "-jsonpath", "$['title','price']", "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "items.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []map[string]any{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+	for _, item := range items {
+		if len(item) != 2 || item["price"] != "10.00" || item["title"] == nil {
+			t.Errorf("got item %v, want only title and price", item)
+		}
+	}
+}
+
+func TestJSONPathApply(t *testing.T) {
+	const item = `{"title":"Dell Laptop","price":"10.00","item_specifics":{"Brand":"Dell"},"images":["a.jpg","b.jpg"]}`
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"$", `{"images":["a.jpg","b.jpg"],"item_specifics":{"Brand":"Dell"},"price":"10.00","title":"Dell Laptop"}`},
+		{"$.title", `"Dell Laptop"`},
+		{"$['title', \"price\"]", `{"price":"10.00","title":"Dell Laptop"}`},
+		{"$['title','missing']", `{"title":"Dell Laptop"}`},
+		{"$.item_specifics.Brand", `"Dell"`},
+		{"$['item_specifics']['Brand']", `"Dell"`},
+		{"$.images[1]", `"b.jpg"`},
+		{"$.images[5]", `null`},
+		{"$.title.length", `null`},
+	}
+
+	for _, test := range tests {
+		path, err := compileJSONPath(test.expr)
+		if err != nil {
+			t.Fatalf("compileJSONPath(%q): %s", test.expr, err)
+		}
+
+		value, err := path.Apply([]byte(item))
+		if err != nil {
+			t.Fatalf("%s: %s", test.expr, err)
+		}
+		got, _ := json.Marshal(value)
+		if string(got) != test.want {
+			t.Errorf("%s = %s, want %s", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestJSONPathErrors(t *testing.T) {
+	for _, expr := range []string{"title", "$.", "$['title'", "$[title]", "$..title", "$x"} {
+		if _, err := compileJSONPath(expr); err == nil {
+			t.Errorf("compileJSONPath(%q) got no error", expr)
+		}
+	}
+
+	//Steps which don't fit the value type
+	for _, expr := range []string{"$[0]", "$.images.name"} {
+		path, err := compileJSONPath(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := path.Apply([]byte(`{"images":["a.jpg"]}`)); err == nil {
+			t.Errorf("%s got no error", expr)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Function creates logger writing to stderr (or the progress bar on it), so the log stream stays separate from crawled data
+func newLogger(level string, jsonFormat bool, output io.Writer) (*slog.Logger, error) {
+	var logLevel slog.Level
+	switch strings.ToLower(level) {
+	case "debug":
+		logLevel = slog.LevelDebug
+	case "info":
+		logLevel = slog.LevelInfo
+	case "warn":
+		logLevel = slog.LevelWarn
+	case "error":
+		logLevel = slog.LevelError
+	default:
+		return nil, fmt.Errorf("ERROR::Unknown log level %s. Possible values are: debug, info, warn or error", level)
+	}
+
+	options := &slog.HandlerOptions{Level: logLevel}
+	if jsonFormat {
+		return slog.New(slog.NewJSONHandler(output, options)), nil
+	}
+
+	return slog.New(slog.NewTextHandler(output, options)), nil
+}
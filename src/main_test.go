@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ebay-crawler/crawler"
+)
+
+func TestRPMRate(t *testing.T) {
+	limiter := newRateLimiter(60, 0)
+	if limiter == nil {
+		t.Fatal("no limiter for -rpm=60")
+	}
+
+	//The first request passes at once, each next one waits for the token of the next second
+	now := time.Now()
+	if delay := limiter.ReserveN(now, 1).DelayFrom(now); delay != 0 {
+		t.Errorf("first request delayed by %s", delay)
+	}
+	for i := 1; i <= 3; i++ {
+		delay := limiter.ReserveN(now, 1).DelayFrom(now)
+		want := time.Duration(i) * time.Second
+		if delay < want-10*time.Millisecond || delay > want+10*time.Millisecond {
+			t.Errorf("request %d delayed by %s, want about %s", i+1, delay, want)
+		}
+	}
+
+	if newRateLimiter(0, 0) != nil {
+		t.Error("limiter created without -rpm and -rps")
+	}
+}
+
+func TestRPMConflictsWithDelay(t *testing.T) {
+	err := run([]string{"-query", "laptop", "-rpm", "60", "-delay", "2s"})
+	if exitCode(err) != exitBadFlags || !strings.Contains(err.Error(), "-rpm and -delay") {
+		t.Fatalf("got %v, want bad flags error for -rpm with -delay", err)
+	}
+}
+
+// Function returns results page with a classic item card, priced $10.00, of each item ID
+func fixtureItemsPage(itemIDs ...string) string {
+	page := `<html><body><ul>`
+	for _, itemID := range itemIDs {
+		page += `<li class="s-item" id="item` + itemID + `"><a class="s-item__link" href="https://www.ebay.com/itm/` + itemID + `">` +
+			`<div class="s-item__title"><span role="heading">Dell Laptop ` + itemID + `</span></div></a><span class="s-item__price">$10.00</span></li>`
+	}
+
+	return page + `</ul></body></html>`
+}
+
+// Test server answering every request with the page
+func newPageServer(t *testing.T, page string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestZeroResultSearch(t *testing.T) {
+	server := newPageServer(t, `<html><body><h1 class="srp-controls__count-heading">0 results for <span>qwertyuiop</span></h1></body></html>`)
+
+	tests := []struct{ output, file string }{
+		{"json", "items.json"},
+		{"csv", "items.csv"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.output, func(t *testing.T) {
+			dir := t.TempDir()
+			err := run([]string{"-query", "qwertyuiop", "-base-url", server.URL, "-output", test.output, "-output-dir", dir, "-delay", "0"})
+			if err != nil {
+				t.Fatalf("got %v, want success of an empty search", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if test.output == "json" && strings.TrimSpace(string(data)) != "[]" {
+				t.Errorf("got %s %q, want empty array", test.file, data)
+			}
+			if test.output == "csv" && (len(lines) != 1 || !strings.HasPrefix(lines[0], "title,")) {
+				t.Errorf("got %s %q, want only the header", test.file, data)
+			}
+		})
+	}
+
+	//A page without items which isn't an empty search fails as before
+	blocked := newPageServer(t, `<html><body><h1>Pardon Our Interruption...</h1></body></html>`)
+	err := run([]string{"-query", "qwertyuiop", "-base-url", blocked.URL, "-output", "json", "-output-dir", t.TempDir(), "-delay", "0"})
+	if exitCode(err) != exitFirstPageFailed {
+		t.Errorf("got %v with exit code %d, want %d", err, exitCode(err), exitFirstPageFailed)
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	var mu sync.Mutex
+	requested := []string{}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.RequestURI())
+		mu.Unlock()
+
+		if r.URL.Query().Get("_pgn") == "2" {
+			fmt.Fprint(w, fixtureItemsPage("201"))
+			return
+		}
+		//Next link of the mock host, allowed as the host of the start URL
+		page := strings.Replace(fixtureItemsPage("101"), "</ul>", `</ul><a class="pagination__next icon-link" href="`+server.URL+r.URL.Path+`?_pgn=2">next</a>`, 1)
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	//Path of the base URL is dropped, only scheme and host are used
+	dir := t.TempDir()
+	err := run([]string{"-seller", "garland computer", "-base-url", server.URL + "/ignored/path", "-output", "json", "-output-dir", dir, "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	got := strings.Join(requested, " ")
+	mu.Unlock()
+	if got != "/sch/garland%20computer/m.html /sch/garland%20computer/m.html?_pgn=2" {
+		t.Errorf("requested %s, want both store pages on the mock server", got)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "items.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []crawler.ItemInfo{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Errorf("got %d items, want the items of both pages", len(items))
+	}
+}
+
+func TestURLsOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("_pgn") == "2" {
+			fmt.Fprint(w, fixtureItemsPage("201", "202"))
+			return
+		}
+
+		//Cards of the first page have neither title nor price, they aren't parsed
+		fmt.Fprint(w, `<html><body><ul>`+
+			`<li class="s-item" id="item101"><a class="s-item__link" href="https://www.ebay.com/itm/101?_trksid=p1"></a></li>`+
+			`<li class="s-item" id="item102"><a class="s-item__link" href="https://www.ebay.com/itm/102"></a></li>`+
+			`</ul><a class="pagination__next icon-link" href="/sch/i.html?_pgn=2">next</a></body></html>`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	err := run([]string{"-url", server.URL + "/sch/i.html", "-output", "json", "-output-dir", dir, "-urls-only", "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "urls.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://www.ebay.com/itm/101\nhttps://www.ebay.com/itm/102\nhttps://www.ebay.com/itm/201\nhttps://www.ebay.com/itm/202\n"
+	if string(data) != want {
+		t.Errorf("urls.txt is\n%s\nwant\n%s", data, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "items.json")); !os.IsNotExist(err) {
+		t.Error("items.json was written with -urls-only")
+	}
+}
+
+func TestConditionCode(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", 0},
+		{"new", 3},
+		{"NEW", 3},
+		{" Used ", 4},
+		{"Refurbished", 2500},
+		{"not-specified", 10},
+		{"Not Specified", 10},
+		{"not_specified", 10},
+		{"3", 3},
+		{" 4 ", 4},
+		{"10", 10},
+		{"2500", 2500},
+	}
+
+	for _, test := range tests {
+		got, err := conditionCode(test.value)
+		if err != nil || got != test.want {
+			t.Errorf("conditionCode(%q) = %d, %v, want %d", test.value, got, err, test.want)
+		}
+	}
+
+	//Unknown names and codes list the valid values
+	for _, value := range []string{"mint", "5", "new-ish"} {
+		_, err := conditionCode(value)
+		if err == nil {
+			t.Errorf("conditionCode(%q) gave no error", value)
+			continue
+		}
+		for _, valid := range []string{"new (3)", "used (4)", "not-specified (10)", "refurbished (2500)"} {
+			if !strings.Contains(err.Error(), valid) {
+				t.Errorf("conditionCode(%q) error %q doesn't list %s", value, err, valid)
+			}
+		}
+	}
+}
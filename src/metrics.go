@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"ebay-crawler/crawler"
+)
+
+// Time given to metrics scrapes in progress when the server is stopped
+const metricsShutdownTimeout = 2 * time.Second
+
+// Function starts HTTP server exposing crawl metrics in Prometheus text format on /metrics. Returned function stops it
+func startMetricsServer(addr string, metrics *crawler.Metrics) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't listen on metrics address %s: %s", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeMetric(w, "pages_fetched", "counter", "Listing pages fetched", metrics.PagesFetched.Load())
+		writeMetric(w, "items_parsed", "counter", "Items parsed and written", metrics.ItemsParsed.Load())
+		writeMetric(w, "items_failed", "counter", "Items which failed to parse or write", metrics.ItemsFailed.Load())
+		writeMetric(w, "http_errors", "counter", "Failed requests and error responses", metrics.HTTPErrors.Load())
+		writeMetric(w, "bytes_downloaded", "counter", "Bytes of downloaded response bodies", metrics.BytesDownloaded.Load())
+		writeMetric(w, "current_page", "gauge", "Number of the page being crawled", metrics.CurrentPage.Load())
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Metrics server failed", "err", err)
+		}
+	}()
+	slog.Info("Serving metrics", "url", "http://"+listener.Addr().String()+"/metrics")
+
+	stop := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+
+		err := server.Shutdown(ctx)
+		if err != nil {
+			slog.Warn("Metrics server didn't stop cleanly", "err", err)
+		}
+	}
+
+	return stop, nil
+}
+
+// Function writes a single metric with its help and type lines
+func writeMetric(w http.ResponseWriter, name string, metricType string, help string, value int64) {
+	name = statsdPrefix + "_" + name
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, metricType, name, value)
+}
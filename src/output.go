@@ -0,0 +1,665 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ebay-crawler/crawler"
+)
+
+// Destination of crawled items
+type itemWriter interface {
+	Write(item *crawler.ItemInfo) error
+	Close() error
+}
+
+// Writer buffering items until Close, which can put them into the order of the crawl result
+type orderedWriter interface {
+	SetOrder(items []crawler.ItemInfo)
+}
+
+// Function returns key of item record matching it with the crawl result, sources may share item IDs
+func itemOrderKey(item *crawler.ItemInfo) string {
+	return item.Source + "\x00" + item.ItemID
+}
+
+// Function sorts records by position of their items in the crawl result, items are written
+// as soon as they are parsed, so their arrival order varies between runs
+func sortByResultOrder[T any](records []T, keys []string, items []crawler.ItemInfo) {
+	rank := make(map[string]int, len(items))
+	for i := range items {
+		rank[itemOrderKey(&items[i])] = i
+	}
+
+	order := make([]int, len(records))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return rank[keys[order[i]]] < rank[keys[order[j]]]
+	})
+
+	sorted := make([]T, len(records))
+	for i, index := range order {
+		sorted[i] = records[index]
+	}
+	copy(records, sorted)
+}
+
+// Directory all output files are written to
+var outputDir = "data"
+
+// Write aggregated output (items.json, items.csv, urls.txt) to stdout and create no files
+var outputStdout bool
+
+// Rewrite only new items and items with changed price (files output mode)
+var incrementalOutput bool
+
+// Skip items whose file already exists instead of overwriting it (files output mode)
+var noOverwrite bool
+
+// Size of the buffer of streamed output lines, 0 writes every line as soon as it is found (-buffer-size)
+var outputBufferSize int
+
+// Items per file of the json output, 0 writes all items into items.json (-split-size)
+var outputSplitSize int
+
+// Interval buffered output lines are flushed at, so consumers of the stream don't wait for a full buffer
+const outputFlushInterval = time.Second
+
+// Error of a writer which skipped the item on purpose, the item is not counted as written
+var errItemSkipped = errors.New("item skipped")
+
+// Error of exclusive output file creation when the file already exists
+var errOutputExists = errors.New("output file already exists")
+
+// State of a single run: its output, counters and the item stores it reads and updates. Each run() creates its own,
+// so runs in the same process don't see each other's counts
+type runState struct {
+	writer     itemWriter    // writer used for all crawled items
+	seenDB     *seenStore    // item keys seen in previous runs, nil when -seen-db is not set
+	writtenIDs *idSet        // item IDs written by this and previous runs of a resumed crawl, nil when -resume is not set
+	sqlite     sqliteOptions // settings of -output sqlite
+
+	itemsWritten         atomic.Int64 // number of items passed to the output writer
+	itemsSkippedExisting atomic.Int64 // number of items skipped because their file already existed
+}
+
+// Function creates item writer for the output mode: files, json, csv, ndjson, sqlite or grouped-by-location
+func (r *runState) newItemWriter(mode string) (itemWriter, error) {
+	switch mode {
+	case "files":
+		return &filesWriter{skippedExisting: &r.itemsSkippedExisting}, nil
+	case "json":
+		return new(jsonArrayWriter), nil
+	case "csv":
+		return new(csvWriter), nil
+	case "grouped-by-location":
+		return new(locationGroupsWriter), nil
+	case "ndjson":
+		if outputRotate != "" {
+			return newRotatingNDJSONWriter(newRotatingFile(outputDir, outputRotate), outputBufferSize), nil
+		}
+		return newNDJSONWriter(os.Stdout, outputBufferSize), nil
+	case "sqlite":
+		path := r.sqlite.path
+		if path == "" {
+			path = filepath.Join(outputDir, "items.db")
+		}
+
+		//Upserting into the database of a previous run would change it
+		if _, err := os.Stat(path); noClobber && err == nil {
+			return nil, fmt.Errorf("ERROR::Refusing to update existing database %s (-no-clobber). Write to a new database with another -db or -output-dir: %w", path, errOutputExists)
+		}
+
+		writer, err := newSQLiteWriter(path, r.sqlite)
+		if err != nil {
+			return nil, err
+		}
+		return writer, nil
+	default:
+		return nil, fmt.Errorf("ERROR::Unknown output mode %s. Possible values are: files, json, csv, ndjson, sqlite or grouped-by-location", mode)
+	}
+}
+
+// Function drops items seen in previous runs, so they reach neither the output nor the crawl result
+func (r *runState) skipSeenItem(item *crawler.ItemInfo) error {
+	if r.seenDB.Seen(getDedupKey(item)) {
+		return crawler.ErrSkipItem
+	}
+
+	return nil
+}
+
+// Function to write item to the output and mark it as seen
+func (r *runState) writeItem(item *crawler.ItemInfo) error {
+	//Written by the run which is resumed
+	if r.writtenIDs != nil && r.writtenIDs.Has(writtenKey(item.Source, item.ItemID)) {
+		return nil
+	}
+
+	err := r.writer.Write(item)
+	if errors.Is(err, errItemSkipped) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.itemsWritten.Add(1)
+
+	if r.writtenIDs != nil {
+		r.writtenIDs.Add(writtenKey(item.Source, item.ItemID))
+	}
+
+	if r.seenDB != nil {
+		r.seenDB.Add(getDedupKey(item))
+	}
+
+	return nil
+}
+
+// Function converts item to the value written to JSON output, applying -jsonpath transformation
+func itemJSONValue(item *crawler.ItemInfo) (interface{}, error) {
+	if itemJSONPath == nil {
+		return item, nil
+	}
+
+	itemJSON, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't marshal item %s: %s", item.ItemID, err)
+	}
+
+	return itemJSONPath.Apply(itemJSON)
+}
+
+// Function encodes value of JSON output files, indented by -json-indent or on a single line with -compact
+func marshalOutputJSON(value interface{}) ([]byte, error) {
+	if compactJSON {
+		return json.Marshal(value)
+	}
+
+	return json.MarshalIndent(value, "", jsonIndent)
+}
+
+// Writer of one <itemID>.json file per item, in a directory per source when there are several
+type filesWriter struct {
+	newItems  atomic.Int64
+	changed   atomic.Int64
+	unchanged atomic.Int64
+
+	skippedExisting *atomic.Int64 // counter of the run, items skipped because their file already existed
+}
+
+func (w *filesWriter) Write(item *crawler.ItemInfo) error {
+	dir := outputDir
+	if multiSource {
+		dir = filepath.Join(outputDir, sourceDirName(item.Source))
+
+		err := os.MkdirAll(dir, 0775)
+		if err != nil {
+			return fmt.Errorf("ERROR::Can't create output directory %s: %s", dir, err)
+		}
+	}
+	path := filepath.Join(dir, item.ItemID+".json")
+
+	if incrementalOutput {
+		write, err := w.checkStoredPrice(path, item)
+		if err != nil || !write {
+			return err
+		}
+	}
+
+	value, err := itemJSONValue(item)
+	if err != nil {
+		return err
+	}
+
+	itemJSON, _ := marshalOutputJSON(value)
+
+	if !noOverwrite {
+		return writeOutputFile(path, itemJSON)
+	}
+
+	//Created exclusively, so workers writing the same item can't both succeed
+	err = createOutputFile(path, itemJSON, true)
+	if errors.Is(err, errOutputExists) {
+		slog.Info("Skipping existing item file", "item_id", item.ItemID, "path", path)
+		w.skippedExisting.Add(1)
+		return errItemSkipped
+	}
+
+	return err
+}
+
+// Function compares price of the item with the one stored by previous run, returns false when the file is up to date
+func (w *filesWriter) checkStoredPrice(path string, item *crawler.ItemInfo) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		w.newItems.Add(1)
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("ERROR::Can't read stored item %s: %s", path, err)
+	}
+
+	stored := struct {
+		Price string `json:"price"`
+	}{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return false, fmt.Errorf("ERROR::Can't parse stored item %s: %s", path, err)
+	}
+
+	if stored.Price == item.Price {
+		w.unchanged.Add(1)
+		return false, nil
+	}
+
+	slog.Info("Price changed", "item_id", item.ItemID, "old_price", stored.Price, "new_price", item.Price)
+	w.changed.Add(1)
+
+	return true, nil
+}
+
+func (w *filesWriter) Close() error {
+	if incrementalOutput {
+		slog.Info("Incremental crawl", "new", w.newItems.Load(), "changed", w.changed.Load(), "unchanged", w.unchanged.Load())
+	}
+
+	return nil
+}
+
+// Writer collecting all items into a single items.json array
+type jsonArrayWriter struct {
+	mu    sync.Mutex
+	items []interface{}
+	keys  []string
+}
+
+func (w *jsonArrayWriter) Write(item *crawler.ItemInfo) error {
+	value, err := itemJSONValue(item)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.items = append(w.items, value)
+	w.keys = append(w.keys, itemOrderKey(item))
+
+	return nil
+}
+
+func (w *jsonArrayWriter) SetOrder(items []crawler.ItemInfo) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	sortByResultOrder(w.items, w.keys, items)
+}
+
+func (w *jsonArrayWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.items == nil {
+		w.items = []interface{}{}
+	}
+
+	if outputSplitSize > 0 {
+		return writeSplitOutput(w.items, outputSplitSize)
+	}
+
+	itemsJSON, _ := marshalOutputJSON(w.items)
+
+	return writeAggregatedOutput("items.json", itemsJSON)
+}
+
+// Key of items whose location isn't known in the grouped-by-location output
+const unknownLocation string = "unknown"
+
+// Writer collecting items into items-by-location.json, an object mapping item locations to arrays of items
+type locationGroupsWriter struct {
+	mu      sync.Mutex
+	records []locationRecord
+	keys    []string
+}
+
+type locationRecord struct {
+	location string
+	value    interface{}
+}
+
+func (w *locationGroupsWriter) Write(item *crawler.ItemInfo) error {
+	value, err := itemJSONValue(item)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.records = append(w.records, locationRecord{location: item.Location, value: value})
+	w.keys = append(w.keys, itemOrderKey(item))
+
+	return nil
+}
+
+func (w *locationGroupsWriter) SetOrder(items []crawler.ItemInfo) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	sortByResultOrder(w.records, w.keys, items)
+}
+
+func (w *locationGroupsWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	groups := map[string][]interface{}{}
+	//Spellings of a location differing in case or spacing share the group named by the first of them
+	names := map[string]string{}
+	for _, record := range w.records {
+		location := strings.Join(strings.Fields(record.location), " ")
+		if location == "" {
+			location = unknownLocation
+		}
+
+		name, ok := names[strings.ToLower(location)]
+		if !ok {
+			name = location
+			names[strings.ToLower(location)] = name
+		}
+		groups[name] = append(groups[name], record.value)
+	}
+
+	groupsJSON, _ := marshalOutputJSON(groups)
+
+	return writeAggregatedOutput("items-by-location.json", groupsJSON)
+}
+
+// Manifest of json output split into parts, written as manifest.json next to them
+type splitManifest struct {
+	SplitSize int         `json:"split_size"`
+	Items     int         `json:"items"`
+	Parts     []splitPart `json:"parts"`
+}
+
+type splitPart struct {
+	File  string `json:"file"`
+	Items int    `json:"items"`
+}
+
+// Function writes items as JSON arrays of up to splitSize items into output-0001.json, output-0002.json... and lists
+// the parts in manifest.json, which is written last, so a manifest never lists a part that wasn't written
+func writeSplitOutput(items []interface{}, splitSize int) error {
+	manifest := splitManifest{SplitSize: splitSize, Items: len(items), Parts: []splitPart{}}
+
+	for start := 0; start < len(items); start += splitSize {
+		part := items[start:min(start+splitSize, len(items))]
+		name := fmt.Sprintf("output-%04d.json", len(manifest.Parts)+1)
+
+		partJSON, _ := marshalOutputJSON(part)
+		err := writeAggregatedOutput(name, partJSON)
+		if err != nil {
+			return err
+		}
+
+		manifest.Parts = append(manifest.Parts, splitPart{File: name, Items: len(part)})
+	}
+
+	manifestJSON, _ := marshalOutputJSON(manifest)
+
+	return writeAggregatedOutput("manifest.json", manifestJSON)
+}
+
+// Writer collecting all items into a single items.csv file
+type csvWriter struct {
+	mu   sync.Mutex
+	rows [][]string
+	keys []string
+}
+
+var csvHeader = []string{"title", "condition", "price", "product_url"}
+
+func (w *csvWriter) Write(item *crawler.ItemInfo) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	row := []string{item.Title, item.Condition, item.Price, item.ProductURL}
+	if multiSource {
+		row = append(row, item.Source)
+	}
+	w.rows = append(w.rows, row)
+	w.keys = append(w.keys, itemOrderKey(item))
+
+	return nil
+}
+
+func (w *csvWriter) SetOrder(items []crawler.ItemInfo) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	sortByResultOrder(w.rows, w.keys, items)
+}
+
+func (w *csvWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	buffer := new(bytes.Buffer)
+	writer := csv.NewWriter(buffer)
+
+	header := csvHeader
+	if multiSource {
+		header = append(header[:len(header):len(header)], "source")
+	}
+
+	_ = writer.Write(header)
+	_ = writer.WriteAll(w.rows)
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("ERROR::Can't write CSV: %s", err)
+	}
+
+	return writeAggregatedOutput("items.csv", buffer.Bytes())
+}
+
+// Writer streaming items as JSON lines while they are found. A single goroutine writes lines, so they never interleave.
+// With a buffer lines are written in batches, when the buffer is full, every outputFlushInterval and on Close
+type ndjsonWriter struct {
+	mu     sync.Mutex
+	closed bool
+	lines  chan []byte
+	done   chan struct{}
+	err    error
+}
+
+// Function creates NDJSON writer and starts its writing goroutine, bufferSize 0 writes each line unbuffered
+func newNDJSONWriter(out io.Writer, bufferSize int) *ndjsonWriter {
+	w := &ndjsonWriter{lines: make(chan []byte, 64), done: make(chan struct{})}
+
+	go func() {
+		defer close(w.done)
+
+		var buffered *bufio.Writer
+		var flushTicks <-chan time.Time
+		if bufferSize > 0 {
+			buffered = bufio.NewWriterSize(out, bufferSize)
+			out = buffered
+
+			ticker := time.NewTicker(outputFlushInterval)
+			defer ticker.Stop()
+			flushTicks = ticker.C
+		}
+
+		for {
+			select {
+			case line, ok := <-w.lines:
+				//Closed, lines left in the buffer are written before Close returns
+				if !ok {
+					if buffered != nil && w.err == nil {
+						w.setError(buffered.Flush())
+					}
+					return
+				}
+
+				if w.err == nil {
+					_, err := out.Write(line)
+					w.setError(err)
+				}
+			case <-flushTicks:
+				if w.err == nil {
+					w.setError(buffered.Flush())
+				}
+			}
+		}
+	}()
+
+	return w
+}
+
+// Function keeps the first write error of the writing goroutine, later lines are dropped
+func (w *ndjsonWriter) setError(err error) {
+	if err != nil {
+		w.err = fmt.Errorf("ERROR::Can't write NDJSON output: %s", err)
+	}
+}
+
+func (w *ndjsonWriter) Write(item *crawler.ItemInfo) error {
+	value, err := itemJSONValue(item)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't marshal item %s: %s", item.ItemID, err)
+	}
+
+	line, err = encodeOutput(append(line, '\n'))
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return fmt.Errorf("ERROR::Item %s received after NDJSON output was closed", item.ItemID)
+	}
+	w.lines <- line
+
+	return nil
+}
+
+func (w *ndjsonWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.lines)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+
+	return w.err
+}
+
+// Function to write output file, honoring the no-clobber setting.
+// Data goes to a temporary file first, so an interrupted run never leaves a half-written file behind
+func writeOutputFile(path string, data []byte) error {
+	err := createOutputFile(path, data, noClobber)
+	if errors.Is(err, errOutputExists) {
+		return fmt.Errorf("ERROR::Refusing to overwrite existing output file %s (-no-clobber). Write to another -output-dir, or with -output files use -no-overwrite to keep existing item files and skip their items: %w", path, err)
+	}
+
+	return err
+}
+
+// Function writes aggregated output into the named file of the output directory, or to stdout with -stdout
+func writeAggregatedOutput(name string, data []byte) error {
+	if !outputStdout {
+		return writeOutputFile(filepath.Join(outputDir, name), data)
+	}
+
+	data, err := encodeOutput(data)
+	if err != nil {
+		return err
+	}
+
+	//Terminated by newline, so the shell prompt or next command output starts on its own line
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+
+	_, err = os.Stdout.Write(data)
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't write %s to stdout: %s", name, err)
+	}
+
+	return nil
+}
+
+// Function writes output file atomically through a temporary file. With exclusive set an existing file is kept
+// and errOutputExists is returned
+func createOutputFile(path string, data []byte, exclusive bool) error {
+	data, err := encodeOutput(data)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't create output file %s: %s", path, err)
+	}
+	tempPath := file.Name()
+	defer os.Remove(tempPath)
+
+	//Synced before the rename, so a crash can't leave the renamed file without its data
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't write output file %s: %s", path, err)
+	}
+
+	err = os.Chmod(tempPath, 0644)
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't write output file %s: %s", path, err)
+	}
+
+	if !exclusive {
+		err = os.Rename(tempPath, path)
+		if err != nil {
+			return fmt.Errorf("ERROR::Can't write output file %s: %s", path, err)
+		}
+		return nil
+	}
+
+	//Link fails when the file exists, unlike rename
+	err = os.Link(tempPath, path)
+	if err != nil {
+		if os.IsExist(err) {
+			return errOutputExists
+		}
+		return fmt.Errorf("ERROR::Can't create output file %s: %s", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ebay-crawler/crawler"
+)
+
+// Output counting write calls, each is a syscall when the output is stdout
+type writeCounter struct {
+	mu     sync.Mutex
+	writes int
+	data   bytes.Buffer
+}
+
+func (w *writeCounter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.writes++
+	return w.data.Write(p)
+}
+
+func (w *writeCounter) count() (int, string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.writes, w.data.String()
+}
+
+func TestNDJSONWriterBuffer(t *testing.T) {
+	tests := []struct {
+		name       string
+		bufferSize int
+		writes     int
+	}{
+		{"unbuffered", 0, 10},
+		{"buffered", 4096, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := new(writeCounter)
+			w := newNDJSONWriter(out, test.bufferSize)
+			for i := 0; i < 10; i++ {
+				if err := w.Write(&crawler.ItemInfo{ItemID: fmt.Sprint(i)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			writes, data := out.count()
+			if lines := strings.Count(data, "\n"); lines != 10 {
+				t.Errorf("got %d lines, want 10", lines)
+			}
+			if writes != test.writes {
+				t.Errorf("got %d writes, want %d", writes, test.writes)
+			}
+		})
+	}
+}
+
+func TestNDJSONWriterFlushesPeriodically(t *testing.T) {
+	out := new(writeCounter)
+	w := newNDJSONWriter(out, 4096)
+	defer w.Close()
+
+	if err := w.Write(&crawler.ItemInfo{ItemID: "111"}); err != nil {
+		t.Fatal(err)
+	}
+
+	//The line is written by the periodic flush, before the writer is closed
+	deadline := time.Now().Add(3 * outputFlushInterval)
+	for time.Now().Before(deadline) {
+		if _, data := out.count(); strings.Contains(data, `"item_id":"111"`) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Errorf("buffered line was not flushed within %s", 3*outputFlushInterval)
+}
+
+func BenchmarkNDJSONWriter(b *testing.B) {
+	for _, bufferSize := range []int{0, 4096, 65536} {
+		b.Run(fmt.Sprintf("buffer-%d", bufferSize), func(b *testing.B) {
+			out := new(writeCounter)
+			w := newNDJSONWriter(out, bufferSize)
+			item := &crawler.ItemInfo{ItemID: "123456", Title: "Dell Latitude 7490 Laptop", Price: "199.99", ProductURL: "https://www.ebay.com/itm/123456"}
+
+			for i := 0; i < b.N; i++ {
+				if err := w.Write(item); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				b.Fatal(err)
+			}
+
+			writes, _ := out.count()
+			b.ReportMetric(float64(writes)/float64(b.N), "writes/item")
+		})
+	}
+}
+
+func TestWriteOutputFileNoClobber(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.json")
+	if err := os.WriteFile(path, []byte("first run"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	noClobber = true
+	defer func() { noClobber = false }()
+
+	err := writeOutputFile(path, []byte("second run"))
+	if !errors.Is(err, errOutputExists) {
+		t.Fatalf("got %v, want refusal to overwrite", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "first run" {
+		t.Errorf("existing file was overwritten with %q", data)
+	}
+}
+
+func TestCreateOutputFileReadersSeeCompleteFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.json")
+
+	//Rewrites of a large items file while a reader polls it, like a consumer reading items.json during a crawl
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 20; i++ {
+			items := make([]crawler.ItemInfo, 200*i)
+			data, _ := json.Marshal(items)
+			if err := createOutputFile(path, data, false); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	reads := 0
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !json.Valid(data) {
+			t.Fatalf("read partial file of %d bytes", len(data))
+		}
+		reads++
+	}
+
+	if reads == 0 {
+		t.Error("reader never saw the file")
+	}
+}
+
+func TestCreateOutputFileFailedWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	//Rename fails after the temporary file is written, the destination is a directory which isn't empty
+	path := filepath.Join(dir, "items.json")
+	if err := os.MkdirAll(filepath.Join(path, "keep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := createOutputFile(path, []byte(`[{"item_id":"111"}]`), false)
+	if err == nil || !strings.HasPrefix(err.Error(), "ERROR::Can't write output file") {
+		t.Fatalf("got %v, want write error", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].IsDir() {
+		t.Errorf("got entries %v, want only the destination directory without temporary files", entries)
+	}
+	if _, err := os.Stat(filepath.Join(path, "keep")); err != nil {
+		t.Errorf("destination was changed: %s", err)
+	}
+}
+
+func TestNoClobberFailsRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><ul><li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/111">`+
+			`<div class="s-item__title"><span role="heading">Dell Laptop</span></div></a><span class="s-item__price">$10.00</span></li></ul></body></html>`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		output   string
+		existing string
+	}{
+		{"files", "files", "111.json"},
+		{"json", "json", "items.json"},
+		{"sqlite", "sqlite", "items.db"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, test.existing), []byte("first run"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := run([]string{"-url", server.URL + "/sch/i.html", "-output", test.output, "-output-dir", dir, "-no-clobber", "-delay", "0"})
+			if exitCode(err) != exitFailure || !errors.Is(err, errOutputExists) {
+				t.Fatalf("got %v, want exit code %d refusing to overwrite %s", err, exitFailure, test.existing)
+			}
+			//The message points to another output directory rather than deleting data
+			if !strings.Contains(err.Error(), "-output-dir") || strings.Contains(err.Error(), "remove") {
+				t.Errorf("error %q doesn't suggest another -output-dir", err)
+			}
+
+			data, _ := os.ReadFile(filepath.Join(dir, test.existing))
+			if string(data) != "first run" {
+				t.Errorf("existing %s was overwritten", test.existing)
+			}
+		})
+	}
+}
+
+func TestSplitSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><ul>`)
+		for i := 1; i <= 5; i++ {
+			fmt.Fprintf(w, `<li class="s-item" id="item%d"><a class="s-item__link" href="https://www.ebay.com/itm/%d">`+
+				`<div class="s-item__title"><span role="heading">Dell Laptop</span></div></a><span class="s-item__price">$10.00</span></li>`, i, 100+i)
+		}
+		fmt.Fprint(w, `</ul></body></html>`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	err := run([]string{"-url", server.URL + "/sch/i.html", "-output", "json", "-output-dir", dir, "-split-size", "2", "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := splitManifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Items != 5 || len(manifest.Parts) != 3 {
+		t.Fatalf("manifest lists %d items in %d parts, want 5 in 3", manifest.Items, len(manifest.Parts))
+	}
+
+	//Parts keep the order of the crawl result
+	wantIDs := [][]string{{"101", "102"}, {"103", "104"}, {"105"}}
+	for i, part := range manifest.Parts {
+		if want := fmt.Sprintf("output-%04d.json", i+1); part.File != want || part.Items != len(wantIDs[i]) {
+			t.Errorf("part %d is %s with %d items, want %s with %d", i, part.File, part.Items, want, len(wantIDs[i]))
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, part.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		items := []crawler.ItemInfo{}
+		if err := json.Unmarshal(data, &items); err != nil {
+			t.Fatalf("%s is not a JSON array: %s", part.File, err)
+		}
+		if got := itemIDList(items); got != strings.Join(wantIDs[i], ",") {
+			t.Errorf("%s has items %s, want %s", part.File, got, strings.Join(wantIDs[i], ","))
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "items.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("items.json was written with -split-size")
+	}
+}
+
+// Function returns item IDs joined with commas
+func itemIDList(items []crawler.ItemInfo) string {
+	ids := []string{}
+	for _, item := range items {
+		ids = append(ids, item.ItemID)
+	}
+
+	return strings.Join(ids, ",")
+}
+
+func TestJSONIndent(t *testing.T) {
+	server := newPageServer(t, fixtureItemsPage("111"))
+
+	tests := []struct {
+		indent string
+		//Start of the line of the item_id field, nested in the item object of the array
+		want string
+	}{
+		{"tab", "\t\t\"item_id\""},
+		{"2", "    \"item_id\""},
+		{"4", "        \"item_id\""},
+		{"..", "....\"item_id\""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.indent, func(t *testing.T) {
+			dir := t.TempDir()
+			err := run([]string{"-url", server.URL + "/sch/i.html", "-output", "json", "-output-dir", dir, "-json-indent", test.indent, "-delay", "0"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "items.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), "\n"+test.want+": \"111\"") {
+				t.Errorf("items.json isn't indented with %q:\n%s", test.indent, data)
+			}
+		})
+	}
+}
+
+func TestGroupedByLocation(t *testing.T) {
+	locations := map[string]string{
+		"111": `<span class="s-item__location">from United States</span>`,
+		"222": `<span class="s-item__itemLocation">Located in: Germany</span>`,
+		"333": ``,
+		"444": `<span class="s-item__location">from united  states</span>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><ul>`)
+		for _, itemID := range []string{"111", "222", "333", "444"} {
+			fmt.Fprintf(w, `<li class="s-item" id="item%[1]s"><a class="s-item__link" href="https://www.ebay.com/itm/%[1]s">`+
+				`<div class="s-item__title"><span role="heading">Dell Laptop</span></div></a><span class="s-item__price">$10.00</span>%[2]s</li>`, itemID, locations[itemID])
+		}
+		fmt.Fprint(w, `</ul></body></html>`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	err := run([]string{"-url", server.URL + "/sch/i.html", "-output", "grouped-by-location", "-output-dir", dir, "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "items-by-location.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := map[string][]crawler.ItemInfo{}
+	if err := json.Unmarshal(data, &groups); err != nil {
+		t.Fatalf("items-by-location.json is not an object of item arrays: %s", err)
+	}
+
+	//Groups keep the order of the crawl result, spellings differing in case and spacing share the first one
+	want := map[string]string{"United States": "111,444", "Germany": "222", "unknown": "333"}
+	if len(groups) != len(want) {
+		t.Errorf("got groups %s, want %v", data, want)
+	}
+	for location, ids := range want {
+		if got := itemIDList(groups[location]); got != ids {
+			t.Errorf("group %q has items %q, want %s", location, got, ids)
+		}
+	}
+}
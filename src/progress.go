@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"ebay-crawler/crawler"
+)
+
+// Time between redraws of the progress line on a terminal
+const progressDrawInterval = 200 * time.Millisecond
+
+// Time between progress log lines when stderr isn't a terminal
+const progressLogInterval = 10 * time.Second
+
+// Escape sequence returning to the line start and clearing the line
+const clearLine string = "\r\033[K"
+
+// Progress line redrawn in place on stderr. Log lines are written through it, so they clear the line first
+// and the line is redrawn below them
+type progressBar struct {
+	mu   sync.Mutex
+	out  *os.File
+	line string
+}
+
+// Function checks if the file is a terminal, as opposed to a pipe or regular file
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Function writes a log line above the progress line
+func (p *progressBar) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.line != "" {
+		fmt.Fprint(p.out, clearLine)
+	}
+	n, err := p.out.Write(b)
+	fmt.Fprint(p.out, p.line)
+
+	return n, err
+}
+
+// Function replaces the progress line
+func (p *progressBar) draw(line string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.line = line
+	fmt.Fprint(p.out, clearLine+line)
+}
+
+// Function removes the progress line, so output after the crawl starts on a clean line
+func (p *progressBar) clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.line != "" {
+		fmt.Fprint(p.out, clearLine)
+	}
+	p.line = ""
+}
+
+// Function reports crawl progress from metrics until the returned function is called: redrawing the progress bar
+// when it is given, logging a progress line now and then otherwise
+func startProgress(metrics *crawler.Metrics, bar *progressBar, startTime time.Time) func() {
+	interval := progressLogInterval
+	if bar != nil {
+		interval = progressDrawInterval
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			elapsed := time.Since(startTime).Round(time.Second)
+			page, parsed, failed := metrics.CurrentPage.Load(), metrics.ItemsParsed.Load(), metrics.ItemsFailed.Load()
+
+			if bar == nil {
+				slog.Info("Progress", "page", page, "items", parsed, "failed", failed, "elapsed", elapsed)
+				continue
+			}
+
+			line := fmt.Sprintf("page %d | %d items", page, parsed)
+			if failed > 0 {
+				line += fmt.Sprintf(" (%d failed)", failed)
+			}
+			bar.draw(line + " | " + elapsed.String())
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+
+		if bar != nil {
+			bar.clear()
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Name of the file in the output directory -resume keeps the state of a stopped crawl in
+const resumeFileName = "resume.json"
+
+// Point a stopped crawl is resumed from by the next run with -resume
+type crawlState struct {
+	Source  string              `json:"source"`             // source crawled when the run stopped, later sources were not crawled
+	PageURL string              `json:"page_url"`           // first page whose items were not all written
+	Pages   int                 `json:"pages"`              // pages of the source crawled before page_url
+	Items   map[string][]string `json:"items"`              // IDs of items already written by source, skipped by the resumed run
+	ItemIDs []string            `json:"item_ids,omitempty"` // written items of states saved without their source, taken as items of source
+}
+
+// Function reads crawl state, nil state means there is nothing to resume
+func loadCrawlState(path string) (*crawlState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't read resume state %s: %s", path, err)
+	}
+
+	state := new(crawlState)
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't parse resume state %s: %s. Remove it to start from the first page", path, err)
+	}
+
+	return state, nil
+}
+
+// Function writes crawl state, replacing the previous one
+func (state *crawlState) Save(path string) error {
+	data, _ := json.MarshalIndent(state, "", "\t")
+
+	tempPath := path + ".tmp"
+	err := os.WriteFile(tempPath, data, 0644)
+	if err == nil {
+		err = os.Rename(tempPath, path)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("ERROR::Can't write resume state %s: %s", path, err)
+	}
+
+	return nil
+}
+
+// Function drops sources crawled before the stopped one and starts the stopped one from its saved page
+func resumeSources(sources []crawlSource, state *crawlState) ([]crawlSource, error) {
+	for i, source := range sources {
+		if source.Name == state.Source {
+			resumed := append([]crawlSource{}, sources[i:]...)
+			resumed[0].URL = state.PageURL
+			return resumed, nil
+		}
+	}
+
+	return nil, fmt.Errorf("ERROR::Resume state is for source %s, which is not crawled by this run. Remove %s or run with the same sources", state.Source, resumeFileName)
+}
+
+// Function saves the point the crawl stopped at together with the written items, or removes the state when the crawl
+// went through all sources
+func saveResumeState(path string, previous *crawlState, stopped *crawlState, written *idSet) error {
+	if stopped == nil {
+		err := os.Remove(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("ERROR::Can't remove resume state %s: %s", path, err)
+		}
+		return nil
+	}
+
+	//Stopped again in the resumed source, its pages add up
+	if previous != nil && previous.Source == stopped.Source {
+		stopped.Pages += previous.Pages
+	}
+	stopped.Items = written.BySource()
+
+	err := stopped.Save(path)
+	if err != nil {
+		return err
+	}
+	slog.Info("Saved resume point, run again with -resume to continue", "source", stopped.Source, "url", stopped.PageURL, "pages_done", stopped.Pages)
+
+	return nil
+}
+
+// Function returns key of the item written by the source. Items found by several sources are kept once per source,
+// so the item ID alone would skip the item of the next source
+func writtenKey(source string, itemID string) string {
+	return source + "\x00" + itemID
+}
+
+// Set of item IDs safe for concurrent use
+type idSet struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+// Function creates set of written items of the crawl state, empty one when state is nil
+func newIDSet(state *crawlState) *idSet {
+	set := &idSet{ids: map[string]bool{}}
+	if state == nil {
+		return set
+	}
+
+	for source, ids := range state.Items {
+		for _, id := range ids {
+			set.ids[writtenKey(source, id)] = true
+		}
+	}
+	for _, id := range state.ItemIDs {
+		set.ids[writtenKey(state.Source, id)] = true
+	}
+
+	return set
+}
+
+func (s *idSet) Has(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ids[id]
+}
+
+func (s *idSet) Add(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ids[id] = true
+}
+
+// Function returns item IDs of the set by source, in sorted order
+func (s *idSet) BySource() map[string][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bySource := map[string][]string{}
+	for key := range s.ids {
+		source, id, _ := strings.Cut(key, "\x00")
+		bySource[source] = append(bySource[source], id)
+	}
+	for _, ids := range bySource {
+		sort.Strings(ids)
+	}
+
+	return bySource
+}
+
+// Function returns number of items in the set
+func (s *idSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.ids)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"ebay-crawler/crawler"
+)
+
+type countingWriter struct {
+	written []string
+}
+
+func (w *countingWriter) Write(item *crawler.ItemInfo) error {
+	w.written = append(w.written, item.Source+"/"+item.ItemID)
+	return nil
+}
+
+func (w *countingWriter) Close() error {
+	return nil
+}
+
+func TestResumeWrittenIDsBySource(t *testing.T) {
+	writer := &countingWriter{}
+	r := &runState{writer: writer, writtenIDs: newIDSet(nil)}
+
+	//The item found by the second source is written again, only its repeat in the same source is skipped
+	for _, item := range []crawler.ItemInfo{
+		{ItemID: "111", Source: "laptops"},
+		{ItemID: "111", Source: "monitors"},
+		{ItemID: "111", Source: "laptops"},
+	} {
+		if err := r.writeItem(&item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(writer.written) != 2 {
+		t.Fatalf("written %v, want item 111 once per source", writer.written)
+	}
+
+	path := filepath.Join(t.TempDir(), resumeFileName)
+	err := saveResumeState(path, nil, &crawlState{Source: "monitors", PageURL: "https://www.ebay.com/sch/i.html?_pgn=2", Pages: 1}, r.writtenIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := loadCrawlState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Items["laptops"]) != 1 || len(state.Items["monitors"]) != 1 {
+		t.Fatalf("saved items %v, want item 111 of both sources", state.Items)
+	}
+
+	//The resumed run skips what each source wrote
+	writer.written = nil
+	r.writtenIDs = newIDSet(state)
+	for _, item := range []crawler.ItemInfo{
+		{ItemID: "111", Source: "monitors"},
+		{ItemID: "111", Source: "phones"},
+	} {
+		if err := r.writeItem(&item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(writer.written) != 1 || writer.written[0] != "phones/111" {
+		t.Errorf("resumed run written %v, want only phones/111", writer.written)
+	}
+}
+
+func TestResumeStateWithoutSources(t *testing.T) {
+	set := newIDSet(&crawlState{Source: "laptops", ItemIDs: []string{"111"}})
+	if !set.Has(writtenKey("laptops", "111")) {
+		t.Error("item ID of state saved without sources isn't taken as written by its source")
+	}
+}
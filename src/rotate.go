@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Period of NDJSON output files, hour or day. Empty streams NDJSON to stdout (-rotate)
+var outputRotate string
+
+// Values of -rotate
+var rotatePeriods = []string{"hour", "day"}
+
+// Suffix of the file lines are written to until its period ends
+const rotatePartSuffix string = ".part"
+
+// Output file of NDJSON lines which starts a new file items-<time>.ndjson in the output directory once the period of
+// the current one passes. Lines go to <name>.part, renamed when the file is complete, so consumers see only complete files
+type rotatingFile struct {
+	dir    string
+	period string
+	now    func() time.Time
+
+	file        *os.File
+	path        string
+	start       time.Time
+	lineStarted bool
+}
+
+// Function creates output rotating files of the period (hour or day) in the directory
+func newRotatingFile(dir string, period string) *rotatingFile {
+	return &rotatingFile{dir: dir, period: period, now: time.Now}
+}
+
+// Function returns start of the period the time is in, in the local time zone
+func (f *rotatingFile) periodStart(t time.Time) time.Time {
+	if f.period == "day" {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
+
+// Function writes data of NDJSON lines. Files are rotated only between lines, a line split between writes of
+// a buffer stays in one file
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	now := f.now()
+	if f.file != nil && !f.lineStarted && f.periodStart(now).After(f.start) {
+		if err := f.complete(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.file == nil {
+		f.start = f.periodStart(now)
+		f.path = filepath.Join(f.dir, fmt.Sprintf("items-%s.ndjson", now.Format("20060102T150405")))
+
+		file, err := os.OpenFile(f.path+rotatePartSuffix, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return 0, fmt.Errorf("ERROR::Can't create output file %s: %s", f.path, err)
+		}
+		f.file = file
+	}
+
+	n, err := f.file.Write(p)
+	if n > 0 {
+		f.lineStarted = p[n-1] != '\n'
+	}
+
+	return n, err
+}
+
+// Function closes the current file and renames it to its final name
+func (f *rotatingFile) complete() error {
+	file := f.file
+	f.file = nil
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("ERROR::Can't write output file %s: %s", f.path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("ERROR::Can't write output file %s: %s", f.path, err)
+	}
+	if err := os.Rename(f.path+rotatePartSuffix, f.path); err != nil {
+		return fmt.Errorf("ERROR::Can't write output file %s: %s", f.path, err)
+	}
+
+	return nil
+}
+
+func (f *rotatingFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+
+	return f.complete()
+}
+
+// NDJSON writer to rotating files, the last file is completed once the lines are written
+type rotatingNDJSONWriter struct {
+	*ndjsonWriter
+	file *rotatingFile
+}
+
+// Function creates NDJSON writer to files of the period in the output directory
+func newRotatingNDJSONWriter(file *rotatingFile, bufferSize int) *rotatingNDJSONWriter {
+	return &rotatingNDJSONWriter{ndjsonWriter: newNDJSONWriter(file, bufferSize), file: file}
+}
+
+func (w *rotatingNDJSONWriter) Close() error {
+	err := w.ndjsonWriter.Close()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
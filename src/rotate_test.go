@@ -0,0 +1,173 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ebay-crawler/crawler"
+)
+
+// Function returns names of files of the directory and numbers of their lines
+func dirLines(t *testing.T, dir string) map[string]int {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := map[string]int{}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines[entry.Name()] = strings.Count(string(data), "\n")
+	}
+
+	return lines
+}
+
+// Clock of rotating files read by the writer goroutine, set by the test
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+}
+
+func TestRotateBoundary(t *testing.T) {
+	dir := t.TempDir()
+
+	clock := &fakeClock{now: time.Date(2026, 10, 14, 14, 58, 30, 0, time.Local)}
+	file := newRotatingFile(dir, "hour")
+	file.now = clock.Now
+	w := newRotatingNDJSONWriter(file, 0)
+
+	//Lines are written by the writer goroutine, each is waited for before the clock moves
+	write := func(itemID string, path string, lines int) {
+		t.Helper()
+		if err := w.Write(&crawler.ItemInfo{ItemID: itemID}); err != nil {
+			t.Fatal(err)
+		}
+		waitForFile(t, filepath.Join(dir, path), lines)
+	}
+
+	write("111", "items-20261014T145830.ndjson.part", 1)
+	clock.Set(time.Date(2026, 10, 14, 14, 59, 59, 0, time.Local))
+	write("222", "items-20261014T145830.ndjson.part", 2)
+	clock.Set(time.Date(2026, 10, 14, 15, 0, 5, 0, time.Local))
+	write("333", "items-20261014T150005.ndjson.part", 1)
+
+	//The file of the passed hour is complete already
+	if got := dirLines(t, dir); got["items-20261014T145830.ndjson"] != 2 || len(got) != 2 {
+		t.Errorf("got files %v during the crawl, want the complete file of 14:00 and the part of 15:00", got)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"items-20261014T145830.ndjson": 2, "items-20261014T150005.ndjson": 1}
+	if got := dirLines(t, dir); len(got) != len(want) || got["items-20261014T145830.ndjson"] != 2 || got["items-20261014T150005.ndjson"] != 1 {
+		t.Errorf("got files %v, want %v", got, want)
+	}
+}
+
+// Function waits until the file has the number of lines
+func waitForFile(t *testing.T, path string, lines int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		data, _ := os.ReadFile(path)
+		if strings.Count(string(data), "\n") == lines {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("%s doesn't have %d lines", path, lines)
+}
+
+func TestRotateKeepsLinesWhole(t *testing.T) {
+	dir := t.TempDir()
+
+	clock := time.Date(2026, 10, 14, 23, 59, 59, 0, time.Local)
+	file := newRotatingFile(dir, "day")
+	file.now = func() time.Time { return clock }
+
+	//A buffer flushed in the middle of a line, the day passes before the rest of it is written
+	for _, data := range []string{`{"item_id":"111"}` + "\n" + `{"item_id":`, `"222"}` + "\n", `{"item_id":"333"}` + "\n"} {
+		if _, err := file.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		clock = time.Date(2026, 10, 15, 0, 0, 1, 0, time.Local)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []string{}
+	for name, lines := range dirLines(t, dir) {
+		files = append(files, name)
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			if !strings.HasPrefix(line, "{") || !strings.HasSuffix(line, "}") {
+				t.Errorf("%s has split line %q of %d lines", name, line, lines)
+			}
+		}
+	}
+	slices.Sort(files)
+	if want := "items-20261014T235959.ndjson,items-20261015T000001.ndjson"; strings.Join(files, ",") != want {
+		t.Errorf("got files %v, want %s", files, want)
+	}
+}
+
+func TestRotateRun(t *testing.T) {
+	server := newPageServer(t, fixtureItemsPage("111", "222"))
+
+	dir := t.TempDir()
+	err := run([]string{"-url", server.URL + "/sch/i.html", "-output", "ndjson", "-rotate", "hour", "-output-dir", dir, "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := dirLines(t, dir)
+	if len(got) != 1 {
+		t.Fatalf("got files %v, want a single NDJSON file", got)
+	}
+	for name, lines := range got {
+		if !strings.HasPrefix(name, "items-") || !strings.HasSuffix(name, ".ndjson") || lines != 2 {
+			t.Errorf("got %s with %d lines, want items-<time>.ndjson with 2", name, lines)
+		}
+	}
+
+	tests := [][]string{
+		{"-output", "ndjson", "-rotate", "week"},
+		{"-output", "json", "-rotate", "hour"},
+		{"-output", "ndjson", "-rotate", "hour", "-stdout"},
+	}
+	for _, args := range tests {
+		err := run(append([]string{"-url", server.URL + "/sch/i.html", "-output-dir", dir}, args...))
+		if exitCode(err) != exitBadFlags {
+			t.Errorf("%v: got %v, want bad flags error", args, err)
+		}
+	}
+}
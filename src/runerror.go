@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes of the crawler
+const (
+	exitFailure         = 1   // output can't be written
+	exitBadFlags        = 2   // invalid flags or configuration
+	exitFirstPageFailed = 3   // first page can't be fetched or has no items while not being an empty search, nothing was crawled
+	exitPartial         = 4   // crawl stopped by an error after some pages, results are partial
+	exitLowSuccessRate  = 5   // share of parsed items on some page was below -min-success-rate
+	exitInterrupted     = 130 // stopped by SIGINT/SIGTERM after flushing partial results
+)
+
+// Error returned by run together with the process exit code
+type runError struct {
+	code int
+	err  error
+}
+
+func newRunError(code int, err error) error {
+	return &runError{code: code, err: err}
+}
+
+func (e *runError) Error() string {
+	return fmt.Sprintf("%s (exit code %d)", e.err, e.code)
+}
+
+func (e *runError) Unwrap() error {
+	return e.err
+}
+
+// Function returns exit code for the error returned by run
+func exitCode(err error) int {
+	var runErr *runError
+	if errors.As(err, &runErr) {
+		return runErr.code
+	}
+
+	return exitFailure
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// On-disk set of item keys seen in previous runs, one key per line
+type seenStore struct {
+	mu      sync.Mutex
+	path    string
+	ids     map[string]bool
+	added   []string
+	skipped int
+}
+
+// Function loads seen store from the provided path. Missing file means empty store
+func loadSeenStore(path string) (*seenStore, error) {
+	store := &seenStore{path: path, ids: map[string]bool{}}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("ERROR::Can't open seen database %s: %s", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		id := strings.TrimSpace(scanner.Text())
+		if id != "" {
+			store.ids[id] = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ERROR::Can't read seen database %s: %s", path, err)
+	}
+
+	return store, nil
+}
+
+// Function checks if the ID was seen before and counts it as skipped if so
+func (s *seenStore) Seen(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ids[id] {
+		s.skipped++
+		return true
+	}
+
+	return false
+}
+
+// Function marks the ID as seen
+func (s *seenStore) Add(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.ids[id] {
+		s.ids[id] = true
+		s.added = append(s.added, id)
+	}
+}
+
+// Function appends IDs added during this run to the store file
+func (s *seenStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.added) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't open seen database %s: %s", s.path, err)
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(strings.Join(s.added, "\n") + "\n")
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't write seen database %s: %s", s.path, err)
+	}
+
+	s.added = nil
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSeenDBSkipsItemsOfPreviousRuns(t *testing.T) {
+	seenDBPath := filepath.Join(t.TempDir(), "seen.txt")
+
+	//The second crawl overlaps the first one on item 102
+	first := newPageServer(t, fixtureItemsPage("101", "102"))
+	second := newPageServer(t, fixtureItemsPage("102", "103"))
+
+	if got := runDedup(t, first, "store", "id", seenDBPath); got != "101,102" {
+		t.Errorf("first run wrote %s, want 101,102", got)
+	}
+	if got := runDedup(t, second, "store", "id", seenDBPath); got != "103" {
+		t.Errorf("second run wrote %s, want only the new 103", got)
+	}
+
+	data, err := os.ReadFile(seenDBPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "101\n102\n103\n" && string(data) != "102\n101\n103\n" {
+		t.Errorf("seen database is %q, want the three IDs", data)
+	}
+}
+
+func TestSeenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen.txt")
+
+	//Missing file is an empty store
+	store, err := loadSeenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Seen("101") {
+		t.Error("empty store has seen 101")
+	}
+	store.Add("101")
+	store.Add("101")
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "101\n" {
+		t.Errorf("saved %q, want 101 once", data)
+	}
+
+	//Blank lines and spaces are ignored
+	if err := os.WriteFile(path, []byte("101\n\n 102 \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store, err = loadSeenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !store.Seen("101") || !store.Seen("102") || store.Seen("103") || store.skipped != 2 {
+		t.Errorf("store has ids %v and %d skipped, want 101 and 102 seen twice", store.ids, store.skipped)
+	}
+}
+
+func TestSeenDBFiltersCrawlResult(t *testing.T) {
+	seenDBPath := filepath.Join(t.TempDir(), "seen.txt")
+	if err := os.WriteFile(seenDBPath, []byte("102\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	server := newPageServer(t, fixtureItemsPage("101", "102"))
+
+	//Seen items are filtered out of the crawl, so the summary doesn't count them as written
+	dir := t.TempDir()
+	err := run([]string{"-seller", "store", "-base-url", server.URL, "-seen-db", seenDBPath, "-output", "json", "-output-dir", dir, "-summary", "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "summary.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := runSummary{}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.ItemsFound != 2 || summary.Filtered != 1 {
+		t.Errorf("summary found %d items and filtered %d, want 2 and the seen 102", summary.ItemsFound, summary.Filtered)
+	}
+
+	//URL only crawls skip seen items and add the new ones
+	server = newPageServer(t, fixtureItemsPage("101", "103"))
+	dir = t.TempDir()
+	err = run([]string{"-seller", "store", "-base-url", server.URL, "-seen-db", seenDBPath, "-urls-only", "-output-dir", dir, "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "urls.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "https://www.ebay.com/itm/103\n" {
+		t.Errorf("urls.txt is %q, want only the unseen 103", data)
+	}
+	data, err = os.ReadFile(seenDBPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "102\n101\n103\n" {
+		t.Errorf("seen database is %q, want 102 and the added 101 and 103", data)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"ebay-crawler/crawler"
+)
+
+// Flag value collecting every occurrence of a repeated flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Listing crawled in a run
+type crawlSource struct {
+	Name     string // tag stored in item source field: seller name, listing URL or search keywords
+	URL      string // first page URL
+	MaxItems int    // items after which the crawl of the source stops, 0 means no limit
+}
+
+// Items come from more than one source: files output goes to a directory per source and CSV gets a source column
+var multiSource bool
+
+// Function builds first page URLs of all sellers, listing URLs and the search query, sharing filter parameters.
+// Crawls of sellers stop after limitPerSeller items, 0 means no limit
+func buildSources(sellers []string, urls []string, query string, params crawler.SearchParams, limitPerSeller int) ([]crawlSource, error) {
+	sources := []crawlSource{}
+
+	for _, seller := range sellers {
+		sourceParams := params
+		sourceParams.Seller = seller
+
+		pageURL, err := crawler.BuildSearchURL(sourceParams)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, crawlSource{Name: seller, URL: pageURL, MaxItems: limitPerSeller})
+	}
+
+	for _, listingURL := range urls {
+		sourceParams := params
+		sourceParams.URL = listingURL
+
+		pageURL, err := crawler.BuildSearchURL(sourceParams)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, crawlSource{Name: listingURL, URL: pageURL})
+	}
+
+	if query != "" {
+		sourceParams := params
+		sourceParams.Query = query
+
+		pageURL, err := crawler.BuildSearchURL(sourceParams)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, crawlSource{Name: query, URL: pageURL})
+	}
+
+	return sources, nil
+}
+
+// Function crawls sources one by one, returning items and counters of all of them, and the point the first
+// stopped source can be resumed from (nil when all sources were crawled to the end).
+// A failed source doesn't stop the others, the first error is returned after all were crawled.
+// Crawl stops once maxItems items of all sources were collected, 0 means no limit
+func crawlSources(ctx context.Context, c *crawler.Crawler, sources []crawlSource, maxItems int) ([]crawler.ItemInfo, crawler.Stats, *crawlState, error) {
+	allItems := []crawler.ItemInfo{}
+	total := crawler.Stats{}
+	var stopped *crawlState
+	var firstErr error
+
+	for _, source := range sources {
+		if ctx.Err() != nil {
+			if stopped == nil {
+				stopped = &crawlState{Source: source.Name, PageURL: source.URL}
+			}
+			return allItems, total, stopped, ctx.Err()
+		}
+
+		if multiSource {
+			slog.Info("Crawling source", "source", source.Name, "url", source.URL)
+		}
+
+		//Global limit caps the source limit with the items left of it
+		limit := source.MaxItems
+		if maxItems > 0 {
+			left := maxItems - len(allItems)
+			if left <= 0 {
+				slog.Info("Reached item limit, stopping crawl", "max_items", maxItems)
+				break
+			}
+			if limit == 0 || left < limit {
+				limit = left
+			}
+		}
+
+		c.Source = source.Name
+		c.MaxItems = limit
+		items, err := c.Crawl(ctx, source.URL)
+		allItems = append(allItems, items...)
+
+		stats := c.Stats()
+		total.Pages += stats.Pages
+		total.ItemsFound += stats.ItemsFound
+		total.Failures += stats.Failures
+		total.Duplicates += stats.Duplicates
+		total.Filtered += stats.Filtered
+		total.LowSuccessPages += stats.LowSuccessPages
+		total.AbandonedPages += stats.AbandonedPages
+		total.BytesDownloaded += stats.BytesDownloaded
+		total.ResultCount += stats.ResultCount
+		if multiSource {
+			if stats.StoreName != "" {
+				slog.Info("Store", "source", source.Name, "name", stats.StoreName)
+			}
+		} else {
+			total.StoreName = stats.StoreName
+		}
+
+		//Pages abandoned by -page-process-timeout leave a resume point even when the crawl succeeded
+		if (err != nil || stats.AbandonedPages > 0) && stopped == nil {
+			pageURL, pages := c.ResumePoint()
+			if pageURL != "" {
+				stopped = &crawlState{Source: source.Name, PageURL: pageURL, Pages: pages}
+			}
+		}
+
+		if err != nil && ctx.Err() != nil {
+			return allItems, total, stopped, err
+		}
+		if err != nil {
+			if multiSource {
+				slog.Error("Crawl of source failed", "source", source.Name, "err", err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return allItems, total, stopped, firstErr
+}
+
+var sourceDirRegEx = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Maximal length of per-source directory name
+const maxSourceDirLen = 80
+
+// Function converts item source to the name of its output directory
+func sourceDirName(source string) string {
+	source = strings.TrimPrefix(strings.TrimPrefix(source, "https://"), "http://")
+
+	name := strings.Trim(sourceDirRegEx.ReplaceAllString(source, "_"), "_.")
+	if len(name) > maxSourceDirLen {
+		name = name[:maxSourceDirLen]
+	}
+	if name == "" {
+		name = "_"
+	}
+
+	return name
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"ebay-crawler/crawler"
+)
+
+// Test server of seller stores at /sch/<seller>/m.html, with three pages of two items each
+func newSellerServer(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seller := strings.Split(r.URL.Path, "/")[2]
+		page, _ := strconv.Atoi(r.URL.Query().Get("_pgn"))
+		page = max(page, 1)
+
+		fmt.Fprint(w, `<html><body><ul>`)
+		for i := 1; i <= 2; i++ {
+			itemID := fmt.Sprintf("%d%d%d", len(seller), page, i)
+			fmt.Fprintf(w, `<li class="s-item" id="item%[1]s"><a class="s-item__link" href="https://www.ebay.com/itm/%[1]s">`+
+				`<div class="s-item__title"><span role="heading">Item %[1]s of %[2]s</span></div></a><span class="s-item__price">$10.00</span></li>`, itemID, seller)
+		}
+		fmt.Fprint(w, `</ul>`)
+		if page < 3 {
+			fmt.Fprintf(w, `<a class="pagination__next icon-link" href="%s/sch/%s/m.html?_pgn=%d">next</a>`, server.URL, seller, page+1)
+		}
+		fmt.Fprint(w, `</body></html>`)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestLimitPerSeller(t *testing.T) {
+	server := newSellerServer(t)
+	dir := t.TempDir()
+
+	err := run([]string{"-seller", "alpha", "-seller", "beta-store", "-limit-per-seller", "3", "-base-url", server.URL,
+		"-output", "json", "-output-dir", dir, "-delay", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "items.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []crawler.ItemInfo{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatal(err)
+	}
+
+	//Each seller has six items, the limit of one doesn't take from the other
+	bySource := map[string]int{}
+	for _, item := range items {
+		bySource[item.Source]++
+	}
+	if len(items) != 6 || bySource["alpha"] != 3 || bySource["beta-store"] != 3 {
+		t.Errorf("got items by seller %v, want three of each", bySource)
+	}
+}
+
+func TestMaxItemsWithLimitPerSeller(t *testing.T) {
+	server := newSellerServer(t)
+
+	tests := []struct {
+		name string
+		args []string
+		//Items of alpha and beta-store sellers, six items each
+		wantAlpha, wantBeta int
+	}{
+		{"global limit cuts the second seller", []string{"-limit-per-seller", "3", "-max-items", "5"}, 3, 2},
+		{"global limit above seller limits", []string{"-limit-per-seller", "3", "-max-items", "10"}, 3, 3},
+		{"global limit reached by the first seller", []string{"-limit-per-seller", "3", "-max-items", "2"}, 2, 0},
+		{"global limit alone", []string{"-max-items", "8"}, 6, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			args := append([]string{"-seller", "alpha", "-seller", "beta-store", "-base-url", server.URL,
+				"-output", "json", "-output-dir", dir, "-delay", "0"}, test.args...)
+			err := run(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "items.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			items := []crawler.ItemInfo{}
+			if err := json.Unmarshal(data, &items); err != nil {
+				t.Fatal(err)
+			}
+
+			bySource := map[string]int{}
+			for _, item := range items {
+				bySource[item.Source]++
+			}
+			if bySource["alpha"] != test.wantAlpha || bySource["beta-store"] != test.wantBeta {
+				t.Errorf("got items by seller %v, want alpha %d and beta-store %d", bySource, test.wantAlpha, test.wantBeta)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"ebay-crawler/crawler"
+)
+
+// Settings of the sqlite output mode
+type sqliteOptions struct {
+	path        string // database path, <output-dir>/items.db when empty (-db)
+	vacuum      bool   // VACUUM the database when the sqlite output is closed (-vacuum)
+	vacuumEvery int    // VACUUM the database after every N upserts, 0 disables periodic vacuum (-vacuum-every)
+	journalMode string // journal mode of the database connection, empty keeps SQLite default
+	synchronous string // synchronous setting of the database connection, empty keeps SQLite default
+}
+
+// Values of PRAGMA journal_mode and PRAGMA synchronous accepted by -sqlite-journal-mode and -sqlite-synchronous
+var sqliteJournalModes = []string{"delete", "truncate", "persist", "memory", "wal", "off"}
+var sqliteSynchronousModes = []string{"off", "normal", "full", "extra"}
+
+// Items found by several sources are kept once per source, like in the other outputs
+const createItemsTable string = `CREATE TABLE IF NOT EXISTS items (
+	item_id     TEXT NOT NULL,
+	source      TEXT NOT NULL DEFAULT '',
+	title       TEXT NOT NULL,
+	condition   TEXT NOT NULL,
+	price       TEXT NOT NULL,
+	product_url TEXT NOT NULL,
+	crawled_at  TEXT NOT NULL,
+	PRIMARY KEY (item_id, source)
+)`
+
+const upsertItem string = `INSERT INTO items (item_id, source, title, condition, price, product_url, crawled_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(item_id, source) DO UPDATE SET
+	title = excluded.title,
+	condition = excluded.condition,
+	price = excluded.price,
+	product_url = excluded.product_url,
+	crawled_at = excluded.crawled_at`
+
+// Writer upserting items into SQLite items table, writes of concurrent workers are serialized by the mutex
+type sqliteWriter struct {
+	mu      sync.Mutex
+	db      *sql.DB
+	options sqliteOptions
+	upserts int
+}
+
+// Function opens SQLite database, creating it and the items table when missing
+func newSQLiteWriter(path string, options sqliteOptions) (*sqliteWriter, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0775)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't create directory of database %s: %s", path, err)
+	}
+
+	dsn, err := sqliteDSN(path, options)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't open database %s: %s", path, err)
+	}
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(createItemsTable)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ERROR::Can't create items table in %s: %s", path, err)
+	}
+
+	return &sqliteWriter{db: db, options: options}, nil
+}
+
+func (w *sqliteWriter) Write(item *crawler.ItemInfo) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	crawledAt := item.CrawledAt
+	if crawledAt == "" {
+		crawledAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	_, err := w.db.Exec(upsertItem, item.ItemID, item.Source, item.Title, item.Condition, item.Price, item.ProductURL, crawledAt)
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't write item %s to database: %s", item.ItemID, err)
+	}
+
+	w.upserts++
+	if w.options.vacuumEvery > 0 && w.upserts%w.options.vacuumEvery == 0 {
+		w.vacuum()
+	}
+
+	return nil
+}
+
+// Function returns data source name of the database, with PRAGMA settings applied to each connection it opens
+func sqliteDSN(path string, options sqliteOptions) (string, error) {
+	pragmas := url.Values{}
+
+	if options.journalMode != "" {
+		if !slices.Contains(sqliteJournalModes, strings.ToLower(options.journalMode)) {
+			return "", fmt.Errorf("ERROR::Unknown SQLite journal mode %s. Possible values are: %s", options.journalMode, strings.Join(sqliteJournalModes, ", "))
+		}
+		pragmas.Add("_pragma", "journal_mode("+options.journalMode+")")
+	}
+
+	if options.synchronous != "" {
+		if !slices.Contains(sqliteSynchronousModes, strings.ToLower(options.synchronous)) {
+			return "", fmt.Errorf("ERROR::Unknown SQLite synchronous setting %s. Possible values are: %s", options.synchronous, strings.Join(sqliteSynchronousModes, ", "))
+		}
+		pragmas.Add("_pragma", "synchronous("+options.synchronous+")")
+	}
+
+	if len(pragmas) == 0 {
+		return path, nil
+	}
+
+	return path + "?" + pragmas.Encode(), nil
+}
+
+// Function rebuilds the database file without free pages left by replaced rows. A failed vacuum keeps the database
+// as it is, so it is logged and doesn't fail the run
+func (w *sqliteWriter) vacuum() {
+	start := time.Now()
+	before := w.size()
+
+	_, err := w.db.Exec("VACUUM")
+	if err != nil {
+		slog.Warn("Can't vacuum database", "err", err)
+		return
+	}
+
+	slog.Info("Vacuumed database", "bytes_before", before, "bytes_after", w.size(), "duration", time.Since(start))
+}
+
+// Function returns size of the database in bytes, counted in pages so it works for in-memory databases too
+func (w *sqliteWriter) size() int64 {
+	var size int64
+	_ = w.db.QueryRow(`SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`).Scan(&size)
+
+	return size
+}
+
+func (w *sqliteWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.options.vacuum {
+		w.vacuum()
+	}
+
+	return w.db.Close()
+}
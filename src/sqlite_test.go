@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ebay-crawler/crawler"
+)
+
+func TestSQLiteWriterCrawledItems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><ul>`+
+			`<li class="s-item" id="item1"><a class="s-item__link" href="https://www.ebay.com/itm/111"><div class="s-item__title"><span role="heading">Dell Laptop</span></div></a><span class="s-item__price">$10.00</span></li>`+
+			`<li class="s-item" id="item2"><a class="s-item__link" href="https://www.ebay.com/itm/222"><div class="s-item__title"><span role="heading">HP Monitor</span></div></a><span class="s-item__price">$25.50</span></li>`+
+			`</ul></body></html>`)
+	}))
+	defer server.Close()
+
+	writer, err := newSQLiteWriter(":memory:", sqliteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	//Both sources find the same items, each keeps its own rows
+	for _, source := range []string{"laptops", "monitors"} {
+		c := &crawler.Crawler{Workers: 2}
+		c.OnItem = func(item *crawler.ItemInfo) error {
+			item.Source = source
+			return writer.Write(item)
+		}
+
+		_, err := c.Crawl(context.Background(), server.URL+"/sch/i.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows := 0
+	err = writer.db.QueryRow(`SELECT COUNT(*) FROM items`).Scan(&rows)
+	if err != nil || rows != 4 {
+		t.Fatalf("got %d rows (%v), want an item 111 and 222 row per source", rows, err)
+	}
+
+	var title, price, crawledAt string
+	err = writer.db.QueryRow(`SELECT title, price, crawled_at FROM items WHERE item_id = '222' AND source = 'monitors'`).Scan(&title, &price, &crawledAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "HP Monitor" || price != "25.50" || crawledAt == "" {
+		t.Errorf("got row %q %q %q", title, price, crawledAt)
+	}
+}
+
+func TestSQLiteWriterUpsert(t *testing.T) {
+	writer, err := newSQLiteWriter(":memory:", sqliteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	first := crawler.ItemInfo{ItemID: "111", Source: "laptops", Title: "Dell Laptop", Price: "10.00", CrawledAt: "2024-01-01T00:00:00Z"}
+	second := first
+	second.Price, second.CrawledAt = "9.00", "2024-01-02T00:00:00Z"
+
+	for _, item := range []crawler.ItemInfo{first, second} {
+		if err := writer.Write(&item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var rows int
+	var price, crawledAt string
+	err = writer.db.QueryRow(`SELECT COUNT(*), MAX(price), MAX(crawled_at) FROM items`).Scan(&rows, &price, &crawledAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 1 || price != "9.00" || crawledAt != second.CrawledAt {
+		t.Errorf("got %d rows with price %s crawled at %s, want the second crawl upserted", rows, price, crawledAt)
+	}
+}
+
+// Function returns size of the database file
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return info.Size()
+}
+
+func TestSQLiteWriterVacuum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.db")
+	writer, err := newSQLiteWriter(path, sqliteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	title := strings.Repeat("Dell Laptop ", 100)
+	for i := 0; i < 1000; i++ {
+		err := writer.Write(&crawler.ItemInfo{ItemID: fmt.Sprint(i), Title: title, Price: "10.00"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//Deleted rows leave free pages, the file keeps its size until it is vacuumed
+	if _, err := writer.db.Exec(`DELETE FROM items`); err != nil {
+		t.Fatal(err)
+	}
+	before := fileSize(t, path)
+
+	writer.vacuum()
+	if after := fileSize(t, path); after >= before {
+		t.Errorf("file has %d bytes after vacuum, %d before", after, before)
+	}
+}
+
+func TestSQLiteWriterVacuumEvery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.db")
+	writer, err := newSQLiteWriter(path, sqliteOptions{vacuumEvery: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	title := strings.Repeat("Dell Laptop ", 100)
+	for i := 0; i < 500; i++ {
+		writer.Write(&crawler.ItemInfo{ItemID: fmt.Sprint(i), Title: title})
+	}
+	writer.db.Exec(`DELETE FROM items`)
+	deleted := fileSize(t, path)
+
+	//The 10th upsert after the delete vacuums the free pages away
+	for i := 0; i < 10; i++ {
+		writer.Write(&crawler.ItemInfo{ItemID: fmt.Sprint(i)})
+	}
+	if size := fileSize(t, path); size >= deleted {
+		t.Errorf("file has %d bytes after periodic vacuum, %d before", size, deleted)
+	}
+}
+
+func TestSQLiteWriterPragmas(t *testing.T) {
+	writer, err := newSQLiteWriter(filepath.Join(t.TempDir(), "items.db"), sqliteOptions{journalMode: "wal", synchronous: "normal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+
+	var journalMode string
+	var synchronous int
+	writer.db.QueryRow(`PRAGMA journal_mode`).Scan(&journalMode)
+	writer.db.QueryRow(`PRAGMA synchronous`).Scan(&synchronous)
+	if journalMode != "wal" || synchronous != 1 {
+		t.Errorf("journal mode %s synchronous %d, want wal and 1 (normal)", journalMode, synchronous)
+	}
+}
+
+func TestSQLiteWriterUnknownPragma(t *testing.T) {
+	_, err := newSQLiteWriter(filepath.Join(t.TempDir(), "items.db"), sqliteOptions{synchronous: "fast"})
+	if err == nil || !strings.Contains(err.Error(), "Unknown SQLite synchronous setting") {
+		t.Errorf("got %v, want unknown synchronous setting error", err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const statsdPrefix string = "ebay_crawler"
+
+// Run counters reported to StatsD when the crawl is finished
+type runStats struct {
+	Pages      int
+	ItemsFound int
+	Failures   int
+	Duration   time.Duration
+}
+
+// Function sends run counters to StatsD over UDP. Errors are returned so they can be reported, but must not fail the run
+func sendStatsD(addr string, stats runStats) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't connect to StatsD %s: %s", addr, err)
+	}
+	defer conn.Close()
+
+	metrics := []string{
+		fmt.Sprintf("%s.pages:%d|g", statsdPrefix, stats.Pages),
+		fmt.Sprintf("%s.items_found:%d|g", statsdPrefix, stats.ItemsFound),
+		fmt.Sprintf("%s.failures:%d|c", statsdPrefix, stats.Failures),
+		fmt.Sprintf("%s.duration:%d|ms", statsdPrefix, stats.Duration.Milliseconds()),
+	}
+
+	_, err = conn.Write([]byte(strings.Join(metrics, "\n")))
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't send metrics to StatsD %s: %s", addr, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendStatsD(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	stats := runStats{Pages: 3, ItemsFound: 42, Failures: 1, Duration: 1500 * time.Millisecond}
+	if err := sendStatsD(listener.LocalAddr().String(), stats); err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := make([]byte, 1024)
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := listener.ReadFrom(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "ebay_crawler.pages:3|g\nebay_crawler.items_found:42|g\nebay_crawler.failures:1|c\nebay_crawler.duration:1500|ms"
+	if got := string(buffer[:n]); got != want {
+		t.Errorf("got packet %q, want %q", got, want)
+	}
+}
+
+func TestSendStatsDInvalidAddress(t *testing.T) {
+	err := sendStatsD("127.0.0.1", runStats{})
+	if err == nil || !strings.HasPrefix(err.Error(), "ERROR::Can't connect to StatsD") {
+		t.Errorf("got %v, want connect error", err)
+	}
+}
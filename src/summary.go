@@ -0,0 +1,101 @@
+package main
+
+import (
+	"log/slog"
+	"math"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"ebay-crawler/crawler"
+)
+
+// Overview of a finished or interrupted run
+type runSummary struct {
+	StoreName    string  `json:"store_name,omitempty"`
+	Pages        int     `json:"pages"`
+	ItemsFound   int     `json:"items_found"`
+	ItemsWritten int64   `json:"items_written"`
+	Existing     int64   `json:"skipped_existing,omitempty"`
+	Duplicates   int     `json:"duplicates_skipped"`
+	Filtered     int     `json:"filtered_out"`
+	Failures     int     `json:"failures"`
+	LowSuccess   int     `json:"low_success_pages,omitempty"`
+	Bytes        int64   `json:"bytes_downloaded"`
+	MinPrice     float64 `json:"min_price"`
+	MaxPrice     float64 `json:"max_price"`
+	AvgPrice     float64 `json:"avg_price"`
+	Elapsed      string  `json:"elapsed"`
+	Interrupted  bool    `json:"interrupted,omitempty"`
+}
+
+// Function builds run summary from crawl counters, output counters of the run and prices of collected items
+func newRunSummary(stats crawler.Stats, items []crawler.ItemInfo, state *runState, elapsed time.Duration, interrupted bool) runSummary {
+	summary := runSummary{
+		StoreName:    stats.StoreName,
+		Pages:        stats.Pages,
+		ItemsFound:   stats.ItemsFound,
+		ItemsWritten: state.itemsWritten.Load(),
+		Existing:     state.itemsSkippedExisting.Load(),
+		Duplicates:   stats.Duplicates,
+		Filtered:     stats.Filtered,
+		Failures:     stats.Failures,
+		LowSuccess:   stats.LowSuccessPages,
+		Bytes:        stats.BytesDownloaded,
+		Elapsed:      elapsed.Round(time.Millisecond).String(),
+		Interrupted:  interrupted,
+	}
+
+	priced := 0
+	total := 0.0
+	for _, item := range items {
+		price, err := strconv.ParseFloat(item.Price, 64)
+		if err != nil {
+			continue
+		}
+
+		if priced == 0 || price < summary.MinPrice {
+			summary.MinPrice = price
+		}
+		if priced == 0 || price > summary.MaxPrice {
+			summary.MaxPrice = price
+		}
+
+		total += price
+		priced++
+	}
+
+	if priced > 0 {
+		summary.AvgPrice = math.Round(total/float64(priced)*100) / 100
+	}
+
+	return summary
+}
+
+// Function logs the summary
+func (s runSummary) Log() {
+	slog.Info("Run summary",
+		"store_name", s.StoreName,
+		"pages", s.Pages,
+		"items_found", s.ItemsFound,
+		"items_written", s.ItemsWritten,
+		"skipped_existing", s.Existing,
+		"duplicates_skipped", s.Duplicates,
+		"filtered_out", s.Filtered,
+		"failures", s.Failures,
+		"low_success_pages", s.LowSuccess,
+		"bytes_downloaded", s.Bytes,
+		"min_price", s.MinPrice,
+		"max_price", s.MaxPrice,
+		"avg_price", s.AvgPrice,
+		"elapsed", s.Elapsed,
+		"interrupted", s.Interrupted,
+	)
+}
+
+// Function writes the summary to summary.json in the output directory
+func (s runSummary) Write() error {
+	summaryJSON, _ := marshalOutputJSON(s)
+
+	return writeOutputFile(filepath.Join(outputDir, "summary.json"), summaryJSON)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ebay-crawler/crawler"
+)
+
+func TestRunSummaryStoreName(t *testing.T) {
+	tests := []struct {
+		name      string
+		storeName string
+		wantJSON  string
+	}{
+		{"seller crawl", "Garland Computer", `"store_name":"Garland Computer"`},
+		{"search", "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			summary := newRunSummary(crawler.Stats{Pages: 1, StoreName: test.storeName}, nil, new(runState), time.Second, false)
+			if summary.StoreName != test.storeName {
+				t.Errorf("store name = %q, want %q", summary.StoreName, test.storeName)
+			}
+
+			summaryJSON, err := json.Marshal(summary)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.wantJSON == "" && strings.Contains(string(summaryJSON), "store_name") {
+				t.Errorf("search summary %s has store_name", summaryJSON)
+			}
+			if test.wantJSON != "" && !strings.Contains(string(summaryJSON), test.wantJSON) {
+				t.Errorf("summary %s has no %s", summaryJSON, test.wantJSON)
+			}
+		})
+	}
+}
+
+func TestRunSummaryCountsPerRun(t *testing.T) {
+	server := newPageServer(t, fixtureItemsPage("101", "102"))
+
+	//Counters belong to the run, so a second run in the same process doesn't add up to the first one
+	for i := 1; i <= 2; i++ {
+		dir := t.TempDir()
+		err := run([]string{"-seller", "store", "-base-url", server.URL, "-output", "json", "-output-dir", dir, "-summary", "-delay", "0"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "summary.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		summary := runSummary{}
+		if err := json.Unmarshal(data, &summary); err != nil {
+			t.Fatal(err)
+		}
+		if summary.ItemsWritten != 2 {
+			t.Errorf("run %d wrote %d items, want 2", i, summary.ItemsWritten)
+		}
+	}
+}
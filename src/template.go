@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/template"
+
+	"ebay-crawler/crawler"
+)
+
+// Escapes usable in -template, so a tab or newline can be typed in shell single quotes
+var templateEscapes = strings.NewReplacer(`\t`, "\t", `\n`, "\n")
+
+// Writer rendering each item with a text/template into one line of out
+type templateWriter struct {
+	mu       sync.Mutex
+	out      io.Writer
+	template *template.Template
+}
+
+// Function parses -template value and checks it against ItemInfo, so errors are reported before crawling starts
+func newTemplateWriter(text string, out io.Writer) (*templateWriter, error) {
+	itemTemplate, err := template.New("item").Parse(templateEscapes.Replace(text))
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't parse template %q: %s", text, err)
+	}
+
+	//Rendering an empty item catches unknown fields before the crawl
+	err = itemTemplate.Execute(io.Discard, new(crawler.ItemInfo))
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Invalid template %q: %s", text, err)
+	}
+
+	return &templateWriter{out: out, template: itemTemplate}, nil
+}
+
+func (w *templateWriter) Write(item *crawler.ItemInfo) error {
+	line := new(bytes.Buffer)
+
+	err := w.template.Execute(line, item)
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't render template for item %s: %s", item.ItemID, err)
+	}
+	line.WriteByte('\n')
+
+	data, err := encodeOutput(line.Bytes())
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err = w.out.Write(data)
+	if err != nil {
+		return fmt.Errorf("ERROR::Can't write template output: %s", err)
+	}
+
+	return nil
+}
+
+func (w *templateWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"ebay-crawler/crawler"
+)
+
+// Numeric flags which must not be negative, with the value name used in errors
+var nonNegativeFlags = []struct{ name, what string }{
+	{"min-price", "Minimal price"},
+	{"max-price", "Maximal price"},
+	{"max-pages", "Maximum number of pages"},
+	{"max-items", "Maximum number of items"},
+	{"sample", "Sample size"},
+	{"buffer-size", "Buffer size"},
+	{"split-size", "Split size"},
+	{"vacuum-every", "Vacuum interval"},
+	{"max-enrich-failures", "Maximum number of enrichment failures"},
+	{"limit-per-seller", "Item limit per seller"},
+	{"min-photos", "Minimal number of photos"},
+	{"alert-drop", "Price drop alert threshold"},
+	{"generate", "Number of generated items"},
+	{"min-items-per-page", "Minimal number of items per page"},
+	{"parallel-parse", "Number of parallel parsers"},
+	{"delay", "Delay"},
+	{"rpm", "Requests per minute"},
+	{"rps", "Requests per second"},
+	{"retries", "Number of retries"},
+	{"timeout", "Request timeout"},
+	{"page-timeout", "Page timeout"},
+	{"run-timeout", "Run timeout"},
+	{"page-process-timeout", "Page process timeout"},
+}
+
+// Numeric flags which must be positive, with the value name used in errors
+var positiveFlags = []struct{ name, what string }{
+	{"workers", "Number of workers"},
+	{"max-body-size", "Maximum body size"},
+}
+
+// Class name flags of item card elements, an empty class would match any element
+var selectorFlags = []string{"item-class", "link-class", "title-class", "subtitle-class", "condition-class"}
+
+// Function checks ranges and combinations of parsed flags, including values of the config file. It runs before
+// anything is fetched or written, the returned error is reported with the usage
+func validateFlags(fs *flag.FlagSet) error {
+	for _, f := range nonNegativeFlags {
+		if flagNumber(fs, f.name) < 0 {
+			return fmt.Errorf("ERROR::%s must not be negative, got %s", f.what, fs.Lookup(f.name).Value)
+		}
+	}
+
+	for _, f := range positiveFlags {
+		if flagNumber(fs, f.name) <= 0 {
+			return fmt.Errorf("ERROR::%s must be positive, got %s", f.what, fs.Lookup(f.name).Value)
+		}
+	}
+
+	for _, name := range selectorFlags {
+		if strings.TrimSpace(flagString(fs, name)) == "" {
+			return fmt.Errorf("ERROR::-%s must not be empty", name)
+		}
+	}
+
+	minPrice, maxPrice := flagNumber(fs, "min-price"), flagNumber(fs, "max-price")
+	if maxPrice > 0 && minPrice > maxPrice {
+		return fmt.Errorf("ERROR::Minimal price %.2f is greater than maximal price %.2f", minPrice, maxPrice)
+	}
+
+	if rate := flagNumber(fs, "min-success-rate"); rate < 0 || rate > 1 {
+		return fmt.Errorf("ERROR::Minimal success rate must be between 0 and 1, got %g", rate)
+	}
+
+	if isFlagSet(fs, "domain") && isFlagSet(fs, "base-url") {
+		return fmt.Errorf("ERROR::-domain and -base-url both set the eBay site, use one of them")
+	}
+
+	if _, err := crawler.DomainBaseURL(flagString(fs, "domain")); err != nil {
+		return err
+	}
+
+	if isFlagSet(fs, "rpm") && isFlagSet(fs, "rps") {
+		return fmt.Errorf("ERROR::-rpm and -rps set the same request rate limit, use one of them")
+	}
+
+	if flagString(fs, "follow-variations") == "true" && flagString(fs, "enrich") != "true" {
+		return fmt.Errorf("ERROR::-follow-variations reads variations from detail pages and requires -enrich")
+	}
+
+	if flagString(fs, "retry-failed-items") == "true" && flagString(fs, "enrich") != "true" {
+		return fmt.Errorf("ERROR::-retry-failed-items retries detail pages and requires -enrich")
+	}
+
+	if flagNumber(fs, "max-enrich-failures") > 0 && flagString(fs, "enrich") != "true" {
+		return fmt.Errorf("ERROR::-max-enrich-failures counts detail page failures and requires -enrich")
+	}
+
+	if flagNumber(fs, "min-photos") > 0 && flagString(fs, "enrich") != "true" {
+		return fmt.Errorf("ERROR::-min-photos counts gallery photos of detail pages and requires -enrich")
+	}
+
+	if flagString(fs, "strict-photos") == "true" && flagNumber(fs, "min-photos") == 0 {
+		return fmt.Errorf("ERROR::-strict-photos applies to -min-photos, which isn't set")
+	}
+
+	if isFlagSet(fs, "rpm") && isFlagSet(fs, "delay") {
+		return fmt.Errorf("ERROR::-rpm and -delay both pace page requests, use one of them")
+	}
+
+	seller, url, query := flagString(fs, "seller"), flagString(fs, "url"), flagString(fs, "query")
+	watch := flagString(fs, "item-id") != "" || flagString(fs, "watch-ids-file") != ""
+	if watch {
+		if seller != "" || url != "" || query != "" || flagNumber(fs, "generate") > 0 {
+			return fmt.Errorf("ERROR::-item-id and -watch-ids-file fetch item pages directly and can't be combined with -seller, -url, -query or -generate")
+		}
+
+		if flagString(fs, "resume") == "true" {
+			return fmt.Errorf("ERROR::-item-id and -watch-ids-file fetch item pages directly and can't be used with -resume")
+		}
+	} else if flagNumber(fs, "generate") > 0 {
+		if seller != "" || url != "" || query != "" {
+			return fmt.Errorf("ERROR::-generate writes fake items without crawling and can't be combined with -seller, -url or -query")
+		}
+
+		if flagString(fs, "resume") == "true" || flagString(fs, "urls-only") == "true" {
+			return fmt.Errorf("ERROR::-generate writes fake items without crawling and can't be used with -resume or -urls-only")
+		}
+	} else if seller == "" && url == "" && query == "" {
+		return fmt.Errorf("ERROR::One of -seller, -url, -query, -item-id or -watch-ids-file must be provided")
+	}
+	if query != "" && (seller != "" || url != "") {
+		return fmt.Errorf("ERROR::-query can't be combined with -seller or -url")
+	}
+
+	output := flagString(fs, "output")
+	template := flagString(fs, "template") != ""
+	resume := flagString(fs, "resume") == "true"
+
+	if template && isFlagSet(fs, "output") {
+		return fmt.Errorf("ERROR::-template writes items to stdout and can't be combined with -output")
+	}
+
+	if outputStdout {
+		if !template && (output == "files" || output == "sqlite") {
+			return fmt.Errorf("ERROR::-stdout writes a single output and can't be used with -output %s", output)
+		}
+
+		if flagString(fs, "summary") == "true" || resume || flagString(fs, "compare-prices") != "" {
+			return fmt.Errorf("ERROR::-stdout creates no files and can't be used with -summary, -resume or -compare-prices")
+		}
+	}
+
+	filesOutput := output == "files" && !template
+	if incrementalOutput && !filesOutput {
+		return fmt.Errorf("ERROR::-incremental works only with -output files")
+	}
+
+	if noOverwrite && !filesOutput {
+		return fmt.Errorf("ERROR::-no-overwrite works only with -output files")
+	}
+
+	if outputRotate != "" && !slices.Contains(rotatePeriods, outputRotate) {
+		return fmt.Errorf("ERROR::Unknown rotation period %s. Possible values are: %s", outputRotate, strings.Join(rotatePeriods, ", "))
+	}
+
+	if outputRotate != "" && (output != "ndjson" || template || outputStdout) {
+		return fmt.Errorf("ERROR::-rotate writes NDJSON files and works only with -output ndjson, without -stdout")
+	}
+
+	if outputSplitSize > 0 && (output != "json" || template || outputStdout || flagString(fs, "urls-only") == "true") {
+		return fmt.Errorf("ERROR::-split-size splits the items.json array and works only with -output json, without -stdout or -urls-only")
+	}
+
+	sqliteOutput := output == "sqlite" && !template
+	sqliteFlags := flagString(fs, "vacuum") == "true" || flagNumber(fs, "vacuum-every") > 0 || flagString(fs, "sqlite-journal-mode") != "" || flagString(fs, "sqlite-synchronous") != ""
+	if !sqliteOutput && sqliteFlags {
+		return fmt.Errorf("ERROR::-vacuum, -vacuum-every, -sqlite-journal-mode and -sqlite-synchronous work only with -output sqlite")
+	}
+
+	if incrementalOutput && noOverwrite {
+		return fmt.Errorf("ERROR::-incremental rewrites changed items and can't be used with -no-overwrite")
+	}
+
+	if incrementalOutput && noClobber {
+		return fmt.Errorf("ERROR::-incremental rewrites changed items and can't be used with -no-clobber")
+	}
+
+	if compactJSON && isFlagSet(fs, "json-indent") {
+		return fmt.Errorf("ERROR::-compact writes JSON without indentation and can't be combined with -json-indent")
+	}
+
+	if flagNumber(fs, "alert-drop") > 0 && flagString(fs, "compare-prices") == "" {
+		return fmt.Errorf("ERROR::-alert-drop compares prices with the previous run and requires -compare-prices")
+	}
+
+	if flagNumber(fs, "alert-drop") > 100 {
+		return fmt.Errorf("ERROR::Price drop alert threshold must not be greater than 100 percent")
+	}
+
+	if flagString(fs, "alert-webhook") != "" && flagNumber(fs, "alert-drop") == 0 {
+		return fmt.Errorf("ERROR::-alert-webhook posts price drop alerts and requires -alert-drop")
+	}
+
+	if flagString(fs, "compare-prices") != "" && flagString(fs, "urls-only") == "true" {
+		return fmt.Errorf("ERROR::-urls-only collects no prices and can't be used with -compare-prices")
+	}
+
+	//Files of these outputs hold only items of the last run
+	if resume && (flagString(fs, "urls-only") == "true" || (!template && (output == "json" || output == "csv" || output == "grouped-by-location"))) {
+		return fmt.Errorf("ERROR::-resume works only with files, ndjson or sqlite output and -template, which keep items of previous runs")
+	}
+
+	return nil
+}
+
+// Function returns value of a numeric flag (int, int64, float64 or duration) as float64
+func flagNumber(fs *flag.FlagSet, name string) float64 {
+	switch value := fs.Lookup(name).Value.(flag.Getter).Get().(type) {
+	case int:
+		return float64(value)
+	case int64:
+		return float64(value)
+	case float64:
+		return value
+	case time.Duration:
+		return float64(value)
+	}
+
+	return 0
+}
+
+// Function returns value of a flag as it is shown in usage
+func flagString(fs *flag.FlagSet, name string) string {
+	return fs.Lookup(name).Value.String()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no source", []string{}, "One of -seller, -url, -query"},
+		{"negative min price", []string{"-query", "laptop", "-min-price", "-1"}, "Minimal price must not be negative"},
+		{"negative max pages", []string{"-query", "laptop", "-max-pages", "-2"}, "Maximum number of pages must not be negative"},
+		{"negative retries", []string{"-query", "laptop", "-retries", "-1"}, "Number of retries must not be negative"},
+		{"negative delay", []string{"-query", "laptop", "-delay", "-1s"}, "Delay must not be negative"},
+		{"zero workers", []string{"-query", "laptop", "-workers", "0"}, "Number of workers must be positive"},
+		{"zero max body size", []string{"-query", "laptop", "-max-body-size", "0"}, "Maximum body size must be positive"},
+		{"empty selector", []string{"-query", "laptop", "-item-class", " "}, "-item-class must not be empty"},
+		{"min price over max price", []string{"-query", "laptop", "-min-price", "20", "-max-price", "10"}, "is greater than maximal price"},
+		{"success rate over 1", []string{"-query", "laptop", "-min-success-rate", "1.5"}, "between 0 and 1"},
+		{"domain with base URL", []string{"-query", "laptop", "-domain", "ebay.de", "-base-url", "http://127.0.0.1"}, "-domain and -base-url"},
+		{"unknown domain", []string{"-query", "laptop", "-domain", "ebay.example"}, "ebay.example"},
+		{"rpm with rps", []string{"-query", "laptop", "-rpm", "30", "-rps", "1"}, "-rpm and -rps"},
+		{"rpm with delay", []string{"-query", "laptop", "-rpm", "30", "-delay", "1s"}, "-rpm and -delay"},
+		{"variations without enrich", []string{"-query", "laptop", "-follow-variations"}, "requires -enrich"},
+		{"strict photos without min photos", []string{"-query", "laptop", "-strict-photos"}, "-strict-photos applies to -min-photos"},
+		{"query with seller", []string{"-query", "laptop", "-seller", "garlandcomputer"}, "-query can't be combined"},
+		{"generate with query", []string{"-generate", "5", "-query", "laptop"}, "-generate writes fake items"},
+		{"item ID with seller", []string{"-item-id", "123", "-seller", "garlandcomputer"}, "-item-id and -watch-ids-file"},
+		{"template with output", []string{"-query", "laptop", "-template", "{{.Title}}", "-output", "json"}, "-template writes items to stdout"},
+		{"incremental with json", []string{"-query", "laptop", "-incremental", "-output", "json"}, "-incremental works only with -output files"},
+		{"unknown rotation", []string{"-query", "laptop", "-output", "ndjson", "-rotate", "weekly"}, "Unknown rotation period"},
+		{"split size with csv", []string{"-query", "laptop", "-output", "csv", "-split-size", "10"}, "-split-size splits"},
+		{"vacuum with files", []string{"-query", "laptop", "-vacuum"}, "work only with -output sqlite"},
+		{"compact with indent", []string{"-query", "laptop", "-compact", "-json-indent", "2"}, "-compact writes JSON"},
+		{"alert drop without compare", []string{"-query", "laptop", "-alert-drop", "10"}, "requires -compare-prices"},
+		{"resume with json", []string{"-query", "laptop", "-resume", "-output", "json"}, "-resume works only"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := run(test.args)
+			if exitCode(err) != exitBadFlags || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got %v, want bad flags error containing %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// eBay item IDs are numbers
+var watchIDRegEx = regexp.MustCompile(`^[0-9]+$`)
+
+// Function reads item IDs of -watch-ids-file, one per line, skipping empty lines and # comments
+func readWatchIDsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR::Can't read watch IDs file %s: %s", path, err)
+	}
+	defer file.Close()
+
+	itemIDs := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		itemIDs = append(itemIDs, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ERROR::Can't read watch IDs file %s: %s", path, err)
+	}
+
+	return itemIDs, nil
+}
+
+// Function checks that watched item IDs are numbers, so they can't change the path of the item page URL
+func validateItemIDs(itemIDs []string) error {
+	for _, itemID := range itemIDs {
+		if !watchIDRegEx.MatchString(itemID) {
+			return fmt.Errorf("ERROR::Item ID %q of -item-id or -watch-ids-file is not a number", itemID)
+		}
+	}
+
+	return nil
+}